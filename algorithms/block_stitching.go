@@ -0,0 +1,213 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// A distance matrix over some of the taxa, which are named by labels shared across blocks
+type MatrixBlock struct {
+	Labels []string
+	Matrix [][]float64
+}
+
+// Result of stitching the trees of overlapping blocks into one tree
+type BlockStitching struct {
+	// Tree over all taxa, numbered in order of first appearance in the blocks and labelled
+	Tree *Graph
+	// Indices of the blocks in the order they were added
+	Order []int
+	// Distances measured in a block that the stitched tree does not realize
+	Inconsistencies []BlockInconsistency
+}
+
+// A distance between two taxa measured in a block that differs from the stitched tree
+type BlockInconsistency struct {
+	Block            int
+	Taxon1, Taxon2   int
+	Measured, InTree float64
+}
+
+// Relative difference above which a distance is reported as inconsistent with the tree
+const stitchingTolerance = 1e-6
+
+// Reconstructs a tree from distance matrices over overlapping sets of taxa. Each block is
+// reconstructed on its own, to check that it is a tree metric, and the tree of the first
+// block is extended with the taxa of the others, taking next the block that shares most taxa
+// with the tree (at least 2). A new taxon is attached where its distances to the block's
+// taxa already in the tree place it on the subtree spanned by them; its distances to taxa
+// outside the block are those implied by that position, since no block measures them.
+// Distances of a block that the tree does not realize, such as a pair of taxa from different
+// earlier blocks measured again, are reported as inconsistencies rather than errors.
+func StitchBlocks(blocks []MatrixBlock, epsilon float64) (*BlockStitching, error) {
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("at least one block is required")
+	}
+
+	var ids = make(map[string]int)
+	var labels = make([]string, 0)
+	for _, block := range blocks {
+		for _, label := range block.Labels {
+			if _, ok := ids[label]; !ok {
+				ids[label] = len(labels)
+				labels = append(labels, label)
+			}
+		}
+	}
+
+	var trees = make([]*Graph, len(blocks))
+	for i, block := range blocks {
+		tree, err := ReconstructRealTree(block.Matrix, epsilon)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", i+1, err)
+		}
+		trees[i] = tree
+	}
+
+	// Taxa take the first IDs, so internal nodes are numbered after all of them
+	var mapping = make(map[int]int)
+	for node := range trees[0].Nodes {
+		mapping[node] = len(labels) + node
+	}
+	for row, label := range blocks[0].Labels {
+		mapping[row] = ids[label]
+	}
+	tree, err := trees[0].RenumberNodes(mapping)
+	if err != nil {
+		return nil, err
+	}
+	tree.MakeTaxaExplicit()
+	tree.MaxNode = max(tree.MaxNode, len(labels)-1)
+	tree.Labels = make(map[int]string)
+	for _, label := range blocks[0].Labels {
+		tree.Labels[ids[label]] = label
+	}
+
+	var result = &BlockStitching{Tree: tree, Order: []int{0}}
+	var placed = make(map[int]bool)
+	for _, label := range blocks[0].Labels {
+		placed[ids[label]] = true
+	}
+	var added = map[int]bool{0: true}
+	for len(added) < len(blocks) {
+		var next, shared = -1, -1
+		for i, block := range blocks {
+			var count = 0
+			for _, label := range block.Labels {
+				if placed[ids[label]] {
+					count++
+				}
+			}
+			if !added[i] && count > shared {
+				next, shared = i, count
+			}
+		}
+		if shared < 2 {
+			var missing = make([]int, 0)
+			for i := range blocks {
+				if !added[i] {
+					missing = append(missing, i+1)
+				}
+			}
+			return nil, fmt.Errorf("blocks %v share fewer than 2 taxa with the others, so they cannot be placed", missing)
+		}
+
+		inconsistencies, err := addBlock(tree, blocks[next], next, ids, placed, epsilon)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", next+1, err)
+		}
+		result.Inconsistencies = append(result.Inconsistencies, inconsistencies...)
+		result.Order = append(result.Order, next)
+		added[next] = true
+	}
+
+	return result, nil
+}
+
+// Checks the distances of the block between taxa already in the tree, and inserts its other
+// taxa one at a time
+func addBlock(tree *Graph, block MatrixBlock, index int, ids map[string]int, placed map[int]bool, epsilon float64) ([]BlockInconsistency, error) {
+	var inconsistencies = make([]BlockInconsistency, 0)
+	var check = func(row1, row2 int, inTree float64) {
+		var measured = block.Matrix[row1][row2]
+		if math.Abs(measured-inTree) > stitchingTolerance*math.Max(1, measured) {
+			inconsistencies = append(inconsistencies, BlockInconsistency{
+				Block: index, Taxon1: ids[block.Labels[row1]], Taxon2: ids[block.Labels[row2]], Measured: measured, InTree: inTree,
+			})
+		}
+	}
+
+	// Rows of the block whose taxa are in the tree
+	var inTree = make([]int, 0)
+	var newRows = make([]int, 0)
+	for row, label := range block.Labels {
+		if placed[ids[label]] {
+			inTree = append(inTree, row)
+		} else {
+			newRows = append(newRows, row)
+		}
+	}
+	for i, row1 := range inTree {
+		var fromTaxon, _ = weightedDistances(tree, tree.TaxonLocation(ids[block.Labels[row1]]))
+		for _, row2 := range inTree[i+1:] {
+			check(row1, row2, fromTaxon[tree.TaxonLocation(ids[block.Labels[row2]])])
+		}
+	}
+
+	for _, row := range newRows {
+		var taxon = ids[block.Labels[row]]
+		var distances = stitchedDistances(tree, block, row, inTree, ids)
+
+		insertion, err := InsertLeaf(tree, distances, epsilon)
+		if err != nil {
+			return nil, fmt.Errorf("error inserting %s: %v", block.Labels[row], err)
+		}
+		if insertion.Duplicate {
+			var duplicates = tree.Duplicates[insertion.AttachedTo]
+			duplicates[sort.SearchInts(duplicates, insertion.Leaf)] = taxon
+			sort.Ints(duplicates)
+		} else if err := tree.RenameNode(insertion.Leaf, taxon); err != nil {
+			return nil, err
+		}
+		tree.Labels[taxon] = block.Labels[row]
+
+		for _, other := range inTree {
+			check(row, other, distances[ids[block.Labels[other]]])
+		}
+		placed[taxon] = true
+		inTree = append(inTree, row)
+	}
+
+	return inconsistencies, nil
+}
+
+// Computes the distances from the taxon of a row of the block to all taxa of the tree. The
+// taxon is attached to the subtree spanned by the block's taxa in the tree (rows inTree):
+// hanging the tree from the first of them, a, the attachment point is where the path to the
+// taxon leaves the path from a to the taxon b of the block that takes it furthest.
+func stitchedDistances(tree *Graph, block MatrixBlock, row int, inTree []int, ids map[string]int) map[int]float64 {
+	var a = ids[block.Labels[inTree[0]]]
+	var fromA, _ = weightedDistances(tree, tree.TaxonLocation(a))
+	var b, position = a, 0.0
+	for _, other := range inTree[1:] {
+		var taxon = ids[block.Labels[other]]
+		var candidate = (block.Matrix[row][inTree[0]] + fromA[tree.TaxonLocation(taxon)] - block.Matrix[row][other]) / 2
+		if candidate > position {
+			b, position = taxon, candidate
+		}
+	}
+	var fromB, _ = weightedDistances(tree, tree.TaxonLocation(b))
+	position = math.Min(position, fromA[tree.TaxonLocation(b)])
+	var pendant = math.Max(block.Matrix[row][inTree[0]]-position, 0)
+
+	// Every taxon joins the path from a to b at some point, and goes on from there to the
+	// attachment point along the path
+	var distances = make(map[int]float64)
+	for _, taxon := range tree.AllTaxa() {
+		var location = tree.TaxonLocation(taxon)
+		var join = (fromA[location] + fromA[tree.TaxonLocation(b)] - fromB[location]) / 2
+		distances[taxon] = pendant + math.Abs(position-join) + fromA[location] - join
+	}
+	return distances
+}
@@ -0,0 +1,102 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Computes the distance matrix of the tree with real-valued entries
+func floatDistanceMatrix(t *testing.T, tree *Graph) [][]float64 {
+	t.Helper()
+	intMatrix, err := CalculateDistanceMatrix(tree)
+	if err != nil {
+		t.Fatalf("CalculateDistanceMatrix returned error: %v", err)
+	}
+	matrix := make([][]float64, len(intMatrix))
+	for i, row := range intMatrix {
+		matrix[i] = make([]float64, len(row))
+		for j, distance := range row {
+			matrix[i][j] = float64(distance)
+		}
+	}
+	return matrix
+}
+
+// Builds the block of the matrix over the given rows, labelling taxa by row
+func matrixBlock(matrix [][]float64, rows []int) MatrixBlock {
+	block := MatrixBlock{Labels: make([]string, len(rows)), Matrix: make([][]float64, len(rows))}
+	for i, row := range rows {
+		block.Labels[i] = fmt.Sprintf("t%d", row)
+		block.Matrix[i] = make([]float64, len(rows))
+		for j, column := range rows {
+			block.Matrix[i][j] = matrix[row][column]
+		}
+	}
+	return block
+}
+
+func rowRange(start, end int) []int {
+	rows := make([]int, 0, end-start)
+	for row := start; row < end; row++ {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestStitchBlocks(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3} {
+		tree, err := GenerateRandomTree(40, seed, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+		matrix := floatDistanceMatrix(t, tree)
+
+		blocks := []MatrixBlock{matrixBlock(matrix, rowRange(0, 25)), matrixBlock(matrix, rowRange(15, 40))}
+		stitching, err := StitchBlocks(blocks, 1e-10)
+		if err != nil {
+			t.Fatalf("seed %d: StitchBlocks returned error: %v", seed, err)
+		}
+		if len(stitching.Inconsistencies) > 0 {
+			t.Errorf("seed %d: blocks of a tree metric reported as inconsistent: %v", seed, stitching.Inconsistencies)
+		}
+
+		// Taxa are numbered by their rows, and every distance measured in a block is realized
+		stitched := stitching.Tree
+		if taxa := stitched.AllTaxa(); len(taxa) != 40 || stitched.NodeName(39) != "t39" {
+			t.Fatalf("seed %d: expected taxa t0..t39, got %v", seed, taxa)
+		}
+		for _, block := range [][]int{rowRange(0, 25), rowRange(15, 40)} {
+			for _, i := range block {
+				distances, _ := weightedDistances(stitched, stitched.TaxonLocation(i))
+				for _, j := range block {
+					if got := distances[stitched.TaxonLocation(j)]; math.Abs(got-matrix[i][j]) > 1e-9 {
+						t.Errorf("seed %d: d(%d,%d) is %g in the stitched tree, expected %g", seed, i, j, got, matrix[i][j])
+					}
+				}
+			}
+		}
+	}
+
+	// A caterpillar with taxa 0..5 along a path; the third block measures d(0,5) wrongly
+	caterpillar := treeOfEdges([][3]int{{0, 6, 1}, {1, 6, 1}, {6, 7, 1}, {2, 7, 1}, {7, 8, 1}, {3, 8, 1}, {8, 9, 1}, {4, 9, 1}, {5, 9, 1}})
+	matrix := floatDistanceMatrix(t, caterpillar)
+	third := matrixBlock(matrix, []int{0, 1, 5})
+	third.Matrix[0][2], third.Matrix[2][0] = 7, 7
+	blocks := []MatrixBlock{matrixBlock(matrix, []int{0, 1, 2, 3}), matrixBlock(matrix, []int{2, 3, 4, 5}), third}
+	stitching, err := StitchBlocks(blocks, 1e-10)
+	if err != nil {
+		t.Fatalf("StitchBlocks returned error: %v", err)
+	}
+	expected := []BlockInconsistency{{Block: 2, Taxon1: 0, Taxon2: 5, Measured: 7, InTree: 5}}
+	if fmt.Sprint(stitching.Inconsistencies) != fmt.Sprint(expected) {
+		t.Errorf("expected inconsistencies %v, got %v", expected, stitching.Inconsistencies)
+	}
+	if !CompareTreeTopology(stitching.Tree, caterpillar) {
+		t.Errorf("expected the caterpillar, got %v", stitching.Tree.AllEdges)
+	}
+
+	if _, err := StitchBlocks([]MatrixBlock{blocks[0], matrixBlock(matrix, []int{3, 4, 5})}, 1e-10); err == nil {
+		t.Errorf("expected an error for a block sharing a single taxon")
+	}
+}
@@ -0,0 +1,258 @@
+package algorithms
+
+import (
+	"sort"
+)
+
+// Returns a copy of the tree laid out canonically: hung from its center, with the children
+// of every node stored in the order of their subtree classes, and internal nodes numbered in
+// that order after the taxa. Trees that are isomorphic with the same taxa at the same places
+// give identical copies, and so identical serializations from the returned root.
+// If anonymous, taxa are renumbered too (in canonical order, from 0) and labels dropped, so
+// that only the shape and the edge weights are left. Otherwise labelled taxa are first
+// numbered in order of name (see AlignTaxaByName), since their IDs follow the input order,
+// and isomorphic subtrees are ordered by the first name in them.
+func CanonicalTree(graph *Graph, anonymous bool) (*Graph, int, error) {
+	if !anonymous {
+		aligned, err := AlignTaxaByName([]*Graph{graph})
+		if err != nil {
+			return nil, 0, err
+		}
+		graph = aligned[0]
+	}
+
+	tree, classes, err := canonicalRooting(graph, anonymous)
+	if err != nil {
+		return nil, 0, err
+	}
+	var order = canonicalPreOrder(tree, classes, smallestTaxa(tree))
+
+	var mapping map[int]int
+	if anonymous {
+		// Taxa and their duplicates first, then the internal nodes
+		mapping = make(map[int]int)
+		for _, index := range order {
+			if tree.IsTaxon(index) {
+				var node = tree.Nodes[index]
+				for _, taxon := range append([]int{node}, graph.Duplicates[node]...) {
+					mapping[taxon] = len(mapping)
+				}
+			}
+		}
+		for _, index := range order {
+			if !tree.IsTaxon(index) {
+				mapping[tree.Nodes[index]] = len(mapping)
+			}
+		}
+	} else {
+		var nodes = make([]int, len(order))
+		for i, index := range order {
+			nodes[i] = tree.Nodes[index]
+		}
+		mapping = numberInOrder(graph, nodes)
+	}
+	var renumber = func(node int) int {
+		if newNode, ok := mapping[node]; ok {
+			return newNode
+		}
+		return node
+	}
+
+	// Edges are added in canonical pre-order, so every node lists its children in order
+	var canonical = &Graph{
+		Nodes: map[int]struct{}{},
+		Edges: map[int][]Edge{},
+	}
+	for _, index := range order {
+		canonical.AddNode(renumber(tree.Nodes[index]))
+		if parent := tree.Parent[index]; parent != -1 {
+			if err := canonical.AddEdge(renumber(tree.Nodes[parent]), renumber(tree.Nodes[index]), tree.ParentWeight[index]); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	if graph.Taxa != nil {
+		canonical.Taxa = make(map[int]struct{})
+		for node := range graph.Taxa {
+			canonical.Taxa[renumber(node)] = struct{}{}
+		}
+	}
+	if graph.Labels != nil && !anonymous {
+		canonical.Labels = make(map[int]string)
+		for node, label := range graph.Labels {
+			canonical.Labels[renumber(node)] = label
+		}
+	}
+	for representative, duplicates := range graph.Duplicates {
+		for _, duplicate := range duplicates {
+			canonical.AddDuplicate(renumber(representative), renumber(duplicate))
+		}
+	}
+
+	return canonical, renumber(tree.Root()), nil
+}
+
+// Hangs the tree from its center and classifies its subtrees. If the tree has two centers,
+// the root is the one whose side of the central edge has the smaller class, or the smaller
+// taxon if both sides are isomorphic.
+func canonicalRooting(graph *Graph, weighted bool) (*RootedTree, []int, error) {
+	var centers = findTreeCenters(graph)
+	var root = centers[0]
+	if len(centers) == 2 {
+		// Compare the two sides in a copy hung from the middle of the central edge
+		copied, err := graph.RenumberNodes(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		rooting, err := rootOnEdge(copied, centers[0], centers[1], edgeWeight(copied, centers[0], centers[1])/2, -1)
+		if err != nil {
+			return nil, nil, err
+		}
+		split, err := NewRootedTree(copied, rooting.Root)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var classes, smallest = subtreeClasses(split, weighted), smallestTaxa(split)
+		var side0, side1 = split.Index[centers[0]], split.Index[centers[1]]
+		if classes[side1] < classes[side0] || (classes[side1] == classes[side0] && smallest[side1] < smallest[side0]) {
+			root = centers[1]
+		}
+	}
+
+	tree, err := NewRootedTree(graph, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tree, subtreeClasses(tree, weighted), nil
+}
+
+// Returns the node indices of the rooted tree in pre-order, visiting the children of each
+// node in the order of their subtree classes, and of their smallest taxa among isomorphic
+// subtrees
+func canonicalPreOrder(tree *RootedTree, classes []int, smallest []int) []int {
+	var order = make([]int, 0, tree.Size())
+	var stack = []int{0}
+	for len(stack) > 0 {
+		var index = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		order = append(order, index)
+
+		var children = append([]int{}, tree.Children[index]...)
+		sort.Slice(children, func(a, b int) bool {
+			if classes[children[a]] != classes[children[b]] {
+				return classes[children[a]] > classes[children[b]]
+			}
+			return smallest[children[a]] > smallest[children[b]]
+		})
+		// Pushed in reverse, so the smallest class is visited first
+		stack = append(stack, children...)
+	}
+	return order
+}
+
+// Classifies the subtrees of a rooted tree up to isomorphism (Aho, Hopcroft and Ullman):
+// two subtrees get the same class if and only if they have the same shape, with internal
+// taxa and duplicate taxa at the same positions. If weighted, the subtrees must also have
+// the same edge weights, including the weight of the edge above them. Classes are assigned
+// level by level from the leaves, ordered by height and then by the sorted classes of the
+// children, so they do not depend on node IDs.
+func subtreeClasses(tree *RootedTree, weighted bool) []int {
+	return jointSubtreeClasses([]*RootedTree{tree}, weighted)[0]
+}
+
+// Classifies the subtrees of several rooted trees together, so that isomorphic subtrees of
+// different trees get the same class
+func jointSubtreeClasses(trees []*RootedTree, weighted bool) [][]int {
+	// A node of one of the trees
+	type subtree struct {
+		tree, index int
+	}
+
+	// Edge weights are compared by their rank among all weights of the trees
+	var weights = make([]float64, 0)
+	for _, tree := range trees {
+		weights = append(weights, tree.ParentWeight...)
+	}
+	sort.Float64s(weights)
+
+	var classes = make([][]int, len(trees))
+	var keys = make([][][]int, len(trees))
+	var levels = make([][]subtree, 0)
+	for t, tree := range trees {
+		classes[t] = make([]int, tree.Size())
+		keys[t] = make([][]int, tree.Size())
+
+		var height = make([]int, tree.Size())
+		for _, index := range tree.PostOrder() {
+			for _, child := range tree.Children[index] {
+				height[index] = max(height[index], height[child]+1)
+			}
+			for len(levels) <= height[index] {
+				levels = append(levels, nil)
+			}
+			levels[height[index]] = append(levels[height[index]], subtree{t, index})
+		}
+	}
+
+	var next = 0
+	for _, level := range levels {
+		// The key of a node is whether it is an internal taxon, its number of duplicates, the
+		// weight of the edge to its parent if weighted, and the sorted classes of its children,
+		// which are on lower levels
+		for _, node := range level {
+			var tree = trees[node.tree]
+			var id, graph = tree.Nodes[node.index], tree.Graph
+			var internalTaxon = 0
+			if graph.Taxa != nil && graph.IsTaxon(id) && len(graph.Edges[id]) > 1 {
+				internalTaxon = 1
+			}
+			var weightRank = 0
+			if weighted {
+				weightRank = sort.SearchFloat64s(weights, tree.ParentWeight[node.index])
+			}
+
+			var childClasses = make([]int, 0, len(tree.Children[node.index]))
+			for _, child := range tree.Children[node.index] {
+				childClasses = append(childClasses, classes[node.tree][child])
+			}
+			sort.Ints(childClasses)
+
+			keys[node.tree][node.index] = append([]int{internalTaxon, len(graph.Duplicates[id]), weightRank}, childClasses...)
+		}
+
+		var key = func(node subtree) []int {
+			return keys[node.tree][node.index]
+		}
+		sort.Slice(level, func(a, b int) bool {
+			return compareKeys(key(level[a]), key(level[b])) < 0
+		})
+		for i, node := range level {
+			if i == 0 || compareKeys(key(level[i-1]), key(node)) != 0 {
+				next++
+			}
+			classes[node.tree][node.index] = next
+		}
+
+		// Keys of a level are no longer needed once the level is classified
+		for _, node := range level {
+			keys[node.tree][node.index] = nil
+		}
+	}
+
+	return classes
+}
+
+// Compares integer sequences lexicographically
+func compareKeys(a []int, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
@@ -0,0 +1,116 @@
+package algorithms
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// Returns an isomorphic copy of the tree with shuffled internal node IDs (and taxon IDs if
+// relabelTaxa) and edges added in random order. Labels stay with their nodes, as when a
+// labelled tree is parsed with its taxa listed in another order.
+func shuffledCopy(tree *Graph, rng *rand.Rand, relabelTaxa bool) *Graph {
+	nodes := make([]int, 0)
+	for node := range tree.Nodes {
+		if relabelTaxa || !tree.IsTaxon(node) {
+			nodes = append(nodes, node)
+		}
+	}
+	targets := append([]int{}, nodes...)
+	rng.Shuffle(len(targets), func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+	mapping := make(map[int]int)
+	for i, node := range nodes {
+		mapping[node] = targets[i]
+	}
+	renumber := func(node int) int {
+		if newNode, ok := mapping[node]; ok {
+			return newNode
+		}
+		return node
+	}
+
+	shuffled := &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}}
+	for node := range tree.Nodes {
+		shuffled.AddNode(renumber(node))
+	}
+	for _, i := range rng.Perm(len(tree.AllEdges)) {
+		edge := tree.AllEdges[i]
+		shuffled.AddEdge(renumber(edge.Node2), renumber(edge.Node1), edge.Weight)
+	}
+	for node, label := range tree.Labels {
+		if shuffled.Labels == nil {
+			shuffled.Labels = make(map[int]string)
+		}
+		shuffled.Labels[renumber(node)] = label
+	}
+	return shuffled
+}
+
+func TestCanonicalTree(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3, 4, 5} {
+		rng := rand.New(rand.NewSource(seed))
+		tree, err := GenerateRandomTree(25, seed, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+		// Weights from a small set, so that some sibling edges are equal and some differ
+		for i := range tree.AllEdges {
+			tree.AllEdges[i].Weight = float64(1 + rng.Intn(3))
+		}
+		tree, _ = tree.RenumberNodes(nil)
+
+		for _, anonymous := range []bool{false, true} {
+			canonical, root, err := CanonicalTree(tree, anonymous)
+			if err != nil {
+				t.Fatalf("seed %d: CanonicalTree returned error: %v", seed, err)
+			}
+			if !CompareTreeTopology(tree, canonical) {
+				t.Errorf("seed %d: canonical tree is not isomorphic to the tree", seed)
+			}
+
+			for i := 0; i < 3; i++ {
+				other, otherRoot, err := CanonicalTree(shuffledCopy(tree, rng, anonymous), anonymous)
+				if err != nil {
+					t.Fatalf("seed %d: CanonicalTree returned error: %v", seed, err)
+				}
+				if root != otherRoot || !reflect.DeepEqual(canonical.Edges, other.Edges) {
+					t.Errorf("seed %d, anonymous %v: canonical trees of isomorphic copies differ", seed, anonymous)
+				}
+			}
+		}
+
+		// Labelled taxa are matched by name, so copies with the taxa numbered in another order
+		// give the same canonical tree
+		labelled, _ := tree.RenumberNodes(nil)
+		labelled.Labels = make(map[int]string)
+		names := rng.Perm(len(labelled.AllTaxa()))
+		for i, taxon := range labelled.AllTaxa() {
+			labelled.Labels[taxon] = "t" + strconv.Itoa(names[i])
+		}
+		canonical, root, err := CanonicalTree(labelled, false)
+		if err != nil {
+			t.Fatalf("seed %d: CanonicalTree returned error: %v", seed, err)
+		}
+		for i := 0; i < 3; i++ {
+			other, otherRoot, err := CanonicalTree(shuffledCopy(labelled, rng, true), false)
+			if err != nil {
+				t.Fatalf("seed %d: CanonicalTree returned error: %v", seed, err)
+			}
+			if root != otherRoot || !reflect.DeepEqual(canonical.Edges, other.Edges) || !reflect.DeepEqual(canonical.Labels, other.Labels) {
+				t.Errorf("seed %d: canonical trees of labelled copies differ", seed)
+			}
+		}
+
+		// Changing a single weight changes the anonymous canonical form
+		changed, _ := tree.RenumberNodes(nil)
+		edge := changed.AllEdges[0]
+		changed.RemoveEdge(edge.Node1, edge.Node2)
+		changed.AddEdge(edge.Node1, edge.Node2, edge.Weight+10)
+		canonical, _, _ = CanonicalTree(tree, true)
+		other, _, _ := CanonicalTree(changed, true)
+		if reflect.DeepEqual(canonical.Edges, other.Edges) {
+			t.Errorf("seed %d: canonical trees with different weights are equal", seed)
+		}
+	}
+}
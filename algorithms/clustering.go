@@ -0,0 +1,163 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Clades of a rooted tree, with the diameter (largest distance between two of its taxa) of
+// each clade. Clades are identified by the index of their top node in the rooted tree.
+type cladeDiameters struct {
+	tree *RootedTree
+	// Largest distance from the node down to a taxon in its clade, -Inf if there is none
+	height   []float64
+	diameter []float64
+}
+
+func computeCladeDiameters(graph *Graph, root int) (*cladeDiameters, error) {
+	tree, err := NewRootedTree(graph, root)
+	if err != nil {
+		return nil, err
+	}
+
+	clades := &cladeDiameters{
+		tree:     tree,
+		height:   make([]float64, tree.Size()),
+		diameter: make([]float64, tree.Size()),
+	}
+
+	for _, index := range tree.PostOrder() {
+		var height, diameter = math.Inf(-1), 0.0
+		if tree.IsTaxon(index) {
+			height = 0
+		}
+
+		for _, child := range tree.Children[index] {
+			diameter = math.Max(diameter, clades.diameter[child])
+			var throughChild = clades.height[child] + tree.ParentWeight[child]
+			if !math.IsInf(height, -1) && !math.IsInf(throughChild, -1) {
+				diameter = math.Max(diameter, height+throughChild)
+			}
+			height = math.Max(height, throughChild)
+		}
+
+		clades.height[index] = height
+		clades.diameter[index] = diameter
+	}
+
+	return clades, nil
+}
+
+// Returns the taxa at the node, including duplicates, or nil if it is not a taxon
+func (c *cladeDiameters) ownTaxa(index int) []int {
+	if !c.tree.IsTaxon(index) {
+		return nil
+	}
+	var node = c.tree.Nodes[index]
+	return append([]int{node}, c.tree.Graph.Duplicates[node]...)
+}
+
+// Returns the taxa of the clade, including duplicates
+func (c *cladeDiameters) taxa(index int) []int {
+	var taxa = make([]int, 0)
+	// The clade is a contiguous range of the pre-order
+	for i := index; i < index+c.tree.SubtreeSize[index]; i++ {
+		taxa = append(taxa, c.ownTaxa(i)...)
+	}
+	sort.Ints(taxa)
+	return taxa
+}
+
+// Splits a clade into the clades of its children, and the taxa at the node itself
+func (c *cladeDiameters) split(index int) (clades []int, ownTaxa []int) {
+	for _, child := range c.tree.Children[index] {
+		if !math.IsInf(c.height[child], -1) {
+			clades = append(clades, child)
+		}
+	}
+	return clades, c.ownTaxa(index)
+}
+
+// Orders clusters by their smallest taxon
+func sortClusters(clusters [][]int) [][]int {
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i][0] < clusters[j][0]
+	})
+	return clusters
+}
+
+// Cuts the tree rooted at the given node into clusters of taxa: the maximal clades whose
+// diameter (largest distance between two of their taxa) is at most the threshold. Taxa at
+// an internal node whose clade is too wide form a cluster of their own.
+func CutTreeAtThreshold(tree *Graph, root int, threshold float64) ([][]int, error) {
+	clades, err := computeCladeDiameters(tree, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters = make([][]int, 0)
+	var stack = []int{0}
+	for len(stack) > 0 {
+		var node = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if clades.diameter[node] <= threshold {
+			clusters = append(clusters, clades.taxa(node))
+			continue
+		}
+
+		var children, ownTaxa = clades.split(node)
+		if len(ownTaxa) > 0 {
+			clusters = append(clusters, ownTaxa)
+		}
+		stack = append(stack, children...)
+	}
+
+	return sortClusters(clusters), nil
+}
+
+// Cuts the tree rooted at the given node into k clusters of taxa, by repeatedly splitting
+// the cluster with the largest diameter into the clades below it. Splitting a multifurcation
+// may give more than k clusters.
+func CutTreeIntoClusters(tree *Graph, root int, k int) ([][]int, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("number of clusters must be positive, got %d", k)
+	}
+
+	clades, err := computeCladeDiameters(tree, root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clades are split while there are fewer than k clusters; clusters of taxa at a single
+	// node cannot be split further
+	var open = []int{0}
+	var fixed = make([][]int, 0)
+	for len(open)+len(fixed) < k {
+		var widest = -1
+		for i, node := range open {
+			if clades.diameter[node] > 0 && (widest == -1 || clades.diameter[node] > clades.diameter[open[widest]]) {
+				widest = i
+			}
+		}
+		if widest == -1 {
+			break
+		}
+
+		var node = open[widest]
+		open = append(open[:widest], open[widest+1:]...)
+		var children, ownTaxa = clades.split(node)
+		if len(ownTaxa) > 0 {
+			fixed = append(fixed, ownTaxa)
+		}
+		open = append(open, children...)
+	}
+
+	var clusters = fixed
+	for _, node := range open {
+		clusters = append(clusters, clades.taxa(node))
+	}
+
+	return sortClusters(clusters), nil
+}
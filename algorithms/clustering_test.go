@@ -0,0 +1,43 @@
+package algorithms
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCutTree(t *testing.T) {
+	// Taxon 0 is the root leaf, taxa 1-5 form a star and taxon 6 hangs off its center
+	matrix := readTestMatrix(t, "../test_inputs/manual3-7.input.txt")
+	tree, err := ReconstructRealTree(matrix, 1e-10)
+	if err != nil {
+		t.Fatalf("ReconstructRealTree returned error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		k         int
+		threshold float64
+		expected  [][]int
+	}{
+		{name: "one cluster", k: 1, expected: [][]int{{0, 1, 2, 3, 4, 5, 6}}},
+		{name: "three clusters", k: 3, expected: [][]int{{0}, {1, 2, 3, 4, 5}, {6}}},
+		{name: "threshold covering the tree", threshold: 4, expected: [][]int{{0, 1, 2, 3, 4, 5, 6}}},
+		{name: "threshold covering the star", threshold: 2, expected: [][]int{{0}, {1, 2, 3, 4, 5}, {6}}},
+		{name: "threshold of single taxa", threshold: 1, expected: [][]int{{0}, {1}, {2}, {3}, {4}, {5}, {6}}},
+	}
+
+	for _, test := range tests {
+		var clusters [][]int
+		if test.k > 0 {
+			clusters, err = CutTreeIntoClusters(tree, 0, test.k)
+		} else {
+			clusters, err = CutTreeAtThreshold(tree, 0, test.threshold)
+		}
+
+		if err != nil {
+			t.Errorf("%s: returned error: %v", test.name, err)
+		} else if !reflect.DeepEqual(clusters, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, clusters)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package algorithms
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Rule deciding which splits of the input trees a consensus tree keeps
+type ConsensusMethod int
+
+const (
+	// Splits found in all trees
+	ConsensusStrict ConsensusMethod = iota
+	// Splits found in more than half of the trees
+	ConsensusMajority
+	// Splits in decreasing order of frequency, skipping those that conflict with the ones
+	// already kept
+	ConsensusGreedy
+)
+
+// Parses a consensus method name as accepted by the --method flag
+func ParseConsensusMethod(name string) (ConsensusMethod, error) {
+	switch name {
+	case "strict":
+		return ConsensusStrict, nil
+	case "majority":
+		return ConsensusMajority, nil
+	case "greedy":
+		return ConsensusGreedy, nil
+	default:
+		return 0, fmt.Errorf("invalid consensus method: %s (expected strict, majority or greedy)", name)
+	}
+}
+
+// Builds the consensus tree of trees with the same taxa, matched by name as in
+// AlignTaxaByName. The splits of the trees are counted, and those chosen by the method
+// become the edges of the consensus tree, with the fraction of trees that have them as
+// their support (see Graph.Support) and the mean length of their edges in those trees as
+// weight. A taxon hangs from the tree by the mean length of its edge, counting 0 for trees
+// where it is at an internal node; if that is 0, it becomes an internal taxon, or a
+// duplicate of another taxon.
+func ConsensusTree(trees []*Graph, method ConsensusMethod) (*Graph, error) {
+	if len(trees) == 0 {
+		return nil, fmt.Errorf("at least one tree is required")
+	}
+	trees, err := AlignTaxaByName(trees)
+	if err != nil {
+		return nil, err
+	}
+	var taxa = trees[0].AllTaxa()
+	for i, tree := range trees[1:] {
+		if !reflect.DeepEqual(tree.AllTaxa(), taxa) {
+			return nil, fmt.Errorf("tree %d does not have the same taxa as tree 1", i+2)
+		}
+	}
+	if len(taxa) < 2 {
+		return nil, fmt.Errorf("trees must have at least 2 taxa")
+	}
+
+	// Splits are counted once per tree, so chains of degree-2 nodes are suppressed first
+	var counts = make(map[string]int)
+	var totalWeights = make(map[string]float64)
+	var sides = make(map[string][]int)
+	for _, tree := range trees {
+		suppressed, err := PruneToTaxa(tree, taxa, true)
+		if err != nil {
+			return nil, err
+		}
+		splits, err := TreeSplits(suppressed)
+		if err != nil {
+			return nil, err
+		}
+		for _, split := range splits {
+			var key = split.Key()
+			counts[key]++
+			totalWeights[key] += split.Weight
+			sides[key] = split.Side
+		}
+	}
+
+	// A taxon's own edge is in every tree where the taxon is a leaf; the other splits are
+	// chosen by the method
+	var leafEdgeWeights = make(map[int]float64)
+	var candidates = make([]string, 0)
+	for key, side := range sides {
+		if len(side) == 1 {
+			leafEdgeWeights[side[0]] = totalWeights[key] / float64(len(trees))
+		} else if len(side) == len(taxa)-1 {
+			leafEdgeWeights[taxa[0]] = totalWeights[key] / float64(len(trees))
+		} else {
+			candidates = append(candidates, key)
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if counts[candidates[a]] != counts[candidates[b]] {
+			return counts[candidates[a]] > counts[candidates[b]]
+		}
+		if len(sides[candidates[a]]) != len(sides[candidates[b]]) {
+			return len(sides[candidates[a]]) < len(sides[candidates[b]])
+		}
+		return candidates[a] < candidates[b]
+	})
+
+	var chosen = make([]Split, 0)
+	for _, key := range candidates {
+		var keep bool
+		switch method {
+		case ConsensusStrict:
+			keep = counts[key] == len(trees)
+		case ConsensusMajority:
+			keep = 2*counts[key] > len(trees)
+		case ConsensusGreedy:
+			keep = true
+			for _, other := range chosen {
+				if !compatibleSides(sides[key], other.Side) {
+					keep = false
+					break
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid consensus method: %d", method)
+		}
+		if keep {
+			chosen = append(chosen, Split{Side: sides[key], Weight: totalWeights[key] / float64(counts[key])})
+		}
+	}
+
+	consensus, err := treeFromSplits(taxa, chosen, leafEdgeWeights)
+	if err != nil {
+		return nil, err
+	}
+	if err := consensus.MergeZeroEdges(1e-10); err != nil {
+		return nil, err
+	}
+	for taxon, label := range trees[0].Labels {
+		if containsTaxon(taxa, taxon) {
+			if consensus.Labels == nil {
+				consensus.Labels = make(map[int]string)
+			}
+			consensus.Labels[taxon] = label
+		}
+	}
+
+	// Support is assigned after merging, from the splits of the final edges
+	rooted, splits, err := rootedSplits(consensus)
+	if err != nil {
+		return nil, err
+	}
+	for index := 1; index < rooted.Size(); index++ {
+		var node, parent = rooted.Nodes[index], rooted.Nodes[rooted.Parent[index]]
+		if len(consensus.Edges[node]) > 1 && len(consensus.Edges[parent]) > 1 {
+			consensus.SetEdgeSupport(node, parent, float64(counts[splits[index].Key()])/float64(len(trees)))
+		}
+	}
+
+	return consensus, nil
+}
+
+// Checks if two splits can be edges of the same tree. Both sides leave out the same
+// reference taxon, so the splits are compatible if the sides are nested or disjoint.
+func compatibleSides(side1, side2 []int) bool {
+	var common = len(intersectSorted(side1, side2))
+	return common == 0 || common == len(side1) || common == len(side2)
+}
+
+// Builds a tree with the taxa as leaves and an edge for each of the compatible splits. The
+// tree is built hanging from the smallest taxon, which every side leaves out: the parent of
+// each split is the smallest split containing it. Internal nodes get IDs after the taxa.
+func treeFromSplits(taxa []int, splits []Split, leafEdgeWeights map[int]float64) (*Graph, error) {
+	var tree = &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}, Taxa: map[int]struct{}{}}
+	for _, taxon := range taxa {
+		tree.AddNode(taxon)
+		tree.SetTaxon(taxon)
+	}
+
+	var sorted = append([]Split{}, splits...)
+	sort.Slice(sorted, func(a, b int) bool {
+		return len(sorted[a].Side) < len(sorted[b].Side)
+	})
+
+	// The node of each split, and the center node from which the smallest taxon and the
+	// largest splits hang
+	var nodes = make([]int, len(sorted))
+	for i := range sorted {
+		nodes[i] = tree.AddNewNode()
+	}
+	var center = tree.AddNewNode()
+
+	// The parent of a split or taxon is the smallest split containing it
+	var parentOf = func(contained []int, from int) int {
+		for j := from; j < len(sorted); j++ {
+			if len(intersectSorted(contained, sorted[j].Side)) == len(contained) {
+				return nodes[j]
+			}
+		}
+		return center
+	}
+
+	for i, split := range sorted {
+		if err := tree.AddEdge(nodes[i], parentOf(split.Side, i+1), split.Weight); err != nil {
+			return nil, err
+		}
+	}
+	for _, taxon := range taxa {
+		var parent = center
+		if taxon != taxa[0] {
+			parent = parentOf([]int{taxon}, 0)
+		}
+		if err := tree.AddEdge(taxon, parent, leafEdgeWeights[taxon]); err != nil {
+			return nil, err
+		}
+	}
+
+	// With two taxa, or when every split contains all taxa but the smallest, the center has
+	// only two neighbors
+	if len(tree.Edges[center]) == 2 {
+		if err := tree.ContractUnaryNodes(); err != nil {
+			return nil, err
+		}
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,136 @@
+package algorithms
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConsensusTree(t *testing.T) {
+	// ((0,1),2,(3,4)), ((2,1),0,(3,4)) and ((0,1),4,(2,3))
+	tree1 := treeOfEdges([][3]int{{0, 5, 1}, {1, 5, 1}, {5, 6, 1}, {2, 6, 1}, {6, 7, 1}, {3, 7, 1}, {4, 7, 1}})
+	tree2 := treeOfEdges([][3]int{{2, 5, 1}, {1, 5, 1}, {5, 6, 1}, {0, 6, 1}, {6, 7, 1}, {3, 7, 1}, {4, 7, 1}})
+	tree3 := treeOfEdges([][3]int{{0, 5, 1}, {1, 5, 1}, {5, 6, 1}, {4, 6, 1}, {6, 7, 1}, {2, 7, 1}, {3, 7, 1}})
+	trees := []*Graph{tree1, tree2, tree3}
+
+	checkSupport := func(name string, consensus *Graph, expected float64) {
+		t.Helper()
+		for _, edge := range consensus.AllEdges {
+			support, ok := consensus.EdgeSupport(edge.Node1, edge.Node2)
+			if len(consensus.Edges[edge.Node1]) == 1 || len(consensus.Edges[edge.Node2]) == 1 {
+				if ok {
+					t.Errorf("%s: leaf edge %d-%d has support %v", name, edge.Node1, edge.Node2, support)
+				}
+				continue
+			}
+			if !ok || support != expected {
+				t.Errorf("%s: edge %d-%d has support %v (%v), expected %v", name, edge.Node1, edge.Node2, support, ok, expected)
+			}
+		}
+	}
+
+	// No split is in all three trees
+	strict, err := ConsensusTree(trees, ConsensusStrict)
+	if err != nil {
+		t.Fatalf("strict: ConsensusTree returned error: %v", err)
+	}
+	if len(strict.Nodes) != 6 {
+		t.Errorf("strict: expected a star with 6 nodes, got %v", strict.AllEdges)
+	}
+
+	// {0,1} | {2,3,4} and {3,4} | {0,1,2} are in two of the trees
+	majority, err := ConsensusTree(trees, ConsensusMajority)
+	if err != nil {
+		t.Fatalf("majority: ConsensusTree returned error: %v", err)
+	}
+	if !CompareTreeTopology(majority, tree1) {
+		t.Errorf("majority: expected the shape of the first tree, got %v", majority.AllEdges)
+	}
+	checkSupport("majority", majority, 2.0/3)
+
+	// Of the first two trees only {3,4} is in both; {1,2} is added before the conflicting {0,1}
+	// since its side is smaller
+	greedy, err := ConsensusTree(trees[:2], ConsensusGreedy)
+	if err != nil {
+		t.Fatalf("greedy: ConsensusTree returned error: %v", err)
+	}
+	if !CompareTreeTopology(greedy, tree2) {
+		t.Errorf("greedy: expected the shape of the second tree, got %v", greedy.AllEdges)
+	}
+	if support, _ := greedy.EdgeSupport(greedy.TaxonLocation(3), greedy.TaxonLocation(4)); support != 0 {
+		t.Errorf("greedy: leaves 3 and 4 should not be adjacent")
+	}
+
+	// Identical trees give back the same tree with full support
+	tree, err := GenerateRandomTree(30, 1, 0.3, 0.25)
+	if err != nil {
+		t.Fatalf("GenerateRandomTree returned error: %v", err)
+	}
+	consensus, err := ConsensusTree([]*Graph{tree, tree}, ConsensusStrict)
+	if err != nil {
+		t.Fatalf("ConsensusTree returned error: %v", err)
+	}
+	diff, err := DiffTrees(tree, consensus, 1e-6)
+	if err != nil {
+		t.Fatalf("DiffTrees returned error: %v", err)
+	}
+	if !diff.Identical() {
+		t.Errorf("consensus of identical trees differs: %+v", diff)
+	}
+	checkSupport("identical", consensus, 1)
+
+	pruned, err := PruneToTaxa(tree1, []int{0, 1, 2, 3}, true)
+	if err != nil {
+		t.Fatalf("PruneToTaxa returned error: %v", err)
+	}
+	if _, err := ConsensusTree([]*Graph{tree1, pruned}, ConsensusMajority); err == nil {
+		t.Errorf("expected an error for trees with different taxa")
+	}
+}
+
+// Builds a tree from edges and labels its taxa, like a Newick tree whose IDs follow the order
+// the taxa are listed in
+func labelledTreeOfEdges(edges [][3]int, labels ...string) *Graph {
+	graph := treeOfEdges(edges)
+	graph.Labels = make(map[int]string)
+	for taxon, label := range labels {
+		graph.Labels[taxon] = label
+	}
+	return graph
+}
+
+func TestConsensusTreeMatchesLabels(t *testing.T) {
+	// ((A,B),(C,D),E), the same tree as (E,(D,C),(B,A)), and ((A,C),(B,D),E)
+	tree1 := labelledTreeOfEdges([][3]int{{0, 5, 1}, {1, 5, 1}, {2, 6, 1}, {3, 6, 1}, {5, 7, 1}, {6, 7, 1}, {4, 7, 2}}, "A", "B", "C", "D", "E")
+	tree2 := labelledTreeOfEdges([][3]int{{1, 5, 1}, {2, 5, 1}, {3, 6, 1}, {4, 6, 1}, {5, 7, 1}, {6, 7, 1}, {0, 7, 2}}, "E", "D", "C", "B", "A")
+	tree3 := labelledTreeOfEdges([][3]int{{0, 5, 1}, {1, 5, 1}, {2, 6, 1}, {3, 6, 1}, {5, 7, 1}, {6, 7, 1}, {4, 7, 2}}, "A", "C", "B", "D", "E")
+
+	same, err := ConsensusTree([]*Graph{tree1, tree2}, ConsensusStrict)
+	if err != nil {
+		t.Fatalf("ConsensusTree returned error: %v", err)
+	}
+	// Aligned taxa are numbered in order of label, which is the order of tree1
+	expected, err := CalculateDistanceMatrix(tree1)
+	if err != nil {
+		t.Fatalf("CalculateDistanceMatrix returned error: %v", err)
+	}
+	distances, err := CalculateDistanceMatrix(same)
+	if err != nil {
+		t.Fatalf("CalculateDistanceMatrix returned error: %v", err)
+	}
+	if !reflect.DeepEqual(distances, expected) {
+		t.Errorf("expected the distances of the first tree %v, got %v", expected, distances)
+	}
+	for taxon, label := range tree1.Labels {
+		if same.Labels[taxon] != label {
+			t.Errorf("expected taxon %d to be labelled %s, got %q", taxon, label, same.Labels[taxon])
+		}
+	}
+
+	star, err := ConsensusTree([]*Graph{tree1, tree3}, ConsensusStrict)
+	if err != nil {
+		t.Fatalf("ConsensusTree returned error: %v", err)
+	}
+	if len(star.Nodes) != 6 {
+		t.Errorf("expected a star with 6 nodes, got %v", star.AllEdges)
+	}
+}
@@ -2,79 +2,49 @@ package algorithms
 
 import (
 	"fmt"
-	"sort"
+	"math"
+	"strconv"
+	"strings"
 )
 
-// Computes the distance matrix between all leaf nodes in the tree.
-// The distances are calculated using BFS to find shortest paths between leaves.
+// Computes the distance matrix between all taxa (usually the leaves) in the tree.
+// The distances are looked up in an LCA index of the tree, so each pair takes O(1) time.
+// Duplicate taxa get the distances of the taxon node they are located at.
 func CalculateDistanceMatrix(graph *Graph) ([][]int, error) {
-	leaves := GetLeafNodes(graph)
+	leaves := graph.AllTaxa()
 	if len(leaves) == 0 {
-		return nil, fmt.Errorf("no leaf nodes found in the graph")
+		return nil, fmt.Errorf("no taxa found in the graph")
 	}
 
-	// Sort leaves for consistent ordering
-	sort.Ints(leaves)
+	index, err := NewLCAIndex(graph, graph.TaxonLocation(leaves[0]))
+	if err != nil {
+		return nil, fmt.Errorf("error indexing tree: %v", err)
+	}
 
+	// Resolve each taxon to its node index in the rooted tree once
 	n := len(leaves)
+	locations := make([]int, n)
+	for i, leaf := range leaves {
+		locations[i] = index.Tree.Index[graph.TaxonLocation(leaf)]
+	}
+
 	matrix := make([][]int, n)
 	for i := range matrix {
 		matrix[i] = make([]int, n)
 	}
 
-	// Create a mapping from node ID to matrix index
-	leafToIndex := make(map[int]int)
-	for i, leaf := range leaves {
-		leafToIndex[leaf] = i
-	}
-
 	// Calculate distances between all pairs of leaves
-	for i, leaf1 := range leaves {
-		distances := bfsDistances(graph, leaf1)
-		for j, leaf2 := range leaves {
-			if distance, exists := distances[leaf2]; exists {
-				matrix[i][j] = distance
-			} else {
-				return nil, fmt.Errorf("no path found between leaves %d and %d", leaf1, leaf2)
-			}
+	for i := range leaves {
+		for j := i + 1; j < n; j++ {
+			distance := int(math.Round(index.distanceOfIndices(locations[i], locations[j])))
+			matrix[i][j] = distance
+			matrix[j][i] = distance
 		}
 	}
 
 	return matrix, nil
 }
 
-// Performs BFS from the start node and returns distances to all reachable nodes
-func bfsDistances(graph *Graph, start int) map[int]int {
-	distances := make(map[int]int)
-	visited := make(map[int]bool)
-	queue := []int{start}
-
-	distances[start] = 0
-	visited[start] = true
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		for _, edge := range graph.Edges[current] {
-			var neighbor int
-			if edge.Node1 == current {
-				neighbor = edge.Node2
-			} else {
-				neighbor = edge.Node1
-			}
-
-			if !visited[neighbor] {
-				visited[neighbor] = true
-				distances[neighbor] = distances[current] + int(edge.Weight)
-				queue = append(queue, neighbor)
-			}
-		}
-	}
-
-	return distances
-}
-
 // Converts a distance matrix to the CSV string format used by the application
 func FormatDistanceMatrix(matrix [][]int) string {
 	if len(matrix) == 0 {
@@ -96,3 +66,17 @@ func FormatDistanceMatrix(matrix [][]int) string {
 
 	return result
 }
+
+// Converts a real-valued distance matrix to the CSV string format used by the application
+func FormatFloatDistanceMatrix(matrix [][]float64) string {
+	var rows = make([]string, len(matrix))
+	for i, row := range matrix {
+		var fields = make([]string, len(row))
+		for j, val := range row {
+			fields[j] = strconv.FormatFloat(val, 'g', 10, 64)
+		}
+		rows[i] = strings.Join(fields, ",")
+	}
+
+	return strings.Join(rows, "\n")
+}
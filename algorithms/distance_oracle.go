@@ -0,0 +1,68 @@
+package algorithms
+
+import (
+	"fmt"
+)
+
+// Source of distances between taxa 0..Size()-1, for cases where measuring a distance is
+// expensive and reconstruction should query as few of them as possible
+type DistanceOracle interface {
+	Size() int
+	Distance(i int, j int) (float64, error)
+}
+
+// Oracle answering queries from a complete distance matrix
+type MatrixOracle struct {
+	Matrix [][]float64
+}
+
+func (o *MatrixOracle) Size() int {
+	return len(o.Matrix)
+}
+
+func (o *MatrixOracle) Distance(i int, j int) (float64, error) {
+	if i < 0 || j < 0 || i >= len(o.Matrix) || j >= len(o.Matrix) {
+		return 0, fmt.Errorf("taxon index out of range: %d, %d", i, j)
+	}
+	return o.Matrix[i][j], nil
+}
+
+// Wraps another oracle, caching its answers and counting the distinct queries made
+type CountingOracle struct {
+	Oracle  DistanceOracle
+	Queries int
+	cache   map[[2]int]float64
+}
+
+func NewCountingOracle(oracle DistanceOracle) *CountingOracle {
+	return &CountingOracle{
+		Oracle: oracle,
+		cache:  make(map[[2]int]float64),
+	}
+}
+
+func (o *CountingOracle) Size() int {
+	return o.Oracle.Size()
+}
+
+func (o *CountingOracle) Distance(i int, j int) (float64, error) {
+	if i == j {
+		return 0, nil
+	}
+	if i > j {
+		i, j = j, i
+	}
+
+	if distance, ok := o.cache[[2]int{i, j}]; ok {
+		return distance, nil
+	}
+
+	distance, err := o.Oracle.Distance(i, j)
+	if err != nil {
+		return 0, err
+	}
+
+	o.Queries++
+	o.cache[[2]int{i, j}] = distance
+	return distance, nil
+}
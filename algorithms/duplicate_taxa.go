@@ -0,0 +1,97 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Finds groups of identical taxa (at distance 0 from each other). The result maps the
+// smallest index of each group to the other indices. Identical taxa must have the same
+// distances to all other taxa, otherwise the matrix cannot be realized by a tree.
+func FindDuplicateTaxa(matrix [][]float64, epsilon float64) (map[int][]int, error) {
+	var duplicates = make(map[int][]int)
+	var representative = make([]int, len(matrix))
+	for i := range matrix {
+		representative[i] = i
+		for j := 0; j < i; j++ {
+			if representative[j] == j && math.Abs(matrix[i][j]) <= epsilon {
+				representative[i] = j
+				break
+			}
+		}
+
+		if j := representative[i]; j != i {
+			for k := range matrix {
+				if math.Abs(matrix[i][k]-matrix[j][k]) > epsilon*math.Max(1, matrix[j][k]) {
+					return nil, fmt.Errorf("taxa %d and %d are at distance 0, but their distances to taxon %d differ (%g and %g)", j, i, k, matrix[j][k], matrix[i][k])
+				}
+			}
+			duplicates[j] = append(duplicates[j], i)
+		}
+	}
+
+	return duplicates, nil
+}
+
+// Removes the rows and columns of duplicate taxa, keeping one representative per group.
+// Returns the reduced matrix and the original index of each of its rows.
+func CollapseDuplicateTaxa(matrix [][]float64, duplicates map[int][]int) ([][]float64, []int) {
+	var removed = make(map[int]struct{})
+	for _, group := range duplicates {
+		for _, duplicate := range group {
+			removed[duplicate] = struct{}{}
+		}
+	}
+
+	var kept = make([]int, 0, len(matrix)-len(removed))
+	for i := range matrix {
+		if _, ok := removed[i]; !ok {
+			kept = append(kept, i)
+		}
+	}
+
+	var reduced = make([][]float64, len(kept))
+	for i, original := range kept {
+		reduced[i] = make([]float64, len(kept))
+		for j, other := range kept {
+			reduced[i][j] = matrix[original][other]
+		}
+	}
+
+	return reduced, kept
+}
+
+// Gives the taxa of a tree reconstructed from a collapsed matrix their original indices,
+// numbers internal nodes from the size of the original matrix, and re-attaches the
+// duplicates at their representatives
+func restoreDuplicateTaxa(tree *Graph, kept []int, duplicates map[int][]int, size int) (*Graph, error) {
+	var internal = make([]int, 0)
+	for node := range tree.Nodes {
+		if node >= len(kept) {
+			internal = append(internal, node)
+		}
+	}
+	sort.Ints(internal)
+
+	var mapping = make(map[int]int)
+	for i, original := range kept {
+		mapping[i] = original
+	}
+	for i, node := range internal {
+		mapping[node] = size + i
+	}
+
+	restored, err := tree.RenumberNodes(mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	for representative, group := range duplicates {
+		for _, duplicate := range group {
+			restored.AddDuplicate(representative, duplicate)
+		}
+	}
+
+	return restored, nil
+}
@@ -0,0 +1,55 @@
+package algorithms
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReconstructWithDuplicateTaxa(t *testing.T) {
+	// Taxa 0 and 4 are identical, as are 2 and 5
+	matrix := [][]float64{
+		{0, 2, 3, 3, 0, 3},
+		{2, 0, 1, 1, 2, 1},
+		{3, 1, 0, 2, 3, 0},
+		{3, 1, 2, 0, 3, 2},
+		{0, 2, 3, 3, 0, 3},
+		{3, 1, 0, 2, 3, 0},
+	}
+
+	tree, err := ReconstructRealTree(matrix, 1e-10)
+	if err != nil {
+		t.Fatalf("ReconstructRealTree returned error: %v", err)
+	}
+
+	expectedGroups := [][]int{{0, 4}, {2, 5}}
+	if groups := tree.DuplicateGroups(); !reflect.DeepEqual(groups, expectedGroups) {
+		t.Errorf("expected duplicate groups %v, got %v", expectedGroups, groups)
+	}
+
+	for _, taxon := range []int{4, 5} {
+		if _, ok := tree.Nodes[taxon]; ok {
+			t.Errorf("duplicate taxon %d should not be a node of the tree", taxon)
+		}
+	}
+
+	for i := range matrix {
+		distances, _ := weightedDistances(tree, tree.TaxonLocation(i))
+		for j := range matrix {
+			if distance := distances[tree.TaxonLocation(j)]; distance != matrix[i][j] {
+				t.Errorf("d(%d,%d) is %g in the tree, expected %g", i, j, distance, matrix[i][j])
+			}
+		}
+	}
+}
+
+func TestFindDuplicateTaxaRejectsInconsistentRows(t *testing.T) {
+	matrix := [][]float64{
+		{0, 0, 3},
+		{0, 0, 2},
+		{3, 2, 0},
+	}
+
+	if _, err := FindDuplicateTaxa(matrix, 1e-10); err == nil {
+		t.Errorf("expected an error for taxa at distance 0 with different rows")
+	}
+}
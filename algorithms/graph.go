@@ -3,6 +3,8 @@ package algorithms
 import (
 	"fmt"
 	"math"
+	"sort"
+	"strconv"
 )
 
 type Graph struct {
@@ -10,6 +12,18 @@ type Graph struct {
 	Edges map[int][]Edge
 	AllEdges []Edge
 	MaxNode int
+	// Optional names of nodes (e.g. sequence names of taxa). Nodes without a label are
+	// referred to by their IDs.
+	Labels map[int]string
+	// Nodes that represent sampled taxa. Taxa may be internal nodes when a taxon lies on
+	// the path between others. If nil, the leaves are the taxa.
+	Taxa map[int]struct{}
+	// Taxa identical to (at distance 0 from) a taxon node, keyed by that node. Duplicates
+	// are not nodes of the graph, but their IDs are reserved (MaxNode covers them).
+	Duplicates map[int][]int
+	// Optional support of edges (e.g. the fraction of trees with the edge's split in a
+	// consensus tree), keyed by the nodes of the edge in ascending order
+	Support map[[2]int]float64
 }
 
 type Edge struct {
@@ -40,6 +54,200 @@ func IndexOfEdge[T Edge | *Edge](edges []T, start int, end int) int {
 	return -1
 }
 
+// Returns the support of the edge between the nodes, if it has one
+func (g *Graph) EdgeSupport(node1 int, node2 int) (float64, bool) {
+	support, ok := g.Support[[2]int{min(node1, node2), max(node1, node2)}]
+	return support, ok
+}
+
+// Sets the support of the edge between the nodes
+func (g *Graph) SetEdgeSupport(node1 int, node2 int, support float64) {
+	if g.Support == nil {
+		g.Support = make(map[[2]int]float64)
+	}
+	g.Support[[2]int{min(node1, node2), max(node1, node2)}] = support
+}
+
+// Returns the label of the node, or its ID if it has no label
+func (g *Graph) NodeName(node int) string {
+	if label, ok := g.Labels[node]; ok {
+		return label
+	}
+	return strconv.Itoa(node)
+}
+
+// Finds a node (or duplicate taxon) by its label or ID
+func (g *Graph) FindNode(name string) (int, error) {
+	for node, label := range g.Labels {
+		if label == name {
+			return node, nil
+		}
+	}
+
+	node, err := strconv.Atoi(name)
+	if err != nil {
+		return 0, fmt.Errorf("no node is labelled %q", name)
+	}
+	if _, ok := g.Nodes[node]; !ok && g.TaxonLocation(node) == node {
+		return 0, fmt.Errorf("node %d does not exist in the graph", node)
+	}
+	return node, nil
+}
+
+// Returns true if the node represents a taxon
+func (g *Graph) IsTaxon(node int) bool {
+	if g.Taxa == nil {
+		return len(g.Edges[node]) == 1
+	}
+	_, ok := g.Taxa[node]
+	return ok
+}
+
+// Records the current leaves as the taxa if taxa are implicit, so that they stay taxa
+// when the tree changes
+func (g *Graph) MakeTaxaExplicit() {
+	if g.Taxa != nil {
+		return
+	}
+
+	g.Taxa = make(map[int]struct{})
+	for node := range g.Nodes {
+		if len(g.Edges[node]) == 1 {
+			g.Taxa[node] = struct{}{}
+		}
+	}
+}
+
+// Marks the node as a taxon
+func (g *Graph) SetTaxon(node int) {
+	g.MakeTaxaExplicit()
+	g.Taxa[node] = struct{}{}
+}
+
+// Returns the sorted IDs of all taxon nodes
+func (g *Graph) TaxonNodes() []int {
+	var taxa = make([]int, 0)
+	for node := range g.Nodes {
+		if g.IsTaxon(node) {
+			taxa = append(taxa, node)
+		}
+	}
+	sort.Ints(taxa)
+	return taxa
+}
+
+// Returns the sorted IDs of all taxa, including duplicates that are not nodes of the graph
+func (g *Graph) AllTaxa() []int {
+	var taxa = g.TaxonNodes()
+	for _, duplicates := range g.Duplicates {
+		taxa = append(taxa, duplicates...)
+	}
+	sort.Ints(taxa)
+	return taxa
+}
+
+// Returns the taxon node a taxon is located at: the taxon itself, or the representative
+// of its duplicate group
+func (g *Graph) TaxonLocation(taxon int) int {
+	for representative, duplicates := range g.Duplicates {
+		for _, duplicate := range duplicates {
+			if duplicate == taxon {
+				return representative
+			}
+		}
+	}
+	return taxon
+}
+
+// Records the taxon as a duplicate of the given taxon node
+func (g *Graph) AddDuplicate(representative int, duplicate int) {
+	if g.Duplicates == nil {
+		g.Duplicates = make(map[int][]int)
+	}
+	g.Duplicates[representative] = append(g.Duplicates[representative], duplicate)
+	sort.Ints(g.Duplicates[representative])
+
+	if duplicate > g.MaxNode {
+		g.MaxNode = duplicate
+	}
+}
+
+// Returns the duplicate groups (each representative followed by its duplicates), sorted
+// by representative
+func (g *Graph) DuplicateGroups() [][]int {
+	var representatives = make([]int, 0, len(g.Duplicates))
+	for representative := range g.Duplicates {
+		representatives = append(representatives, representative)
+	}
+	sort.Ints(representatives)
+
+	var groups = make([][]int, len(representatives))
+	for i, representative := range representatives {
+		groups[i] = append([]int{representative}, g.Duplicates[representative]...)
+	}
+	return groups
+}
+
+// Returns the sorted IDs of taxa that are not leaves
+func (g *Graph) InternalTaxa() []int {
+	var taxa = make([]int, 0)
+	for _, node := range g.TaxonNodes() {
+		if len(g.Edges[node]) > 1 {
+			taxa = append(taxa, node)
+		}
+	}
+	return taxa
+}
+
+// Changes the ID of a node, keeping its edges, label and taxon status
+func (g *Graph) RenameNode(node int, newNode int) error {
+	if _, ok := g.Nodes[node]; !ok {
+		return fmt.Errorf("node %d does not exist in the graph", node)
+	}
+	if node == newNode {
+		return nil
+	}
+	if _, ok := g.Nodes[newNode]; ok {
+		return fmt.Errorf("node %d already exists in the graph", newNode)
+	}
+
+	var isTaxon = g.Taxa != nil && g.IsTaxon(node)
+	var edges = append([]Edge{}, g.Edges[node]...)
+	for _, edge := range edges {
+		if _, err := g.RemoveEdge(edge.Node1, edge.Node2); err != nil {
+			return err
+		}
+	}
+
+	g.AddNode(newNode)
+	for _, edge := range edges {
+		var other = edge.Node1
+		if other == node {
+			other = edge.Node2
+		}
+		if err := g.AddEdge(newNode, other, edge.Weight); err != nil {
+			return err
+		}
+	}
+
+	delete(g.Nodes, node)
+	delete(g.Edges, node)
+	if label, ok := g.Labels[node]; ok {
+		delete(g.Labels, node)
+		g.Labels[newNode] = label
+	}
+	if isTaxon {
+		delete(g.Taxa, node)
+		g.Taxa[newNode] = struct{}{}
+	}
+	if duplicates, ok := g.Duplicates[node]; ok {
+		delete(g.Duplicates, node)
+		g.Duplicates[newNode] = duplicates
+	}
+
+	return nil
+}
+
 func (g *Graph) AddNode(node int) bool {
 	if _, ok := g.Nodes[node]; ok {
 		return false
@@ -113,6 +321,8 @@ func (g *Graph) RemoveEdge(node1 int, node2 int) (bool, error) {
 		g.AllEdges = append(g.AllEdges[:index3], g.AllEdges[index3+1:]...)
 	}
 
+	delete(g.Support, [2]int{node1, node2})
+
 	if !(index1 != -1 && index2 != -1 && index3 != -1) && !(index1 == -1 && index2 == -1 && index3 == -1) {
 		return false, fmt.Errorf("edge %d-%d found in only some lists: (from %d: %v, from %d: %v, from all: %v)", 
 			node1, node2, node1, index1 != -1, node2, index2 != -1, index3 != -1)
@@ -145,6 +355,7 @@ func (g *Graph) MergeNodes(node1 int, node2 int) error {
 	}
 	
 	for _, edge := range edgesToChange {
+		support, hasSupport := g.EdgeSupport(edge.Node1, edge.Node2)
 		g.RemoveEdge(edge.Node1, edge.Node2)
 		if edge.Node1 == node2 {
 			g.AddEdge(node1, edge.Node2, edge.Weight)
@@ -152,6 +363,9 @@ func (g *Graph) MergeNodes(node1 int, node2 int) error {
 		if edge.Node2 == node2 {
 			g.AddEdge(edge.Node1, node1, edge.Weight)
 		}
+		if hasSupport {
+			g.SetEdgeSupport(node1, edge.Node1+edge.Node2-node2, support)
+		}
 	}
 	
 	delete(g.Nodes, node2)
@@ -168,25 +382,60 @@ func (g *Graph) MergeZeroEdges(epsilon float64) error {
 		}
 	}
 
+	// Maps merged nodes to the node they were merged into
 	var merged = make(map[int]int)
-	for _, edge := range zeroEdges {
-		merged[edge.Node1] = edge.Node1
-		merged[edge.Node2] = edge.Node2
+	var find = func(node int) int {
+		for {
+			next, ok := merged[node]
+			if !ok {
+				return node
+			}
+			node = next
+		}
 	}
 
 	for _, edge := range zeroEdges {
-		err := g.MergeNodes(merged[edge.Node1], merged[edge.Node2])
+		// Taxa keep their IDs, so that a taxon merged with an internal node stays recognizable
+		// Two taxa at distance 0 are duplicates: the smaller ID represents both
+		var keep, remove = find(edge.Node1), find(edge.Node2)
+		var duplicate = false
+		if g.Taxa != nil && g.IsTaxon(remove) {
+			if g.IsTaxon(keep) {
+				duplicate = true
+				if remove < keep {
+					keep, remove = remove, keep
+				}
+			} else {
+				keep, remove = remove, keep
+			}
+		}
+
+		err := g.MergeNodes(keep, remove)
 		if err != nil {
 			return err
 		}
 
+		if duplicate {
+			delete(g.Taxa, remove)
+			for _, other := range g.Duplicates[remove] {
+				g.AddDuplicate(keep, other)
+			}
+			delete(g.Duplicates, remove)
+			g.AddDuplicate(keep, remove)
+		} else if label, ok := g.Labels[remove]; ok {
+			delete(g.Labels, remove)
+			if _, exists := g.Labels[keep]; !exists {
+				g.Labels[keep] = label
+			}
+		}
+
 		// PrintTree(g)
 		err = g.ValidateTree()
 		if err != nil {
 			return err
 		}
 
-		merged[edge.Node2] = merged[edge.Node1]
+		merged[remove] = keep
 	}
 
 	return nil
@@ -236,6 +485,73 @@ func (g *Graph) SplitEdges(epsilon float64) error {
 	return nil
 }
 
+// Removes a node with its edges, their supports, its label and taxon status, and the
+// duplicate taxa located at it
+func (g *Graph) RemoveNode(node int) error {
+	if _, ok := g.Nodes[node]; !ok {
+		return fmt.Errorf("node %d does not exist in the graph", node)
+	}
+
+	var edges = append([]Edge{}, g.Edges[node]...)
+	for _, edge := range edges {
+		if _, err := g.RemoveEdge(edge.Node1, edge.Node2); err != nil {
+			return err
+		}
+	}
+
+	delete(g.Nodes, node)
+	delete(g.Edges, node)
+	delete(g.Labels, node)
+	delete(g.Taxa, node)
+	for _, duplicate := range g.Duplicates[node] {
+		delete(g.Labels, duplicate)
+	}
+	delete(g.Duplicates, node)
+	for edge := range g.Support {
+		if edge[0] == node || edge[1] == node {
+			delete(g.Support, edge)
+		}
+	}
+	return nil
+}
+
+// Merges every maximal chain of degree-2 nodes into a single edge whose weight is the
+// length of the chain; the inverse of SplitEdges. Taxa are never removed.
+func (g *Graph) ContractUnaryNodes() error {
+	var nodes = make([]int, 0, len(g.Nodes))
+	for node := range g.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Ints(nodes)
+
+	// Removing a node from a chain leaves the other nodes of the chain with degree 2, so
+	// contracting them one at a time merges the whole chain
+	for _, node := range nodes {
+		if len(g.Edges[node]) != 2 || g.IsTaxon(node) {
+			continue
+		}
+
+		var edge1, edge2 = g.Edges[node][0], g.Edges[node][1]
+		var neighbor1, neighbor2 = edge1.Node1 + edge1.Node2 - node, edge2.Node1 + edge2.Node2 - node
+		// Both edges separate the same taxa, so either support applies to the joined edge
+		support, hasSupport := g.EdgeSupport(node, neighbor1)
+		if !hasSupport {
+			support, hasSupport = g.EdgeSupport(node, neighbor2)
+		}
+		if err := g.RemoveNode(node); err != nil {
+			return err
+		}
+		if err := g.AddEdge(neighbor1, neighbor2, edge1.Weight + edge2.Weight); err != nil {
+			return err
+		}
+		if hasSupport {
+			g.SetEdgeSupport(neighbor1, neighbor2, support)
+		}
+	}
+
+	return nil
+}
+
 func (g *Graph) IsIntegerWeighted(epsilon float64) bool {
 	for _, edge := range g.AllEdges {
 		if math.Abs(edge.Weight - math.Round(edge.Weight)) > epsilon {
@@ -264,3 +580,99 @@ func (g *Graph) ValidateTree() error {
 
 	return nil
 }
+
+// Returns a copy of the graph with node IDs replaced according to the mapping. Nodes
+// missing from the mapping keep their IDs. Labels, taxa and duplicates are carried over.
+func (g *Graph) RenumberNodes(mapping map[int]int) (*Graph, error) {
+	var renumber = func(node int) int {
+		if newNode, ok := mapping[node]; ok {
+			return newNode
+		}
+		return node
+	}
+
+	var renumbered = &Graph{
+		Nodes: map[int]struct{}{},
+		Edges: map[int][]Edge{},
+	}
+	for node := range g.Nodes {
+		if !renumbered.AddNode(renumber(node)) {
+			return nil, fmt.Errorf("node %d is the target of several nodes", renumber(node))
+		}
+	}
+	for _, edge := range g.AllEdges {
+		if err := renumbered.AddEdge(renumber(edge.Node1), renumber(edge.Node2), edge.Weight); err != nil {
+			return nil, err
+		}
+	}
+
+	if g.Labels != nil {
+		renumbered.Labels = make(map[int]string)
+		for node, label := range g.Labels {
+			renumbered.Labels[renumber(node)] = label
+		}
+	}
+	if g.Taxa != nil {
+		renumbered.Taxa = make(map[int]struct{})
+		for node := range g.Taxa {
+			renumbered.Taxa[renumber(node)] = struct{}{}
+		}
+	}
+	for representative, duplicates := range g.Duplicates {
+		for _, duplicate := range duplicates {
+			renumbered.AddDuplicate(renumber(representative), renumber(duplicate))
+		}
+	}
+	for edge, support := range g.Support {
+		renumbered.SetEdgeSupport(renumber(edge[0]), renumber(edge[1]), support)
+	}
+
+	return renumbered, nil
+}
+
+// Finds the smallest positive integer factor that makes every edge weight integral when
+// multiplied by it. Each weight is approximated by a fraction with denominator at most
+// maxScale, and the factor is the least common multiple of those denominators.
+func (g *Graph) IntegerScaleFactor(epsilon float64, maxScale int) (int, error) {
+	var scale = 1
+	for _, edge := range g.AllEdges {
+		var denominator = 0
+		for d := 1; d <= maxScale; d++ {
+			var scaled = edge.Weight * float64(d)
+			if math.Abs(scaled - math.Round(scaled)) <= epsilon * float64(d) {
+				denominator = d
+				break
+			}
+		}
+
+		if denominator == 0 {
+			return 0, fmt.Errorf("edge %d-%d has weight %f which is not a multiple of 1/d for any d <= %d", edge.Node1, edge.Node2, edge.Weight, maxScale)
+		}
+
+		scale = scale / gcd(scale, denominator) * denominator
+		if scale > maxScale {
+			return 0, fmt.Errorf("edge weights need a scale factor of at least %d, which exceeds the limit of %d", scale, maxScale)
+		}
+	}
+
+	return scale, nil
+}
+
+// Multiplies every edge weight by the given factor
+func (g *Graph) ScaleWeights(factor float64) {
+	for i := range g.AllEdges {
+		g.AllEdges[i].Weight *= factor
+	}
+	for node := range g.Edges {
+		for i := range g.Edges[node] {
+			g.Edges[node][i].Weight *= factor
+		}
+	}
+}
+
+func gcd(a int, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,116 @@
+package algorithms
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Builds a star with the given weights on the edges from the center 0 to leaves 1, 2, ...
+func weightedStar(weights ...float64) *Graph {
+	graph := &Graph{Nodes: map[int]struct{}{0: {}}, Edges: map[int][]Edge{}}
+	for i, weight := range weights {
+		graph.AddNode(i + 1)
+		graph.AddEdge(0, i+1, weight)
+	}
+	return graph
+}
+
+func TestIntegerScaleFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		weights  []float64
+		maxScale int
+		want     int
+		wantErr  bool
+	}{
+		{name: "integers", weights: []float64{1, 2, 5}, maxScale: 1, want: 1},
+		{name: "half-integers", weights: []float64{0.5, 1.5, 1}, maxScale: 1000, want: 2},
+		{name: "one-third steps", weights: []float64{1.0 / 3, 2.0 / 3, 2}, maxScale: 1000, want: 3},
+		{name: "halves and thirds", weights: []float64{0.5, 1.0 / 3}, maxScale: 1000, want: 6},
+		{name: "halves with scaling disabled", weights: []float64{0.5, 1}, maxScale: 1, wantErr: true},
+		{name: "halves and thirds over the limit", weights: []float64{0.5, 1.0 / 3}, maxScale: 5, wantErr: true},
+		{name: "irrational weight", weights: []float64{math.Sqrt2}, maxScale: 1000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale, err := weightedStar(tt.weights...).IntegerScaleFactor(1e-9, tt.maxScale)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IntegerScaleFactor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && scale != tt.want {
+				t.Errorf("IntegerScaleFactor() = %d, want %d", scale, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleWeights(t *testing.T) {
+	graph := weightedStar(0.5, 1.0/3, 2)
+	graph.ScaleWeights(6)
+
+	expected := map[int]float64{1: 3, 2: 2, 3: 12}
+	for _, edge := range graph.AllEdges {
+		if math.Abs(edge.Weight-expected[edge.Node2]) > 1e-9 {
+			t.Errorf("edge %d-%d has weight %g, expected %g", edge.Node1, edge.Node2, edge.Weight, expected[edge.Node2])
+		}
+	}
+	for node, edges := range graph.Edges {
+		for _, edge := range edges {
+			var leaf = edge.Node1 + edge.Node2
+			if math.Abs(edge.Weight-expected[leaf]) > 1e-9 {
+				t.Errorf("edge %d-%d in the neighbors of %d has weight %g, expected %g", edge.Node1, edge.Node2, node, edge.Weight, expected[leaf])
+			}
+		}
+	}
+	if !graph.IsIntegerWeighted(1e-9) {
+		t.Errorf("expected integer weights after scaling, got %v", graph.AllEdges)
+	}
+}
+
+func TestGcd(t *testing.T) {
+	tests := []struct{ a, b, want int }{
+		{1, 1, 1},
+		{2, 3, 1},
+		{4, 6, 2},
+		{6, 4, 2},
+		{5, 0, 5},
+		{12, 18, 6},
+	}
+
+	for _, tt := range tests {
+		if got := gcd(tt.a, tt.b); got != tt.want {
+			t.Errorf("gcd(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	// Leaf 1 of the star has a duplicate taxon 4, both labelled, and a supported edge
+	graph := weightedStar(1, 1, 1)
+	graph.AddDuplicate(1, 4)
+	graph.Labels = map[int]string{1: "A", 2: "B", 4: "D"}
+	graph.SetEdgeSupport(0, 1, 0.5)
+	graph.SetEdgeSupport(0, 2, 0.75)
+
+	if err := graph.RemoveNode(1); err != nil {
+		t.Fatalf("RemoveNode returned error: %v", err)
+	}
+	if taxa := graph.AllTaxa(); !reflect.DeepEqual(taxa, []int{2, 3}) {
+		t.Errorf("expected taxa [2 3], got %v", taxa)
+	}
+	if len(graph.Duplicates) != 0 {
+		t.Errorf("expected no duplicates, got %v", graph.Duplicates)
+	}
+	if !reflect.DeepEqual(graph.Labels, map[int]string{2: "B"}) {
+		t.Errorf("expected only the label of taxon 2, got %v", graph.Labels)
+	}
+	if !reflect.DeepEqual(graph.Support, map[[2]int]float64{{0, 2}: 0.75}) {
+		t.Errorf("expected only the support of edge 0-2, got %v", graph.Support)
+	}
+
+	if err := graph.RemoveNode(1); err == nil {
+		t.Errorf("expected an error for a missing node")
+	}
+}
@@ -0,0 +1,244 @@
+package algorithms
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// Answers lowest common ancestor and weighted distance queries between nodes of a tree in
+// constant time, after linear preprocessing. The tree is rooted at an arbitrary node, and the
+// LCA of two nodes is the shallowest node between their first occurrences in an Euler tour.
+// Range minima of the tour are found as in Fischer and Heun (2006): the tour is cut into
+// blocks of about log(n)/2 entries, a sparse table over the block minima answers the part of
+// a range covering whole blocks, and tables per block shape answer the parts inside blocks.
+// Adjacent entries of the tour differ in depth by one, so a block's shape is one bit per
+// step and there are only O(sqrt(n)) shapes.
+type LCAIndex struct {
+	Tree *RootedTree
+	// Node indices in the order of the Euler tour
+	tour []int
+	// Position of the first occurrence of each node index in the Euler tour
+	first     []int
+	blockSize int
+	// blockSparse[k][b] is the tour position of the shallowest entry in the 2^k blocks
+	// starting at block b
+	blockSparse [][]int
+	// Shape of each block, and for each shape the offset of the shallowest entry between
+	// offsets i and j (at i*blockSize + j)
+	blockShapes []int
+	inBlock     map[int][]int
+}
+
+// Builds the LCA index of the tree hanging from the given root in O(n) time
+func NewLCAIndex(graph *Graph, root int) (*LCAIndex, error) {
+	tree, err := NewRootedTree(graph, root)
+	if err != nil {
+		return nil, err
+	}
+	return NewLCAIndexOfRootedTree(tree), nil
+}
+
+// Builds the LCA index of an already rooted tree
+func NewLCAIndexOfRootedTree(tree *RootedTree) *LCAIndex {
+	var n = tree.Size()
+	var index = &LCAIndex{Tree: tree, first: make([]int, n)}
+
+	// Euler tour: every node is written when it is entered and after each of its children
+	var tour = make([]int, 0, 2*n-1)
+	var stack, next = []int{0}, make([]int, n)
+	for len(stack) > 0 {
+		var current = stack[len(stack)-1]
+		if next[current] == 0 {
+			index.first[current] = len(tour)
+		}
+		tour = append(tour, current)
+
+		if next[current] < len(tree.Children[current]) {
+			stack = append(stack, tree.Children[current][next[current]])
+			next[current]++
+		} else {
+			stack = stack[:len(stack)-1]
+		}
+	}
+	index.tour = tour
+
+	index.blockSize = max(1, bits.Len(uint(len(tour)))/2)
+	var blocks = (len(tour) + index.blockSize - 1) / index.blockSize
+	var minima = make([]int, blocks)
+	index.blockShapes = make([]int, blocks)
+	index.inBlock = make(map[int][]int)
+	for block := range minima {
+		var start = block * index.blockSize
+		var shape = 0
+		minima[block] = start
+		for offset := 1; offset < index.blockSize; offset++ {
+			// Past the end of the tour the depth is taken to keep increasing
+			if start+offset >= len(tour) || tree.Depth[tour[start+offset]] > tree.Depth[tour[start+offset-1]] {
+				shape |= 1 << (offset - 1)
+			}
+			if start+offset < len(tour) && tree.Depth[tour[start+offset]] < tree.Depth[tour[minima[block]]] {
+				minima[block] = start + offset
+			}
+		}
+		index.blockShapes[block] = shape
+		if _, ok := index.inBlock[shape]; !ok {
+			index.inBlock[shape] = shapeMinima(shape, index.blockSize)
+		}
+	}
+
+	index.blockSparse = [][]int{minima}
+	for width := 2; width <= blocks; width *= 2 {
+		var previous = index.blockSparse[len(index.blockSparse)-1]
+		var level = make([]int, blocks-width+1)
+		for i := range level {
+			level[i] = index.shallowerPosition(previous[i], previous[i+width/2])
+		}
+		index.blockSparse = append(index.blockSparse, level)
+	}
+
+	return index
+}
+
+// Computes the offsets of the minima of all ranges of a block from its shape, with depths
+// relative to the first entry
+func shapeMinima(shape int, size int) []int {
+	var depth = make([]int, size)
+	for offset := 1; offset < size; offset++ {
+		if shape&(1<<(offset-1)) != 0 {
+			depth[offset] = depth[offset-1] + 1
+		} else {
+			depth[offset] = depth[offset-1] - 1
+		}
+	}
+
+	var minima = make([]int, size*size)
+	for i := 0; i < size; i++ {
+		minima[i*size+i] = i
+		for j := i + 1; j < size; j++ {
+			minima[i*size+j] = minima[i*size+j-1]
+			if depth[j] < depth[minima[i*size+j]] {
+				minima[i*size+j] = j
+			}
+		}
+	}
+	return minima
+}
+
+// Returns whichever of two tour positions holds the shallower node
+func (l *LCAIndex) shallowerPosition(position1 int, position2 int) int {
+	if l.Tree.Depth[l.tour[position2]] < l.Tree.Depth[l.tour[position1]] {
+		return position2
+	}
+	return position1
+}
+
+// Returns the tour position of the shallowest entry between two offsets of a block
+func (l *LCAIndex) minimumInBlock(block int, from int, to int) int {
+	return block*l.blockSize + l.inBlock[l.blockShapes[block]][from*l.blockSize+to]
+}
+
+// Returns the index of the lowest common ancestor of two node indices of the rooted tree
+func (l *LCAIndex) lcaOfIndices(index1 int, index2 int) int {
+	var from, to = l.first[index1], l.first[index2]
+	if from > to {
+		from, to = to, from
+	}
+
+	var fromBlock, toBlock = from / l.blockSize, to / l.blockSize
+	if fromBlock == toBlock {
+		return l.tour[l.minimumInBlock(fromBlock, from%l.blockSize, to%l.blockSize)]
+	}
+
+	var position = l.shallowerPosition(
+		l.minimumInBlock(fromBlock, from%l.blockSize, l.blockSize-1),
+		l.minimumInBlock(toBlock, 0, to%l.blockSize))
+	if fromBlock+1 < toBlock {
+		var level = bits.Len(uint(toBlock-fromBlock-1)) - 1
+		position = l.shallowerPosition(position, l.shallowerPosition(
+			l.blockSparse[level][fromBlock+1], l.blockSparse[level][toBlock-(1<<level)]))
+	}
+	return l.tour[position]
+}
+
+// Returns the weighted distance between two node indices of the rooted tree
+func (l *LCAIndex) distanceOfIndices(index1 int, index2 int) float64 {
+	var lca = l.lcaOfIndices(index1, index2)
+	return l.Tree.Distance[index1] + l.Tree.Distance[index2] - 2*l.Tree.Distance[lca]
+}
+
+func (l *LCAIndex) indicesOf(node1 int, node2 int) (int, int, error) {
+	index1, ok := l.Tree.Index[node1]
+	if !ok {
+		return 0, 0, fmt.Errorf("node %d does not exist in the tree", node1)
+	}
+	index2, ok := l.Tree.Index[node2]
+	if !ok {
+		return 0, 0, fmt.Errorf("node %d does not exist in the tree", node2)
+	}
+	return index1, index2, nil
+}
+
+// Returns the lowest common ancestor of two nodes with respect to the root of the index
+func (l *LCAIndex) LCA(node1 int, node2 int) (int, error) {
+	index1, index2, err := l.indicesOf(node1, node2)
+	if err != nil {
+		return 0, err
+	}
+	return l.Tree.Nodes[l.lcaOfIndices(index1, index2)], nil
+}
+
+// Returns the weighted length of the path between two nodes
+func (l *LCAIndex) Distance(node1 int, node2 int) (float64, error) {
+	index1, index2, err := l.indicesOf(node1, node2)
+	if err != nil {
+		return 0, err
+	}
+	return l.distanceOfIndices(index1, index2), nil
+}
+
+// Returns the nodes on the path between two nodes, including both ends
+func (l *LCAIndex) Path(node1 int, node2 int) ([]int, error) {
+	index1, index2, err := l.indicesOf(node1, node2)
+	if err != nil {
+		return nil, err
+	}
+
+	var lca = l.lcaOfIndices(index1, index2)
+	var up, down = make([]int, 0), make([]int, 0)
+	for index := index1; index != lca; index = l.Tree.Parent[index] {
+		up = append(up, l.Tree.Nodes[index])
+	}
+	for index := index2; index != lca; index = l.Tree.Parent[index] {
+		down = append(down, l.Tree.Nodes[index])
+	}
+
+	var path = append(up, l.Tree.Nodes[lca])
+	for i := len(down) - 1; i >= 0; i-- {
+		path = append(path, down[i])
+	}
+	return path, nil
+}
+
+// Returns the total edge length of the smallest subtree connecting the nodes. Visiting the
+// nodes in pre-order and returning to the first one walks every edge of the subtree twice.
+func (l *LCAIndex) Span(nodes []int) (float64, error) {
+	var indices = make([]int, len(nodes))
+	for i, node := range nodes {
+		index, ok := l.Tree.Index[node]
+		if !ok {
+			return 0, fmt.Errorf("node %d does not exist in the tree", node)
+		}
+		indices[i] = index
+	}
+	if len(indices) < 2 {
+		return 0, nil
+	}
+
+	sort.Ints(indices)
+	var total = l.distanceOfIndices(indices[len(indices)-1], indices[0])
+	for i := 1; i < len(indices); i++ {
+		total += l.distanceOfIndices(indices[i-1], indices[i])
+	}
+	return total / 2, nil
+}
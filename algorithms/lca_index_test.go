@@ -0,0 +1,130 @@
+package algorithms
+
+import (
+	"testing"
+)
+
+func TestLCAIndex(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3} {
+		tree, err := GenerateRandomTree(40, seed, 0.5, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+
+		root := tree.TaxonNodes()[0]
+		index, err := NewLCAIndex(tree, root)
+		if err != nil {
+			t.Fatalf("seed %d: NewLCAIndex returned error: %v", seed, err)
+		}
+
+		// Distances match a traversal of the graph from every node
+		rooted := index.Tree
+		for _, node1 := range rooted.Nodes {
+			distances, _ := weightedDistances(tree, node1)
+			for _, node2 := range rooted.Nodes {
+				distance, err := index.Distance(node1, node2)
+				if err != nil {
+					t.Fatalf("seed %d: Distance returned error: %v", seed, err)
+				}
+				if distance != distances[node2] {
+					t.Errorf("seed %d: distance between %d and %d is %g, expected %g", seed, node1, node2, distance, distances[node2])
+				}
+			}
+		}
+
+		// The LCA is the deepest common node of the paths to the root
+		for _, node1 := range rooted.Nodes[:10] {
+			ancestors := make(map[int]bool)
+			for i := rooted.Index[node1]; i != -1; i = rooted.Parent[i] {
+				ancestors[i] = true
+			}
+			for _, node2 := range rooted.Nodes {
+				expected := rooted.Index[node2]
+				for !ancestors[expected] {
+					expected = rooted.Parent[expected]
+				}
+				if lca, _ := index.LCA(node1, node2); lca != rooted.Nodes[expected] {
+					t.Errorf("seed %d: LCA of %d and %d is %d, expected %d", seed, node1, node2, lca, rooted.Nodes[expected])
+				}
+			}
+		}
+
+		// Paths follow edges and have the length of the distance
+		taxa := tree.TaxonNodes()
+		for _, node2 := range taxa {
+			path, err := index.Path(taxa[1], node2)
+			if err != nil {
+				t.Fatalf("seed %d: Path returned error: %v", seed, err)
+			}
+			length := 0.0
+			for i := 1; i < len(path); i++ {
+				edge := IndexOfEdge(tree.Edges[path[i-1]], path[i-1], path[i])
+				if edge == -1 {
+					t.Fatalf("seed %d: path %v between %d and %d does not follow the edges", seed, path, taxa[1], node2)
+				}
+				length += tree.Edges[path[i-1]][edge].Weight
+			}
+			if distance, _ := index.Distance(taxa[1], node2); path[0] != taxa[1] || path[len(path)-1] != node2 || length != distance {
+				t.Errorf("seed %d: path %v between %d and %d has length %g, expected %g", seed, path, taxa[1], node2, length, distance)
+			}
+		}
+
+		// The subtree connecting all leaves is the whole tree
+		total := 0.0
+		for _, edge := range tree.AllEdges {
+			total += edge.Weight
+		}
+		if span, _ := index.Span(taxa); span != total {
+			t.Errorf("seed %d: span of all taxa is %g, expected %g", seed, span, total)
+		}
+		if span, _ := index.Span(taxa[2:4]); span != index.distanceOfIndices(rooted.Index[taxa[2]], rooted.Index[taxa[3]]) {
+			t.Errorf("seed %d: span of two taxa is %g, expected their distance", seed, span)
+		}
+
+		if _, err := index.Distance(root, tree.MaxNode+1); err == nil {
+			t.Errorf("seed %d: expected an error for a missing node", seed)
+		}
+	}
+}
+
+func TestLCAIndexBlocks(t *testing.T) {
+	// Tours from a single entry to many blocks and levels of the block sparse table
+	single := &Graph{Nodes: map[int]struct{}{0: {}}, Edges: map[int][]Edge{}}
+	trees := []*Graph{single, treeOfEdges([][3]int{{0, 1, 1}}), treeOfEdges([][3]int{{0, 3, 1}, {1, 3, 2}, {2, 3, 3}})}
+	for _, leaves := range []int{5, 17, 300} {
+		tree, err := GenerateRandomTree(leaves, int64(leaves), 0.5, 0.25)
+		if err != nil {
+			t.Fatalf("%d leaves: GenerateRandomTree returned error: %v", leaves, err)
+		}
+		trees = append(trees, tree)
+	}
+
+	for _, tree := range trees {
+		var root int
+		for node := range tree.Nodes {
+			root = node
+			break
+		}
+		index, err := NewLCAIndex(tree, root)
+		if err != nil {
+			t.Fatalf("NewLCAIndex returned error: %v", err)
+		}
+
+		rooted := index.Tree
+		for index1 := 0; index1 < rooted.Size(); index1++ {
+			ancestors := make(map[int]bool)
+			for i := index1; i != -1; i = rooted.Parent[i] {
+				ancestors[i] = true
+			}
+			for index2 := 0; index2 < rooted.Size(); index2++ {
+				expected := index2
+				for !ancestors[expected] {
+					expected = rooted.Parent[expected]
+				}
+				if lca := index.lcaOfIndices(index1, index2); lca != expected {
+					t.Errorf("%d nodes: LCA of indices %d and %d is %d, expected %d", rooted.Size(), index1, index2, lca, expected)
+				}
+			}
+		}
+	}
+}
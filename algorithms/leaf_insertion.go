@@ -0,0 +1,170 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+)
+
+// Describes where a new taxon was attached to the tree
+type LeafInsertion struct {
+	Leaf          int
+	AttachedTo    int
+	SplitEdge     *Edge
+	PendantLength float64
+	// Set if the new taxon is identical to the taxon at AttachedTo and was recorded as
+	// its duplicate rather than added to the graph
+	Duplicate bool
+}
+
+// Inserts a new taxon into the tree, given its distances to all current taxa (keyed by node).
+// The attachment point is found with the three-point condition relative to one fixed taxon,
+// which needs a single traversal of the tree, so the insertion takes O(n) time.
+// If the new taxon lies on the tree itself, it becomes an internal taxon (and Leaf is the
+// node at its position) rather than a new leaf. A taxon identical to an existing one is
+// recorded as its duplicate. The tree is only changed once the distances are known to be
+// consistent with it.
+func InsertLeaf(tree *Graph, distances map[int]float64, epsilon float64) (*LeafInsertion, error) {
+	taxa := tree.TaxonNodes()
+	if len(taxa) < 2 {
+		return nil, fmt.Errorf("tree must have at least 2 taxa, got %d", len(taxa))
+	}
+
+	var allTaxa = tree.AllTaxa()
+	for _, taxon := range allTaxa {
+		if _, ok := distances[taxon]; !ok {
+			return nil, fmt.Errorf("missing distance to taxon %d", taxon)
+		}
+	}
+	if len(distances) != len(allTaxa) {
+		return nil, fmt.Errorf("got %d distances, but the tree has %d taxa", len(distances), len(allTaxa))
+	}
+
+	// Position of the attachment point on the path from a to b, for the b which maximizes it
+	var a = taxa[0]
+	var fromA, parents = weightedDistances(tree, a)
+	var bestTaxon, bestPosition = -1, math.Inf(-1)
+	for _, b := range taxa[1:] {
+		position := (distances[a] + fromA[b] - distances[b]) / 2
+		if position > bestPosition {
+			bestTaxon, bestPosition = b, position
+		}
+	}
+
+	var pendantLength = distances[a] - bestPosition
+	if bestPosition < -epsilon || pendantLength < -epsilon || bestPosition > fromA[bestTaxon]+epsilon {
+		return nil, fmt.Errorf("distances violate the triangle inequality for taxa %d and %d", a, bestTaxon)
+	}
+	bestPosition = math.Max(bestPosition, 0)
+	pendantLength = math.Max(pendantLength, 0)
+
+	// Walk back from the chosen taxon to the edge containing the attachment point
+	var child = bestTaxon
+	for fromA[parents[child]] > bestPosition+epsilon {
+		child = parents[child]
+	}
+	var parent = parents[child]
+
+	insertion := &LeafInsertion{AttachedTo: -1, PendantLength: pendantLength}
+	if math.Abs(fromA[child]-bestPosition) <= epsilon {
+		insertion.AttachedTo = child
+	} else if math.Abs(fromA[parent]-bestPosition) <= epsilon {
+		insertion.AttachedTo = parent
+	}
+
+	// Check that the remaining distances are realized before changing the tree. The path
+	// from the attachment point to any node leaves through parent or child.
+	var toParent, toChild = bestPosition - fromA[parent], fromA[child] - bestPosition
+	if insertion.AttachedTo == child {
+		toParent, toChild = fromA[child]-fromA[parent], 0
+	} else if insertion.AttachedTo == parent {
+		toParent, toChild = 0, fromA[child]-fromA[parent]
+	}
+	var fromParent, _ = weightedDistances(tree, parent)
+	var fromChild, _ = weightedDistances(tree, child)
+	for _, taxon := range allTaxa {
+		var location = tree.TaxonLocation(taxon)
+		var distance = math.Min(fromParent[location]+toParent, fromChild[location]+toChild)
+		if pendantLength > epsilon {
+			distance += pendantLength
+		}
+		if math.Abs(distance-distances[taxon]) > epsilon*math.Max(1, distances[taxon]) {
+			return nil, fmt.Errorf("distance to taxon %d is %f, but the tree implies %f: distances are not consistent with the tree", taxon, distances[taxon], distance)
+		}
+	}
+
+	// Existing leaves stay taxa even if the new taxon makes them internal
+	tree.MakeTaxaExplicit()
+
+	if pendantLength <= epsilon && insertion.AttachedTo != -1 && tree.IsTaxon(insertion.AttachedTo) {
+		insertion.Leaf = tree.MaxNode + 1
+		insertion.PendantLength = 0
+		insertion.Duplicate = true
+		tree.AddDuplicate(insertion.AttachedTo, insertion.Leaf)
+	} else if err := attachNewTaxon(tree, insertion, parent, child, fromA, bestPosition, pendantLength, epsilon); err != nil {
+		return nil, err
+	}
+
+	return insertion, nil
+}
+
+// Adds the new taxon at the attachment point, splitting the edge between parent and child
+// if the point is not a node, and adding a pendant edge unless the taxon lies on the tree
+func attachNewTaxon(tree *Graph, insertion *LeafInsertion, parent int, child int, fromA map[int]float64, bestPosition float64, pendantLength float64, epsilon float64) error {
+	if insertion.AttachedTo == -1 {
+		var weight = fromA[child] - fromA[parent]
+		var edge = Edge{parent, child, weight}
+		if _, err := tree.RemoveEdge(parent, child); err != nil {
+			return err
+		}
+
+		insertion.AttachedTo = tree.AddNewNode()
+		insertion.SplitEdge = &edge
+		if err := tree.AddEdge(parent, insertion.AttachedTo, bestPosition-fromA[parent]); err != nil {
+			return err
+		}
+		if err := tree.AddEdge(insertion.AttachedTo, child, fromA[child]-bestPosition); err != nil {
+			return err
+		}
+	}
+
+	if pendantLength <= epsilon {
+		insertion.Leaf = insertion.AttachedTo
+		insertion.PendantLength = 0
+	} else {
+		insertion.Leaf = tree.AddNewNode()
+		if err := tree.AddEdge(insertion.AttachedTo, insertion.Leaf, pendantLength); err != nil {
+			return err
+		}
+	}
+	tree.SetTaxon(insertion.Leaf)
+
+	return nil
+}
+
+// Computes weighted distances from the start node to all nodes, and the parent of each
+// node on the path from the start node
+func weightedDistances(graph *Graph, start int) (map[int]float64, map[int]int) {
+	distances := map[int]float64{start: 0}
+	parents := map[int]int{start: start}
+	stack := []int{start}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, edge := range graph.Edges[current] {
+			var neighbor = edge.Node1
+			if neighbor == current {
+				neighbor = edge.Node2
+			}
+
+			if _, visited := distances[neighbor]; !visited {
+				distances[neighbor] = distances[current] + edge.Weight
+				parents[neighbor] = current
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+
+	return distances, parents
+}
@@ -0,0 +1,169 @@
+package algorithms
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Reconstructs the tree of the first n-1 taxa of the matrix and inserts taxon n-1 from its row
+func insertLastTaxon(t *testing.T, matrix [][]float64) (*Graph, *LeafInsertion, error) {
+	t.Helper()
+	var n = len(matrix)
+	var submatrix = make([][]float64, n-1)
+	for i := range submatrix {
+		submatrix[i] = matrix[i][:n-1]
+	}
+	tree, err := ReconstructRealTree(submatrix, 1e-10)
+	if err != nil {
+		t.Fatalf("ReconstructRealTree returned error: %v", err)
+	}
+
+	var distances = make(map[int]float64)
+	for taxon := 0; taxon < n-1; taxon++ {
+		distances[taxon] = matrix[n-1][taxon]
+	}
+	insertion, err := InsertLeaf(tree, distances, 1e-10)
+	return tree, insertion, err
+}
+
+// Checks that the tree with the inserted taxon is the reconstruction of the full matrix. The
+// new taxon has the largest ID, so it comes last in the distance matrix like in the input.
+func checkMatchesReconstruction(t *testing.T, tree *Graph, matrix [][]float64) {
+	t.Helper()
+	full, err := ReconstructRealTree(matrix, 1e-10)
+	if err != nil {
+		t.Fatalf("ReconstructRealTree returned error: %v", err)
+	}
+	if len(tree.Nodes) != len(full.Nodes) || len(tree.AllEdges) != len(full.AllEdges) {
+		t.Errorf("expected %d nodes and %d edges as in the full reconstruction, got %d and %d: %v",
+			len(full.Nodes), len(full.AllEdges), len(tree.Nodes), len(tree.AllEdges), tree.AllEdges)
+	}
+
+	expected, err := CalculateDistanceMatrix(full)
+	if err != nil {
+		t.Fatalf("CalculateDistanceMatrix returned error: %v", err)
+	}
+	distances, err := CalculateDistanceMatrix(tree)
+	if err != nil {
+		t.Fatalf("CalculateDistanceMatrix returned error: %v", err)
+	}
+	if !reflect.DeepEqual(distances, expected) {
+		t.Errorf("expected the distances of the full reconstruction %v, got %v", expected, distances)
+	}
+}
+
+func TestInsertLeaf(t *testing.T) {
+	// ((0,1),(2,3)) with nodes 5 and 6 joined by an edge of length 2, and taxon 4 placed
+	// differently in each case
+	base := [][3]int{{0, 5, 1}, {1, 5, 1}, {2, 6, 1}, {3, 6, 1}}
+	tests := []struct {
+		name          string
+		edges         [][3]int
+		wantSplit     bool
+		wantPendant   float64
+		wantDuplicate bool
+	}{
+		{
+			name:        "inside an edge",
+			edges:       [][3]int{{5, 7, 1}, {7, 6, 1}, {4, 7, 3}},
+			wantSplit:   true,
+			wantPendant: 3,
+		},
+		{
+			name:        "at an existing node",
+			edges:       [][3]int{{5, 6, 2}, {4, 5, 2}},
+			wantPendant: 2,
+		},
+		{
+			name:  "on the tree inside an edge",
+			edges: [][3]int{{5, 4, 1}, {4, 6, 1}},
+			// The new taxon is the node splitting the edge
+			wantSplit: true,
+		},
+		{
+			name:          "identical to a taxon",
+			edges:         [][3]int{{5, 6, 2}, {4, 6, 0}},
+			wantDuplicate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			full := treeOfEdges(append(append([][3]int{}, base...), tt.edges...))
+			if tt.wantDuplicate {
+				// Taxon 4 coincides with taxon 3 rather than hanging from node 6
+				full = treeOfEdges([][3]int{{0, 5, 1}, {1, 5, 1}, {5, 6, 2}, {2, 6, 1}, {3, 6, 1}})
+				full.AddDuplicate(3, 4)
+			} else {
+				// Taxon 4 is not always a leaf
+				full.MakeTaxaExplicit()
+				full.SetTaxon(4)
+			}
+			matrix := floatDistanceMatrix(t, full)
+
+			tree, insertion, err := insertLastTaxon(t, matrix)
+			if err != nil {
+				t.Fatalf("InsertLeaf returned error: %v", err)
+			}
+			if (insertion.SplitEdge != nil) != tt.wantSplit {
+				t.Errorf("expected split edge %v, got %v", tt.wantSplit, insertion.SplitEdge)
+			}
+			if insertion.PendantLength != tt.wantPendant {
+				t.Errorf("expected pendant length %g, got %g", tt.wantPendant, insertion.PendantLength)
+			}
+			if insertion.Duplicate != tt.wantDuplicate {
+				t.Errorf("expected duplicate %v, got %v", tt.wantDuplicate, insertion.Duplicate)
+			}
+			checkMatchesReconstruction(t, tree, matrix)
+		})
+	}
+
+	// Random trees, whose last taxon may be a leaf or an internal taxon
+	for _, seed := range []int64{1, 2, 3, 4, 5} {
+		generated, err := GenerateRandomTree(25, seed, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+		matrix := floatDistanceMatrix(t, generated)
+		tree, _, err := insertLastTaxon(t, matrix)
+		if err != nil {
+			t.Fatalf("seed %d: InsertLeaf returned error: %v", seed, err)
+		}
+		checkMatchesReconstruction(t, tree, matrix)
+	}
+}
+
+func TestInsertLeafErrors(t *testing.T) {
+	// ((0,1),(2,3)) with leaf edges of 1 and an internal edge of 2
+	matrix := [][]float64{{0, 2, 4, 4}, {2, 0, 4, 4}, {4, 4, 0, 2}, {4, 4, 2, 0}}
+
+	tests := []struct {
+		name      string
+		distances map[int]float64
+	}{
+		{name: "row missing a taxon", distances: map[int]float64{0: 2, 1: 2, 2: 4}},
+		{name: "row with an extra entry", distances: map[int]float64{0: 2, 1: 2, 2: 4, 3: 4, 4: 1}},
+		{name: "triangle inequality violated", distances: map[int]float64{0: 1, 1: 1, 2: 10, 3: 10}},
+		// Placed at the parent of 0 and 1, but then too close to 3
+		{name: "row inconsistent with the tree", distances: map[int]float64{0: 1, 1: 1, 2: 3, 3: 1}},
+		// Placed inside the edge of taxon 0, but then too far from 3
+		{name: "row inconsistent with the tree inside an edge", distances: map[int]float64{0: 1, 1: 2, 2: 4, 3: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := ReconstructRealTree(matrix, 1e-10)
+			if err != nil {
+				t.Fatalf("ReconstructRealTree returned error: %v", err)
+			}
+			edges, taxa := sortedEdges(tree), tree.AllTaxa()
+			if _, err := InsertLeaf(tree, tt.distances, 1e-10); err == nil {
+				t.Errorf("expected an error for distances %v", tt.distances)
+			}
+			// A failed insertion leaves the tree as it was
+			if !reflect.DeepEqual(sortedEdges(tree), edges) || !reflect.DeepEqual(tree.AllTaxa(), taxa) {
+				t.Errorf("tree changed by a failed insertion: %v", tree.AllEdges)
+			}
+		})
+	}
+}
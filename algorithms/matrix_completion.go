@@ -0,0 +1,125 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+)
+
+// Result of filling in the missing (NaN) entries of a distance matrix
+type MatrixCompletion struct {
+	Matrix       [][]float64
+	Inferred     [][2]int
+	Undetermined [][2]int
+}
+
+// Infers missing entries of a distance matrix from the constraints that every tree metric
+// satisfies. For taxa i, j and any k, l with the other five distances known, the four-point
+// condition says that the two largest of d(i,j)+d(k,l), d(i,k)+d(j,l), d(i,l)+d(j,k) are
+// equal, so d(i,j) is determined whenever the last two sums differ. Inferred entries are
+// used to infer further ones until no more progress can be made. Every quadruple that
+// determines an entry must give it the same value, otherwise the known entries conflict and
+// an error is returned. If some entries remain undetermined, the completion listing them is
+// returned along with an error.
+func CompleteDistanceMatrix(matrix [][]float64, epsilon float64) (*MatrixCompletion, error) {
+	var n = len(matrix)
+	var completed = make([][]float64, n)
+	for i := range matrix {
+		completed[i] = append([]float64{}, matrix[i]...)
+	}
+
+	var missing [][2]int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if math.IsNaN(completed[i][j]) {
+				missing = append(missing, [2]int{i, j})
+			}
+		}
+	}
+
+	var result = &MatrixCompletion{Matrix: completed}
+	for len(missing) > 0 {
+		var remaining [][2]int
+		for _, pair := range missing {
+			distance, ok, err := inferDistance(completed, pair[0], pair[1], epsilon)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				remaining = append(remaining, pair)
+				continue
+			}
+
+			if distance < -epsilon {
+				return nil, fmt.Errorf("inferred negative distance %f between %d and %d: matrix is not a tree metric", distance, pair[0], pair[1])
+			}
+
+			distance = math.Max(distance, 0)
+			completed[pair[0]][pair[1]] = distance
+			completed[pair[1]][pair[0]] = distance
+			result.Inferred = append(result.Inferred, pair)
+		}
+
+		if len(remaining) == len(missing) {
+			break
+		}
+		missing = remaining
+	}
+
+	result.Undetermined = missing
+	if len(missing) > 0 {
+		return result, fmt.Errorf("%d missing distances could not be inferred", len(missing))
+	}
+	return result, nil
+}
+
+// Returns the distance between i and j implied by the known entries, and whether any of
+// them determine it. All the taxa and quadruples that determine it must agree.
+func inferDistance(matrix [][]float64, i int, j int, epsilon float64) (float64, bool, error) {
+	var known = func(a int, b int) bool { return !math.IsNaN(matrix[a][b]) }
+
+	var distance, found, source = 0.0, false, ""
+	var imply = func(value float64, describe func() string) error {
+		if !found {
+			distance, found, source = value, true, describe()
+			return nil
+		}
+		if math.Abs(value-distance) > epsilon*math.Max(1, math.Abs(distance)) {
+			return fmt.Errorf("known distances conflict: d(%d,%d) is %g by %s, but %g by %s", i, j, distance, source, value, describe())
+		}
+		return nil
+	}
+
+	for k := range matrix {
+		if k == i || k == j || !known(i, k) || !known(j, k) {
+			continue
+		}
+
+		// A taxon at distance 0 from one of the pair stands in for it
+		if matrix[i][k] <= epsilon {
+			if err := imply(matrix[k][j], func() string { return fmt.Sprintf("taxon %d, identical to %d", k, i) }); err != nil {
+				return 0, false, err
+			}
+		}
+		if matrix[j][k] <= epsilon {
+			if err := imply(matrix[i][k], func() string { return fmt.Sprintf("taxon %d, identical to %d", k, j) }); err != nil {
+				return 0, false, err
+			}
+		}
+
+		for l := k + 1; l < len(matrix); l++ {
+			if l == i || l == j || !known(i, l) || !known(j, l) || !known(k, l) {
+				continue
+			}
+
+			var sum1 = matrix[i][k] + matrix[j][l]
+			var sum2 = matrix[i][l] + matrix[j][k]
+			if math.Abs(sum1-sum2) > epsilon {
+				if err := imply(math.Max(sum1, sum2)-matrix[k][l], func() string { return fmt.Sprintf("taxa %d and %d", k, l) }); err != nil {
+					return 0, false, err
+				}
+			}
+		}
+	}
+
+	return distance, found, nil
+}
@@ -0,0 +1,110 @@
+package algorithms
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Copies the matrix with the given pairs replaced by NaN
+func withMissing(matrix [][]float64, pairs ...[2]int) [][]float64 {
+	var partial = make([][]float64, len(matrix))
+	for i := range matrix {
+		partial[i] = append([]float64{}, matrix[i]...)
+	}
+	for _, pair := range pairs {
+		partial[pair[0]][pair[1]] = math.NaN()
+		partial[pair[1]][pair[0]] = math.NaN()
+	}
+	return partial
+}
+
+func TestCompleteDistanceMatrix(t *testing.T) {
+	// ((0,1),(2,3)) with leaf edges of 1 and an internal edge of 2, where d(0,2) follows from
+	// d(0,1) + d(2,3) < d(0,3) + d(1,2)
+	quartet := [][]float64{{0, 2, 4, 4}, {2, 0, 4, 4}, {4, 4, 0, 2}, {4, 4, 2, 0}}
+	// A caterpillar with taxa 0 and 1 at one end, 2 and 3 along the path and 4 and 5 at the
+	// other end, all edges of length 1
+	caterpillar := [][]float64{
+		{0, 2, 3, 4, 5, 5},
+		{2, 0, 3, 4, 5, 5},
+		{3, 3, 0, 3, 4, 4},
+		{4, 4, 3, 0, 3, 3},
+		{5, 5, 4, 3, 0, 2},
+		{5, 5, 4, 3, 2, 0},
+	}
+	// The quartet with taxon 4 identical to taxon 0
+	withDuplicate := [][]float64{{0, 2, 4, 4, 0}, {2, 0, 4, 4, 2}, {4, 4, 0, 2, 4}, {4, 4, 2, 0, 4}, {0, 2, 4, 4, 0}}
+
+	tests := []struct {
+		name    string
+		matrix  [][]float64
+		missing [][2]int
+	}{
+		{name: "four-point condition", matrix: quartet, missing: [][2]int{{0, 2}}},
+		// Not all three follow from the known entries alone; the last needs an inferred one
+		{name: "inferred entries determine others", matrix: caterpillar, missing: [][2]int{{0, 2}, {0, 3}, {1, 3}}},
+		{name: "identical taxon stands in", matrix: withDuplicate, missing: [][2]int{{2, 4}, {3, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completion, err := CompleteDistanceMatrix(withMissing(tt.matrix, tt.missing...), 1e-10)
+			if err != nil {
+				t.Fatalf("CompleteDistanceMatrix returned error: %v", err)
+			}
+			if !reflect.DeepEqual(completion.Matrix, tt.matrix) {
+				t.Errorf("expected %v, got %v", tt.matrix, completion.Matrix)
+			}
+			if !reflect.DeepEqual(completion.Inferred, tt.missing) {
+				t.Errorf("expected inferred pairs %v, got %v", tt.missing, completion.Inferred)
+			}
+		})
+	}
+}
+
+func TestCompleteDistanceMatrixErrors(t *testing.T) {
+	// A star of 4 taxa with edges of 1 satisfies the four-point condition with all sums equal
+	star := [][]float64{{0, 2, 2, 2}, {2, 0, 2, 2}, {2, 2, 0, 2}, {2, 2, 2, 0}}
+	// A caterpillar with taxa 0 and 1 at one end and 3 and 4 at the other; taxa 1 and 3
+	// give d(0,2) = 3, but d(1,4) is raised from 4 to 5 so taxa 1 and 4 give 2
+	caterpillar := [][]float64{
+		{0, 2, 3, 4, 4},
+		{2, 0, 3, 4, 5},
+		{3, 3, 0, 3, 3},
+		{4, 4, 3, 0, 2},
+		{4, 5, 3, 2, 0},
+	}
+	// d(0,1) would be d(0,3) + d(1,2) - d(2,3) = 3 - 10
+	negative := [][]float64{{0, 0, 1, 2}, {0, 0, 1, 1}, {1, 1, 0, 10}, {2, 1, 10, 0}}
+
+	tests := []struct {
+		name             string
+		matrix           [][]float64
+		missing          [][2]int
+		wantUndetermined [][2]int
+		wantErr          string
+	}{
+		{name: "all sums equal", matrix: star, missing: [][2]int{{0, 1}}, wantUndetermined: [][2]int{{0, 1}}, wantErr: "could not be inferred"},
+		{name: "three taxa", matrix: [][]float64{{0, 2, 2}, {2, 0, 2}, {2, 2, 0}}, missing: [][2]int{{0, 2}}, wantUndetermined: [][2]int{{0, 2}}, wantErr: "could not be inferred"},
+		{name: "taxon with no known distances", matrix: star, missing: [][2]int{{0, 3}, {1, 3}, {2, 3}}, wantUndetermined: [][2]int{{0, 3}, {1, 3}, {2, 3}}, wantErr: "could not be inferred"},
+		{name: "known entries conflict", matrix: caterpillar, missing: [][2]int{{0, 2}}, wantErr: "known distances conflict"},
+		{name: "negative inferred distance", matrix: negative, missing: [][2]int{{0, 1}}, wantErr: "negative distance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completion, err := CompleteDistanceMatrix(withMissing(tt.matrix, tt.missing...), 1e-10)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if tt.wantUndetermined == nil {
+				return
+			}
+			if completion == nil || !reflect.DeepEqual(completion.Undetermined, tt.wantUndetermined) {
+				t.Errorf("expected undetermined pairs %v, got %v", tt.wantUndetermined, completion)
+			}
+		})
+	}
+}
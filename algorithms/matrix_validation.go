@@ -0,0 +1,113 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Result of checking whether a distance matrix can be realized by a tree
+type MatrixValidation struct {
+	// Problems that make the matrix unusable for reconstruction
+	Errors []string
+	// Issues that reconstruction handles, but that the user may want to know about
+	Warnings []string
+	// Groups of identical taxa, keyed by the smallest index of each group
+	Duplicates map[int][]int
+}
+
+// Reports at most this many violations of the same kind
+const maxReportedViolations = 10
+
+// Checks that the matrix is a square, symmetric, non-negative distance matrix with a zero
+// diagonal, and that it is a tree metric: the tree reconstructed from it must realize all
+// distances. Identical taxa are reported as warnings.
+func ValidateDistanceMatrix(matrix [][]float64, epsilon float64) *MatrixValidation {
+	validation := &MatrixValidation{Errors: []string{}, Warnings: []string{}}
+	var n = len(matrix)
+	if n < 2 {
+		validation.Errors = append(validation.Errors, fmt.Sprintf("matrix must have at least 2 rows, got %d", n))
+		return validation
+	}
+
+	for i, row := range matrix {
+		if len(row) != n {
+			validation.Errors = append(validation.Errors, fmt.Sprintf("matrix is not square: row %d has %d elements, but there are %d rows", i, len(row), n))
+			return validation
+		}
+	}
+
+	var violations = 0
+	var report = func(message string) {
+		if violations < maxReportedViolations {
+			validation.Errors = append(validation.Errors, message)
+		}
+		violations++
+	}
+
+	for i := range matrix {
+		if math.Abs(matrix[i][i]) > epsilon {
+			report(fmt.Sprintf("d(%d,%d)=%g is not 0", i, i, matrix[i][i]))
+		}
+		for j := range matrix {
+			if matrix[i][j] < -epsilon {
+				report(fmt.Sprintf("d(%d,%d)=%g is negative", i, j, matrix[i][j]))
+			}
+			if j > i && math.Abs(matrix[i][j]-matrix[j][i]) > epsilon {
+				report(fmt.Sprintf("matrix is not symmetric: d(%d,%d)=%g, but d(%d,%d)=%g", i, j, matrix[i][j], j, i, matrix[j][i]))
+			}
+		}
+	}
+	if violations > 0 {
+		return finishValidation(validation, violations)
+	}
+
+	duplicates, err := FindDuplicateTaxa(matrix, epsilon)
+	if err != nil {
+		validation.Errors = append(validation.Errors, err.Error())
+		return validation
+	}
+	validation.Duplicates = duplicates
+	var representatives = make([]int, 0, len(duplicates))
+	for representative := range duplicates {
+		representatives = append(representatives, representative)
+	}
+	sort.Ints(representatives)
+	for _, representative := range representatives {
+		for _, duplicate := range duplicates[representative] {
+			validation.Warnings = append(validation.Warnings, fmt.Sprintf("taxa %d and %d are identical (distance 0)", representative, duplicate))
+		}
+	}
+
+	tree, err := ReconstructRealTree(matrix, epsilon)
+	if err != nil {
+		validation.Errors = append(validation.Errors, fmt.Sprintf("matrix cannot be reconstructed: %v", err))
+		return validation
+	}
+
+	index, err := NewLCAIndex(tree, tree.TaxonLocation(0))
+	if err != nil {
+		validation.Errors = append(validation.Errors, fmt.Sprintf("reconstructed tree is invalid: %v", err))
+		return validation
+	}
+
+	// A tree metric is realized exactly by the neighbor joining tree
+	for i := range matrix {
+		for j := i + 1; j < n; j++ {
+			var distance, _ = index.Distance(tree.TaxonLocation(i), tree.TaxonLocation(j))
+			if math.Abs(distance-matrix[i][j]) > epsilon*math.Max(1, matrix[i][j]) {
+				report(fmt.Sprintf("d(%d,%d)=%g does not fit a tree (the reconstructed tree gives %g)", i, j, matrix[i][j], distance))
+			}
+		}
+	}
+
+	return finishValidation(validation, violations)
+}
+
+// Notes how many violations were left out of the report
+func finishValidation(validation *MatrixValidation, violations int) *MatrixValidation {
+	if violations > maxReportedViolations {
+		validation.Errors = append(validation.Errors, fmt.Sprintf("... and %d more", violations-maxReportedViolations))
+	}
+	return validation
+}
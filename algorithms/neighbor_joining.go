@@ -17,11 +17,23 @@ func MatrixToDict(matrix [][]float64) map[int]map[int]float64 {
 	return dict
 }
 
+// Returns the joinable nodes in increasing order, so that sums and ties do not depend on
+// map iteration order
+func sortedJoinable(joinable map[int]struct{}) []int {
+	var joinableList = make([]int, 0, len(joinable))
+	for k := range joinable {
+		joinableList = append(joinableList, k)
+	}
+	sort.Ints(joinableList)
+	return joinableList
+}
+
 func MakeRValues(distances map[int]map[int]float64, joinable map[int]struct{}) map[int]float64 {
 	var r = make(map[int]float64)
-	for i := range joinable {
+	var joinableList = sortedJoinable(joinable)
+	for _, i := range joinableList {
 		var sum = 0.0
-		for j := range joinable {
+		for _, j := range joinableList {
 			sum += distances[i][j]
 		}
 		r[i] = sum
@@ -51,15 +63,17 @@ func PrintTree(tree *Graph) {
 }
 
 func PrintJoinable(joinable map[int]struct{}) {
-	var joinableList = []int{}
-	for k := range joinable {
-		joinableList = append(joinableList, k)
-	}
-	sort.Ints(joinableList)
-	fmt.Printf("Joinable: %v\n", joinableList)
+	fmt.Printf("Joinable: %v\n", sortedJoinable(joinable))
 }
 
 func NeighborJoining(matrix [][]float64) (*Graph, error) {
+	return neighborJoining(matrix, false)
+}
+
+// Runs neighbor joining. With clampNegative, branch lengths and distances to new nodes that
+// come out negative for non-additive matrices are clamped to zero; otherwise a negative
+// branch length is an error.
+func neighborJoining(matrix [][]float64, clampNegative bool) (*Graph, error) {
 	for len(matrix) < 2 {
 		return nil, fmt.Errorf("matrix must have at least 2 rows")
 	}
@@ -74,19 +88,21 @@ func NeighborJoining(matrix [][]float64) (*Graph, error) {
 	var tree = Graph{
 		Nodes: map[int]struct{}{},
 		Edges: map[int][]Edge{},
+		Taxa: map[int]struct{}{},
+	}
+	for i := range matrix {
+		tree.Taxa[i] = struct{}{}
 	}
 
 	for len(joinable) > 2 {
 		var r = MakeRValues(distances, joinable)
 		
+		// Ties, common in integer matrices, go to the first pair in node order
 		var minScore = math.Inf(1)
 		var minI, minJ = -1, -1
-		for i := range joinable {
-			for j := range joinable {
-				if i >= j {
-					continue
-				}
-				
+		var joinableList = sortedJoinable(joinable)
+		for a, i := range joinableList {
+			for _, j := range joinableList[a+1:] {
 				var q = (float64(len(joinable)) - 2) * distances[i][j] - r[i] - r[j]
 				if q < minScore {
 					minScore = q
@@ -102,6 +118,14 @@ func NeighborJoining(matrix [][]float64) (*Graph, error) {
 		var distanceToI = (distances[minI][minJ] + (r[minI] - r[minJ]) / float64(len(joinable) - 2)) / 2
 		var distanceToJ = distances[minI][minJ] - distanceToI
 
+		// Non-additive matrices can produce negative branch lengths - clamp them to zero
+		// and move the difference to the sibling branch
+		if clampNegative && distanceToI < 0 {
+			distanceToI, distanceToJ = 0, distances[minI][minJ]
+		} else if clampNegative && distanceToJ < 0 {
+			distanceToI, distanceToJ = distances[minI][minJ], 0
+		}
+
 		tree.AddNode(u)
 		tree.AddNode(minI)
 		tree.AddNode(minJ)
@@ -126,6 +150,10 @@ func NeighborJoining(matrix [][]float64) (*Graph, error) {
 			}
 
 			distances[u][k] = (distances[minI][k] + distances[minJ][k] - distances[minI][minJ]) / 2
+			// Likewise, distances to the new node cannot be negative
+			if clampNegative && distances[u][k] < 0 {
+				distances[u][k] = 0
+			}
 			distances[k][u] = distances[u][k]
 		}
 		
@@ -140,10 +168,7 @@ func NeighborJoining(matrix [][]float64) (*Graph, error) {
 		// PrintJoinable(joinable)
 	}
 
-	var remaining []int
-	for k := range joinable {
-		remaining = append(remaining, k)
-	}
+	var remaining = sortedJoinable(joinable)
 
 	tree.AddNode(remaining[0])
 	tree.AddNode(remaining[1])
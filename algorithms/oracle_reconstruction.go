@@ -0,0 +1,359 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+)
+
+// Reconstructs a tree by inserting taxa one at a time, querying only the distances needed
+// to locate each new taxon. The attachment point is searched for with a centroid
+// decomposition of the current tree: at each centroid, one representative leaf per
+// branch is queried to decide which branch contains the attachment point, so each
+// insertion takes O(d log n) queries on trees with maximum degree d.
+func ReconstructFromOracle(oracle DistanceOracle, epsilon float64) (*Graph, error) {
+	var n = oracle.Size()
+	if n < 2 {
+		return nil, fmt.Errorf("oracle must have at least 2 taxa, got %d", n)
+	}
+
+	tree := &Graph{
+		Nodes:    make(map[int]struct{}),
+		Edges:    make(map[int][]Edge),
+		AllEdges: make([]Edge, 0),
+		MaxNode:  -1,
+		Taxa:     map[int]struct{}{0: {}},
+	}
+	tree.AddNode(0)
+
+	// Start from the first taxon that is not identical to taxon 0
+	var second = 1
+	for ; second < n; second++ {
+		distance, err := oracle.Distance(0, second)
+		if err != nil {
+			return nil, err
+		}
+		if distance <= epsilon {
+			tree.AddDuplicate(0, second)
+			continue
+		}
+
+		tree.AddNode(second)
+		tree.SetTaxon(second)
+		if err := tree.AddEdge(0, second, distance); err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	var nextInternal = n
+	for taxon := second + 1; taxon < n; taxon++ {
+		if err := insertTaxonFromOracle(tree, taxon, oracle, &nextInternal, epsilon); err != nil {
+			return nil, fmt.Errorf("error inserting taxon %d: %v", taxon, err)
+		}
+	}
+
+	if err := tree.ValidateTree(); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// Position of the attachment point of a new taxon relative to a node
+type oracleLocation struct {
+	// Neighbor in whose direction the attachment point lies, or -1 if it is the node itself
+	direction int
+	// Distance from the node to the attachment point, along the edge to direction
+	offset float64
+	// Distance from the attachment point to the new taxon, if known
+	pendant float64
+}
+
+func insertTaxonFromOracle(tree *Graph, taxon int, oracle DistanceOracle, nextInternal *int, epsilon float64) error {
+	var region = make(map[int]bool)
+	for node := range tree.Nodes {
+		region[node] = true
+	}
+
+	// Distances from the taxon queried so far, so that later steps can reuse them
+	var known = make(map[int]float64)
+
+	for {
+		var center = regionCentroid(tree, region)
+		location, err := locateFromNode(tree, center, taxon, oracle, known, epsilon)
+		if err != nil {
+			return err
+		}
+
+		if location.direction == -1 {
+			return attachTaxon(tree, taxon, center, location.pendant, epsilon)
+		}
+
+		var weight = edgeWeight(tree, center, location.direction)
+		if location.offset < weight-epsilon {
+			var node = *nextInternal
+			*nextInternal++
+			tree.AddNode(node)
+			if _, err := tree.RemoveEdge(center, location.direction); err != nil {
+				return err
+			}
+			if err := tree.AddEdge(center, node, location.offset); err != nil {
+				return err
+			}
+			if err := tree.AddEdge(node, location.direction, weight-location.offset); err != nil {
+				return err
+			}
+			return attachTaxon(tree, taxon, node, location.pendant, epsilon)
+		}
+
+		if !region[location.direction] {
+			return fmt.Errorf("distances are not consistent with a tree near node %d", center)
+		}
+
+		region = componentWithout(tree, region, location.direction, center)
+	}
+}
+
+func attachTaxon(tree *Graph, taxon int, node int, pendant float64, epsilon float64) error {
+	if pendant <= epsilon {
+		if tree.IsTaxon(node) {
+			tree.AddDuplicate(node, taxon)
+			return nil
+		}
+
+		// The taxon lies on the tree, so the node at its position becomes the taxon
+		if err := tree.RenameNode(node, taxon); err != nil {
+			return err
+		}
+		tree.SetTaxon(taxon)
+		return nil
+	}
+
+	tree.AddNode(taxon)
+	tree.SetTaxon(taxon)
+	return tree.AddEdge(node, taxon, pendant)
+}
+
+// Decides whether the attachment point of the taxon is the node itself, or in which
+// branch around it, by querying one representative leaf per branch
+func locateFromNode(tree *Graph, node int, taxon int, oracle DistanceOracle, known map[int]float64, epsilon float64) (*oracleLocation, error) {
+	var neighbors, representatives, toRepresentative = representativeLeaves(tree, node, known)
+
+	// A leaf acts as its own representative, at distance 0
+	if len(neighbors) == 1 {
+		neighbors = append(neighbors, -1)
+		representatives = append(representatives, node)
+		toRepresentative = append(toRepresentative, 0)
+	}
+
+	var query = func(leaf int) (float64, error) {
+		if distance, ok := known[leaf]; ok {
+			return distance, nil
+		}
+
+		distance, err := oracle.Distance(taxon, leaf)
+		if err != nil {
+			return 0, err
+		}
+
+		known[leaf] = distance
+		return distance, nil
+	}
+
+	var queried = make([]float64, len(neighbors))
+	for i := 0; i < 2; i++ {
+		distance, err := query(representatives[i])
+		if err != nil {
+			return nil, err
+		}
+		queried[i] = distance
+	}
+
+	// Where the taxon's path meets the path between the first two representatives,
+	// measured from the first one
+	var meeting = (queried[0] + toRepresentative[0] + toRepresentative[1] - queried[1]) / 2
+	if meeting < toRepresentative[0]-epsilon {
+		var offset = toRepresentative[0] - meeting
+		return &oracleLocation{neighbors[0], offset, queried[0] - meeting}, nil
+	}
+	if meeting > toRepresentative[0]+epsilon {
+		if neighbors[1] == -1 {
+			return nil, fmt.Errorf("distances to leaves %d and %d violate the triangle inequality", representatives[0], node)
+		}
+
+		var offset = meeting - toRepresentative[0]
+		return &oracleLocation{neighbors[1], offset, queried[1] - (toRepresentative[1] - offset)}, nil
+	}
+
+	var height = queried[0] - toRepresentative[0]
+	for i := 2; i < len(neighbors); i++ {
+		distance, err := query(representatives[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if distance < height+toRepresentative[i]-epsilon {
+			var offset = (height + toRepresentative[i] - distance) / 2
+			return &oracleLocation{neighbors[i], offset, height - offset}, nil
+		}
+	}
+
+	return &oracleLocation{-1, 0, height}, nil
+}
+
+// Picks one leaf in the direction of each neighbor of the node and returns the neighbors,
+// the leaves and their distances from the node. Leaves whose distance to the new taxon is
+// already known are preferred, and their branches are listed first.
+func representativeLeaves(tree *Graph, node int, known map[int]float64) ([]int, []int, []float64) {
+	var neighbors = neighborsOf(tree, node)
+	var representatives = make([]int, len(neighbors))
+	var distances = make([]float64, len(neighbors))
+	var hasKnown = make([]bool, len(neighbors))
+
+	for i, neighbor := range neighbors {
+		representatives[i] = -1
+
+		var fromNeighbor, _ = weightedDistancesWithout(tree, neighbor, node)
+		for leaf, distance := range fromNeighbor {
+			if len(tree.Edges[leaf]) != 1 {
+				continue
+			}
+
+			var _, isKnown = known[leaf]
+			if representatives[i] == -1 || (isKnown && !hasKnown[i]) || (isKnown == hasKnown[i] && leaf < representatives[i]) {
+				representatives[i], distances[i], hasKnown[i] = leaf, distance, isKnown
+			}
+		}
+		distances[i] += edgeWeight(tree, node, neighbor)
+	}
+
+	var order = make([]int, 0, len(neighbors))
+	for i := range neighbors {
+		if hasKnown[i] {
+			order = append(order, i)
+		}
+	}
+	for i := range neighbors {
+		if !hasKnown[i] {
+			order = append(order, i)
+		}
+	}
+
+	var sortedNeighbors = make([]int, len(order))
+	var sortedRepresentatives = make([]int, len(order))
+	var sortedDistances = make([]float64, len(order))
+	for i, index := range order {
+		sortedNeighbors[i], sortedRepresentatives[i], sortedDistances[i] = neighbors[index], representatives[index], distances[index]
+	}
+
+	return sortedNeighbors, sortedRepresentatives, sortedDistances
+}
+
+// Like weightedDistances, but does not traverse past the excluded node
+func weightedDistancesWithout(graph *Graph, start int, excluded int) (map[int]float64, map[int]int) {
+	distances := map[int]float64{start: 0, excluded: math.NaN()}
+	parents := map[int]int{start: start}
+	stack := []int{start}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, neighbor := range neighborsOf(graph, current) {
+			if _, visited := distances[neighbor]; !visited {
+				distances[neighbor] = distances[current] + edgeWeight(graph, current, neighbor)
+				parents[neighbor] = current
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+
+	delete(distances, excluded)
+	return distances, parents
+}
+
+// Finds the node of the (connected) region whose removal leaves the smallest largest component
+func regionCentroid(tree *Graph, region map[int]bool) int {
+	var start = -1
+	for node := range region {
+		if start == -1 || node < start {
+			start = node
+		}
+	}
+
+	// Iterative DFS to get a parent-before-child order of the region
+	var order []int
+	var parents = map[int]int{start: -1}
+	var stack = []int{start}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		order = append(order, current)
+		for _, neighbor := range neighborsOf(tree, current) {
+			if _, seen := parents[neighbor]; !seen && region[neighbor] {
+				parents[neighbor] = current
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+
+	var sizes = make(map[int]int)
+	for i := len(order) - 1; i >= 0; i-- {
+		node := order[i]
+		sizes[node]++
+		if parents[node] != -1 {
+			sizes[parents[node]] += sizes[node]
+		}
+	}
+
+	var best, bestSize = start, len(order) + 1
+	for _, node := range order {
+		largest := len(order) - sizes[node]
+		for _, neighbor := range neighborsOf(tree, node) {
+			if region[neighbor] && parents[neighbor] == node {
+				largest = max(largest, sizes[neighbor])
+			}
+		}
+		if largest < bestSize {
+			best, bestSize = node, largest
+		}
+	}
+
+	return best
+}
+
+// Returns the part of the region reachable from start without passing through the excluded node
+func componentWithout(tree *Graph, region map[int]bool, start int, excluded int) map[int]bool {
+	var component = map[int]bool{start: true}
+	var stack = []int{start}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, neighbor := range neighborsOf(tree, current) {
+			if neighbor != excluded && region[neighbor] && !component[neighbor] {
+				component[neighbor] = true
+				stack = append(stack, neighbor)
+			}
+		}
+	}
+	return component
+}
+
+func neighborsOf(tree *Graph, node int) []int {
+	var neighbors = make([]int, 0, len(tree.Edges[node]))
+	for _, edge := range tree.Edges[node] {
+		if edge.Node1 == node {
+			neighbors = append(neighbors, edge.Node2)
+		} else {
+			neighbors = append(neighbors, edge.Node1)
+		}
+	}
+	return neighbors
+}
+
+func edgeWeight(tree *Graph, node1 int, node2 int) float64 {
+	if index := IndexOfEdge(tree.Edges[node1], node1, node2); index != -1 {
+		return tree.Edges[node1][index].Weight
+	}
+	return math.NaN()
+}
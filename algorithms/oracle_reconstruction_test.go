@@ -0,0 +1,71 @@
+package algorithms
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func readTestMatrix(t *testing.T, path string) [][]float64 {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading %s: %v", path, err)
+	}
+
+	var matrix [][]float64
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var row []float64
+		for _, field := range strings.Split(line, ",") {
+			value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				t.Fatalf("error parsing %s: %v", path, err)
+			}
+			row = append(row, value)
+		}
+		matrix = append(matrix, row)
+	}
+
+	return matrix
+}
+
+func TestReconstructFromOracle(t *testing.T) {
+	tests := []struct {
+		input            string
+		maxQueryFraction float64
+	}{
+		{input: "manual2-4", maxQueryFraction: 1},
+		{input: "generated-10", maxQueryFraction: 1},
+		{input: "generated-100", maxQueryFraction: 1},
+		// Trees with bounded degree should need only a small fraction of all pairs
+		{input: "generated-chains-100", maxQueryFraction: 0.2},
+		{input: "generated-chains-200", maxQueryFraction: 0.1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			matrix := readTestMatrix(t, "../test_inputs/"+test.input+".input.txt")
+			oracle := NewCountingOracle(&MatrixOracle{Matrix: matrix})
+
+			tree, err := ReconstructFromOracle(oracle, 1e-9)
+			if err != nil {
+				t.Fatalf("ReconstructFromOracle returned error: %v", err)
+			}
+
+			for i := range matrix {
+				distances, _ := weightedDistances(tree, i)
+				for j := range matrix {
+					if math.Abs(distances[j]-matrix[i][j]) > 1e-9 {
+						t.Fatalf("distance between %d and %d is %f in the tree, expected %f", i, j, distances[j], matrix[i][j])
+					}
+				}
+			}
+
+			pairs := len(matrix) * (len(matrix) - 1) / 2
+			if float64(oracle.Queries) > test.maxQueryFraction*float64(pairs) {
+				t.Errorf("made %d queries for %d pairs, expected at most %.0f%%", oracle.Queries, pairs, test.maxQueryFraction*100)
+			}
+		})
+	}
+}
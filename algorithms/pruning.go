@@ -0,0 +1,107 @@
+package algorithms
+
+import (
+	"fmt"
+)
+
+// Returns the subtree induced by the given taxa: a copy of the tree without the other taxa
+// and the branches that only led to them. Taxa at internal nodes that are not kept remain
+// as plain internal nodes. With suppressUnary, internal nodes left with two neighbors are
+// removed and their edges joined, summing the weights.
+func PruneToTaxa(tree *Graph, keep []int, suppressUnary bool) (*Graph, error) {
+	if len(keep) == 0 {
+		return nil, fmt.Errorf("at least one taxon must be kept")
+	}
+
+	var kept = make(map[int]bool)
+	for _, taxon := range keep {
+		if !tree.IsTaxon(tree.TaxonLocation(taxon)) {
+			return nil, fmt.Errorf("node %d is not a taxon", taxon)
+		}
+		kept[taxon] = true
+	}
+
+	pruned, err := tree.RenumberNodes(nil)
+	if err != nil {
+		return nil, err
+	}
+	pruned.MakeTaxaExplicit()
+
+	// Drop the taxa that are not kept from each taxon node; if the node's own taxon goes but
+	// one of its duplicates stays, that duplicate takes over the node
+	for _, node := range pruned.TaxonNodes() {
+		var remaining = make([]int, 0)
+		for _, taxon := range append([]int{node}, pruned.Duplicates[node]...) {
+			if kept[taxon] {
+				remaining = append(remaining, taxon)
+			} else {
+				delete(pruned.Labels, taxon)
+			}
+		}
+		delete(pruned.Duplicates, node)
+
+		if len(remaining) == 0 {
+			delete(pruned.Taxa, node)
+			continue
+		}
+
+		if remaining[0] != node {
+			if err := renameTaxonNode(pruned, node, remaining[0], tree.Labels); err != nil {
+				return nil, err
+			}
+		}
+		for _, duplicate := range remaining[1:] {
+			pruned.AddDuplicate(remaining[0], duplicate)
+		}
+	}
+
+	// Remove dead branches: leaves that are not taxa, until there are none left
+	var dead = make([]int, 0)
+	for node := range pruned.Nodes {
+		if len(pruned.Edges[node]) <= 1 && !pruned.IsTaxon(node) {
+			dead = append(dead, node)
+		}
+	}
+	for len(dead) > 0 {
+		var node = dead[len(dead)-1]
+		dead = dead[:len(dead)-1]
+		if _, ok := pruned.Nodes[node]; !ok {
+			continue
+		}
+
+		var neighbors = neighborsOf(pruned, node)
+		if err := pruned.RemoveNode(node); err != nil {
+			return nil, err
+		}
+		for _, neighbor := range neighbors {
+			if len(pruned.Edges[neighbor]) <= 1 && !pruned.IsTaxon(neighbor) {
+				dead = append(dead, neighbor)
+			}
+		}
+	}
+
+	if suppressUnary {
+		if err := pruned.ContractUnaryNodes(); err != nil {
+			return nil, err
+		}
+	}
+
+	// The kept taxa are the leaves again if they were to begin with
+	if tree.Taxa == nil && len(pruned.Nodes) > 1 {
+		pruned.Taxa = nil
+	}
+
+	return pruned, nil
+}
+
+// Moves a taxon node to the ID of one of its duplicates, which keeps its own label
+func renameTaxonNode(graph *Graph, node int, newNode int, labels map[int]string) error {
+	if err := graph.RenameNode(node, newNode); err != nil {
+		return err
+	}
+	delete(graph.Labels, newNode)
+	if label, ok := labels[newNode]; ok {
+		graph.Labels[newNode] = label
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package algorithms
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPruneToTaxa(t *testing.T) {
+	for _, input := range []string{"manual3-7", "generated-12"} {
+		matrix := readTestMatrix(t, "../test_inputs/"+input+".input.txt")
+		tree, err := ReconstructRealTree(matrix, 1e-10)
+		if err != nil {
+			t.Fatalf("%s: ReconstructRealTree returned error: %v", input, err)
+		}
+
+		keep := []int{1, 2, 4, 6}
+		for _, suppressUnary := range []bool{false, true} {
+			pruned, err := PruneToTaxa(tree, keep, suppressUnary)
+			if err != nil {
+				t.Fatalf("%s: PruneToTaxa returned error: %v", input, err)
+			}
+
+			if taxa := pruned.AllTaxa(); !reflect.DeepEqual(taxa, keep) {
+				t.Errorf("%s: pruned tree has taxa %v, expected %v", input, taxa, keep)
+			}
+			if len(pruned.AllEdges) != len(pruned.Nodes)-1 {
+				t.Errorf("%s: pruned tree has %d edges for %d nodes", input, len(pruned.AllEdges), len(pruned.Nodes))
+			}
+
+			// Distances between the kept taxa are preserved
+			index, err := NewLCAIndex(pruned, keep[0])
+			if err != nil {
+				t.Fatalf("%s: NewLCAIndex returned error: %v", input, err)
+			}
+			for _, i := range keep {
+				for _, j := range keep {
+					if distance, _ := index.Distance(i, j); math.Abs(distance-matrix[i][j]) > 1e-9 {
+						t.Errorf("%s: distance between %d and %d is %g after pruning, expected %g", input, i, j, distance, matrix[i][j])
+					}
+				}
+			}
+
+			for node := range pruned.Nodes {
+				if suppressUnary && len(pruned.Edges[node]) == 2 && !pruned.IsTaxon(node) {
+					t.Errorf("%s: node %d has two neighbors after suppressing unary nodes", input, node)
+				}
+			}
+		}
+
+		if _, err := PruneToTaxa(tree, []int{tree.MaxNode}, false); err == nil {
+			t.Errorf("%s: expected an error for an internal node", input)
+		}
+	}
+
+	// A kept duplicate takes over the node of a removed taxon
+	tree := &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}}
+	for node := 0; node <= 3; node++ {
+		tree.AddNode(node)
+	}
+	tree.AddEdge(0, 3, 1)
+	tree.AddEdge(1, 3, 1)
+	tree.AddEdge(2, 3, 1)
+	tree.AddDuplicate(1, 4)
+	pruned, err := PruneToTaxa(tree, []int{0, 4}, true)
+	if err != nil {
+		t.Fatalf("PruneToTaxa returned error: %v", err)
+	}
+	if len(pruned.Nodes) != 2 || IndexOfEdge(pruned.AllEdges, 0, 4) == -1 || pruned.AllEdges[0].Weight != 2 {
+		t.Errorf("expected a single edge 0-4 of weight 2, got %v", pruned.AllEdges)
+	}
+}
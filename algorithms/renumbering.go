@@ -0,0 +1,142 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Order in which internal nodes are given new IDs
+type NumberingPolicy int
+
+const (
+	// Internal nodes keep their relative order
+	NumberingContiguous NumberingPolicy = iota
+	// Internal nodes are numbered in breadth-first order from a root
+	NumberingBFS
+	// Internal nodes are numbered in the order of the canonical form of the tree, so that
+	// isomorphic trees with the same taxa get the same numbering
+	NumberingCanonical
+)
+
+// Parses a numbering policy name as accepted by the --renumber flags
+func ParseNumberingPolicy(name string) (NumberingPolicy, error) {
+	switch name {
+	case "contiguous":
+		return NumberingContiguous, nil
+	case "bfs":
+		return NumberingBFS, nil
+	case "canonical":
+		return NumberingCanonical, nil
+	default:
+		return 0, fmt.Errorf("invalid numbering policy: %s (expected contiguous, bfs or canonical)", name)
+	}
+}
+
+// Maps the internal (non-taxon) nodes to consecutive IDs following the largest taxon ID,
+// in the order given by the policy. Taxa, including duplicates, keep their IDs. The root
+// is only used by the BFS policy.
+func InternalNodeNumbering(graph *Graph, policy NumberingPolicy, root int) (map[int]int, error) {
+	switch policy {
+	case NumberingContiguous:
+		return ContiguousNumbering(graph), nil
+	case NumberingBFS:
+		return BFSNumbering(graph, root)
+	case NumberingCanonical:
+		return CanonicalNumbering(graph)
+	default:
+		return nil, fmt.Errorf("invalid numbering policy: %d", policy)
+	}
+}
+
+// Assigns consecutive IDs following the largest taxon ID to the internal nodes in the order
+// they appear in, skipping taxa
+func numberInOrder(graph *Graph, order []int) map[int]int {
+	var next = 0
+	for _, taxon := range graph.AllTaxa() {
+		if taxon >= next {
+			next = taxon + 1
+		}
+	}
+
+	var mapping = make(map[int]int)
+	for _, node := range order {
+		if _, ok := graph.Nodes[node]; ok && !graph.IsTaxon(node) {
+			mapping[node] = next
+			next++
+		}
+	}
+	return mapping
+}
+
+// Maps the internal (non-taxon) nodes to consecutive IDs following the largest taxon ID,
+// in the order of their current IDs. Taxa, including duplicates, keep their IDs.
+func ContiguousNumbering(graph *Graph) map[int]int {
+	var nodes = make([]int, 0, len(graph.Nodes))
+	for node := range graph.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Ints(nodes)
+	return numberInOrder(graph, nodes)
+}
+
+// Returns the smallest taxon (including duplicates) in the subtree of each node, or MaxInt
+// for subtrees without taxa
+func smallestTaxa(tree *RootedTree) []int {
+	var smallest = make([]int, tree.Size())
+	for _, index := range tree.PostOrder() {
+		smallest[index] = math.MaxInt
+		if tree.IsTaxon(index) {
+			smallest[index] = tree.Nodes[index]
+			for _, duplicate := range tree.Graph.Duplicates[tree.Nodes[index]] {
+				smallest[index] = min(smallest[index], duplicate)
+			}
+		}
+		for _, child := range tree.Children[index] {
+			smallest[index] = min(smallest[index], smallest[child])
+		}
+	}
+	return smallest
+}
+
+// Numbers the internal nodes in breadth-first order from the root, visiting the children
+// of each node in the order of the smallest taxon below them
+func BFSNumbering(graph *Graph, root int) (map[int]int, error) {
+	tree, err := NewRootedTree(graph, root)
+	if err != nil {
+		return nil, err
+	}
+	var smallest = smallestTaxa(tree)
+
+	var order = []int{0}
+	for i := 0; i < len(order); i++ {
+		var children = append([]int{}, tree.Children[order[i]]...)
+		sort.SliceStable(children, func(a, b int) bool {
+			return smallest[children[a]] < smallest[children[b]]
+		})
+		order = append(order, children...)
+	}
+
+	var nodes = make([]int, len(order))
+	for i, index := range order {
+		nodes[i] = tree.Nodes[index]
+	}
+	return numberInOrder(graph, nodes), nil
+}
+
+// Numbers the internal nodes in the canonical pre-order of the tree (see canonicalPreOrder).
+// The numbering depends only on the shape of the tree and the positions of the taxa, not on
+// the current IDs of internal nodes.
+func CanonicalNumbering(graph *Graph) (map[int]int, error) {
+	tree, classes, err := canonicalRooting(graph, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var order = canonicalPreOrder(tree, classes, smallestTaxa(tree))
+	var nodes = make([]int, len(order))
+	for i, index := range order {
+		nodes[i] = tree.Nodes[index]
+	}
+	return numberInOrder(graph, nodes), nil
+}
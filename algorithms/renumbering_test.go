@@ -0,0 +1,79 @@
+package algorithms
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Lists the edges of the graph as sorted node pairs
+func sortedEdges(graph *Graph) [][2]int {
+	edges := make([][2]int, 0, len(graph.AllEdges))
+	for _, edge := range graph.AllEdges {
+		edges = append(edges, [2]int{edge.Node1, edge.Node2})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+	return edges
+}
+
+func TestInternalNodeNumbering(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3, 4} {
+		tree, err := GenerateRandomTree(30, seed, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+
+		// Shuffle the IDs of the internal nodes
+		internal := make([]int, 0)
+		for node := range tree.Nodes {
+			if !tree.IsTaxon(node) {
+				internal = append(internal, node)
+			}
+		}
+		sort.Ints(internal)
+		permutation := rand.New(rand.NewSource(seed)).Perm(len(internal))
+		shuffle := make(map[int]int)
+		for i, node := range internal {
+			shuffle[node] = tree.MaxNode + 1 + permutation[i]
+		}
+		shuffled, err := tree.RenumberNodes(shuffle)
+		if err != nil {
+			t.Fatalf("seed %d: RenumberNodes returned error: %v", seed, err)
+		}
+
+		root := tree.TaxonNodes()[0]
+		for _, policy := range []NumberingPolicy{NumberingContiguous, NumberingBFS, NumberingCanonical} {
+			renumber := func(graph *Graph) *Graph {
+				mapping, err := InternalNodeNumbering(graph, policy, root)
+				if err != nil {
+					t.Fatalf("seed %d, policy %d: InternalNodeNumbering returned error: %v", seed, policy, err)
+				}
+				renumbered, err := graph.RenumberNodes(mapping)
+				if err != nil {
+					t.Fatalf("seed %d, policy %d: RenumberNodes returned error: %v", seed, policy, err)
+				}
+				return renumbered
+			}
+
+			// Internal nodes get the IDs right after the taxa
+			renumbered := renumber(tree)
+			maxTaxon := renumbered.AllTaxa()[len(renumbered.AllTaxa())-1]
+			for node := range renumbered.Nodes {
+				if !renumbered.IsTaxon(node) && (node <= maxTaxon || node > maxTaxon+len(internal)) {
+					t.Errorf("seed %d, policy %d: internal node got ID %d outside %d..%d", seed, policy, node, maxTaxon+1, maxTaxon+len(internal))
+				}
+			}
+
+			// Only the contiguous policy depends on the previous IDs
+			if policy != NumberingContiguous && !reflect.DeepEqual(sortedEdges(renumbered), sortedEdges(renumber(shuffled))) {
+				t.Errorf("seed %d, policy %d: numbering depends on the IDs of internal nodes", seed, policy)
+			}
+		}
+	}
+}
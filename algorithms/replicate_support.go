@@ -0,0 +1,227 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// Random perturbation applied to the distances of a replicate matrix
+type NoiseModel int
+
+const (
+	// Adds normally distributed noise with the scale as standard deviation
+	NoiseGaussian NoiseModel = iota
+	// Draws each distance d as scale * Poisson(d / scale), so larger distances vary more
+	NoisePoisson
+)
+
+// Parses a noise model name as accepted by the --noise flag
+func ParseNoiseModel(name string) (NoiseModel, error) {
+	switch name {
+	case "gaussian":
+		return NoiseGaussian, nil
+	case "poisson":
+		return NoisePoisson, nil
+	default:
+		return 0, fmt.Errorf("invalid noise model: %s (expected gaussian or poisson)", name)
+	}
+}
+
+// Settings for estimating the support of a tree's edges from perturbed matrices
+type ReplicateOptions struct {
+	Replicates int
+	Noise      NoiseModel
+	Scale      float64
+	// Replicate r draws its noise from a generator seeded with Seed + r, so results do not
+	// depend on how replicates are scheduled
+	Seed int64
+	// Rounds perturbed distances to integers, for matrices of integer distances
+	Integral bool
+	Epsilon  float64
+}
+
+// Returns a copy of the matrix with each distance perturbed by the noise model. The copy is
+// symmetric with a zero diagonal. Taxa at distance 0 stay identical, sharing the perturbed
+// distances of the first of them, and distances between other taxa stay positive: at least 1
+// if integral, otherwise at least a millionth of the scale.
+func PerturbMatrix(matrix [][]float64, noise NoiseModel, scale float64, integral bool, rng *rand.Rand) [][]float64 {
+	var minDistance = scale * 1e-6
+	if integral {
+		minDistance = 1
+	}
+
+	var representative = make([]int, len(matrix))
+	var perturbed = make([][]float64, len(matrix))
+	for i := range matrix {
+		representative[i] = i
+		for j := 0; j < i; j++ {
+			if representative[j] == j && matrix[i][j] == 0 {
+				representative[i] = j
+				break
+			}
+		}
+		perturbed[i] = make([]float64, len(matrix))
+	}
+
+	for i := range matrix {
+		for j := i + 1; j < len(matrix); j++ {
+			if representative[i] != i || representative[j] != j {
+				continue
+			}
+			var distance = matrix[i][j]
+			switch noise {
+			case NoiseGaussian:
+				distance += scale * rng.NormFloat64()
+			case NoisePoisson:
+				distance = scale * poissonSample(distance/scale, rng)
+			}
+			if integral {
+				distance = math.Round(distance)
+			}
+			distance = math.Max(distance, minDistance)
+			perturbed[i][j], perturbed[j][i] = distance, distance
+		}
+	}
+
+	for i := range matrix {
+		for j := range matrix {
+			if representative[i] != i || representative[j] != j {
+				perturbed[i][j] = perturbed[representative[i]][representative[j]]
+			}
+		}
+	}
+
+	return perturbed
+}
+
+// Returns a noise scale proportional to the distances of the matrix, so that a distance of
+// the mean size is perturbed by about a tenth of it: a tenth of the mean distance between
+// distinct taxa as gaussian standard deviation, or a hundredth as poisson unit, as the
+// poisson noise of a distance d has standard deviation sqrt(scale * d).
+func DefaultNoiseScale(matrix [][]float64, noise NoiseModel) float64 {
+	var sum, count = 0.0, 0
+	for i := range matrix {
+		for j := i + 1; j < len(matrix); j++ {
+			sum += matrix[i][j]
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+
+	var mean = sum / float64(count)
+	if noise == NoisePoisson {
+		return mean / 100
+	}
+	return mean / 10
+}
+
+// Draws from a Poisson distribution with the given mean. Small means use Knuth's product of
+// uniforms; above 30 the normal approximation is close enough and takes constant time.
+func poissonSample(mean float64, rng *rand.Rand) float64 {
+	if mean <= 0 {
+		return 0
+	}
+	if mean > 30 {
+		return math.Max(math.Round(mean+math.Sqrt(mean)*rng.NormFloat64()), 0)
+	}
+
+	var limit, product, count = math.Exp(-mean), rng.Float64(), 0.0
+	for product > limit {
+		product *= rng.Float64()
+		count++
+	}
+	return count
+}
+
+// Sets the support of each internal edge of a tree reconstructed from the matrix (with the
+// rows as taxa) to the fraction of replicates whose tree has its split. Each replicate
+// perturbs the matrix and reconstructs it as a real-valued tree; replicates run in parallel.
+func ReplicateSupport(tree *Graph, matrix [][]float64, options ReplicateOptions) error {
+	if options.Replicates < 1 {
+		return fmt.Errorf("at least one replicate is required")
+	}
+	if options.Scale <= 0 {
+		return fmt.Errorf("noise scale must be positive, got %g", options.Scale)
+	}
+
+	rooted, splits, err := rootedSplits(tree)
+	if err != nil {
+		return err
+	}
+
+	// Only edges between internal nodes get a support, as every tree has the edges of leaves
+	var edges = make(map[string]int)
+	for index := 1; index < rooted.Size(); index++ {
+		var node, parent = rooted.Nodes[index], rooted.Nodes[rooted.Parent[index]]
+		if len(tree.Edges[node]) > 1 && len(tree.Edges[parent]) > 1 {
+			edges[splits[index].Key()] = index
+		}
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	// Each replicate marks the edges it has, and the marks are counted once all are done
+	var found = make([][]bool, options.Replicates)
+	var errs = make([]error, options.Replicates)
+	var jobs = make(chan int)
+	var wait sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), options.Replicates); w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for r := range jobs {
+				found[r], errs[r] = replicateSplits(matrix, edges, rooted.Size(), options, options.Seed+int64(r))
+			}
+		}()
+	}
+	for r := 0; r < options.Replicates; r++ {
+		jobs <- r
+	}
+	close(jobs)
+	wait.Wait()
+
+	var counts = make([]int, rooted.Size())
+	for r := range found {
+		if errs[r] != nil {
+			return fmt.Errorf("replicate %d: %v", r+1, errs[r])
+		}
+		for index, ok := range found[r] {
+			if ok {
+				counts[index]++
+			}
+		}
+	}
+
+	for _, index := range edges {
+		var node, parent = rooted.Nodes[index], rooted.Nodes[rooted.Parent[index]]
+		tree.SetEdgeSupport(node, parent, float64(counts[index])/float64(options.Replicates))
+	}
+	return nil
+}
+
+// Reconstructs one perturbed replicate and marks the indices of the given splits it has
+func replicateSplits(matrix [][]float64, edges map[string]int, size int, options ReplicateOptions, seed int64) ([]bool, error) {
+	var perturbed = PerturbMatrix(matrix, options.Noise, options.Scale, options.Integral, rand.New(rand.NewSource(seed)))
+	replicate, err := ReconstructRealTree(perturbed, options.Epsilon)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := TreeSplits(replicate)
+	if err != nil {
+		return nil, err
+	}
+
+	var found = make([]bool, size)
+	for _, split := range splits {
+		if index, ok := edges[split.Key()]; ok {
+			found[index] = true
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,123 @@
+package algorithms
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPerturbMatrix(t *testing.T) {
+	tree, err := GenerateRandomTree(8, 1, 0, 0.25)
+	if err != nil {
+		t.Fatalf("GenerateRandomTree returned error: %v", err)
+	}
+	matrix := floatDistanceMatrix(t, tree)
+
+	for _, noise := range []NoiseModel{NoiseGaussian, NoisePoisson} {
+		perturbed := PerturbMatrix(matrix, noise, 2, true, rand.New(rand.NewSource(7)))
+		changed := false
+		for i := range perturbed {
+			if perturbed[i][i] != 0 {
+				t.Errorf("noise %d: d(%d,%d)=%g, expected 0", noise, i, i, perturbed[i][i])
+			}
+			for j := range perturbed {
+				if perturbed[i][j] != perturbed[j][i] || perturbed[i][j] < 0 || perturbed[i][j] != math.Round(perturbed[i][j]) {
+					t.Errorf("noise %d: d(%d,%d)=%g and d(%d,%d)=%g, expected equal non-negative integers", noise, i, j, perturbed[i][j], j, i, perturbed[j][i])
+				}
+				changed = changed || perturbed[i][j] != matrix[i][j]
+			}
+		}
+		if !changed {
+			t.Errorf("noise %d: no distance was perturbed", noise)
+		}
+	}
+}
+
+func TestReplicateSupport(t *testing.T) {
+	// ((0,1),(2,3)) with a long edge {0,1} | {2,3}, and ((0,1),(2,3),(4,5)) with a short
+	// edge above {4,5}
+	strong := treeOfEdges([][3]int{{0, 4, 10}, {1, 4, 10}, {4, 5, 10}, {2, 5, 10}, {3, 5, 10}})
+	weak := treeOfEdges([][3]int{{0, 6, 10}, {1, 6, 10}, {6, 8, 10}, {2, 7, 10}, {3, 7, 10}, {7, 8, 10}, {8, 9, 1}, {4, 9, 10}, {5, 9, 10}})
+	options := ReplicateOptions{Replicates: 50, Noise: NoiseGaussian, Scale: 2, Seed: 1, Epsilon: 1e-10}
+
+	tree, err := ReconstructRealTree(floatDistanceMatrix(t, strong), 1e-10)
+	if err != nil {
+		t.Fatalf("ReconstructRealTree returned error: %v", err)
+	}
+	if err := ReplicateSupport(tree, floatDistanceMatrix(t, strong), options); err != nil {
+		t.Fatalf("ReplicateSupport returned error: %v", err)
+	}
+	if len(tree.Support) != 1 {
+		t.Fatalf("expected support on the internal edge only, got %v", tree.Support)
+	}
+	for edge, support := range tree.Support {
+		if support != 1 {
+			t.Errorf("edge %v has support %g, expected 1", edge, support)
+		}
+	}
+
+	// The same seed gives the same support, however the replicates are scheduled, also when
+	// rounding to integers makes ties between the pairs neighbor joining may join. Internal
+	// node IDs may differ between reconstructions, so edges are compared by their splits.
+	for _, integral := range []bool{false, true} {
+		options.Integral = integral
+		supports := make([]map[string]float64, 2)
+		for i := range supports {
+			tree, err := ReconstructRealTree(floatDistanceMatrix(t, weak), 1e-10)
+			if err != nil {
+				t.Fatalf("ReconstructRealTree returned error: %v", err)
+			}
+			if err := ReplicateSupport(tree, floatDistanceMatrix(t, weak), options); err != nil {
+				t.Fatalf("integral %v: ReplicateSupport returned error: %v", integral, err)
+			}
+			rooted, splits, err := rootedSplits(tree)
+			if err != nil {
+				t.Fatalf("rootedSplits returned error: %v", err)
+			}
+			supports[i] = make(map[string]float64)
+			for index := 1; index < rooted.Size(); index++ {
+				if support, ok := tree.EdgeSupport(rooted.Nodes[index], rooted.Nodes[rooted.Parent[index]]); ok {
+					supports[i][splits[index].Key()] = support
+				}
+			}
+		}
+		if len(supports[0]) != 3 {
+			t.Fatalf("integral %v: expected support on 3 internal edges, got %v", integral, supports[0])
+		}
+		weakest := 1.0
+		for split, support := range supports[0] {
+			weakest = math.Min(weakest, support)
+			if other, ok := supports[1][split]; !ok || other != support {
+				t.Errorf("integral %v: split %s has support %g and then %g with the same seed", integral, split, support, other)
+			}
+		}
+		if weakest == 1 {
+			t.Errorf("integral %v: expected the short edge to be missing from some replicates, got %v", integral, supports[0])
+		}
+	}
+
+	if err := ReplicateSupport(tree, floatDistanceMatrix(t, strong), ReplicateOptions{Replicates: 0, Scale: 1}); err == nil {
+		t.Errorf("expected an error without replicates")
+	}
+}
+
+func TestDefaultNoiseScale(t *testing.T) {
+	// Distances 2, 4 and 6, with a mean of 4
+	matrix := [][]float64{{0, 2, 4}, {2, 0, 6}, {4, 6, 0}}
+	if scale := DefaultNoiseScale(matrix, NoiseGaussian); math.Abs(scale-0.4) > 1e-12 {
+		t.Errorf("expected a gaussian scale of 0.4, got %g", scale)
+	}
+	if scale := DefaultNoiseScale(matrix, NoisePoisson); math.Abs(scale-0.04) > 1e-12 {
+		t.Errorf("expected a poisson scale of 0.04, got %g", scale)
+	}
+
+	// Scaling the matrix scales the noise
+	for i := range matrix {
+		for j := range matrix[i] {
+			matrix[i][j] *= 100
+		}
+	}
+	if scale := DefaultNoiseScale(matrix, NoiseGaussian); math.Abs(scale-40) > 1e-9 {
+		t.Errorf("expected a gaussian scale of 40 for the scaled matrix, got %g", scale)
+	}
+}
@@ -0,0 +1,187 @@
+package algorithms
+
+import (
+	"fmt"
+)
+
+// A tree hanging from a root node. Nodes are indexed densely in pre-order, so the root has
+// index 0 and every parent comes before its children; per-node data is kept in slices
+// indexed the same way.
+type RootedTree struct {
+	// Graph the tree was built from, which holds the taxa, labels and duplicates
+	Graph *Graph
+	// Node ID of each index
+	Nodes []int
+	// Index of each node ID
+	Index map[int]int
+	// Index of the parent of each node, -1 for the root
+	Parent []int
+	// Indices of the children of each node, in the order of the graph's edges
+	Children [][]int
+	// Weight of the edge to the parent, 0 for the root
+	ParentWeight []float64
+	// Number of edges on the path from the root
+	Depth []int
+	// Weighted distance from the root
+	Distance []float64
+	// Number of nodes in the subtree, including the node itself
+	SubtreeSize []int
+}
+
+// Builds the rooted tree of the graph hanging from the given root node. Fails if the graph
+// is not a tree.
+func NewRootedTree(graph *Graph, root int) (*RootedTree, error) {
+	if _, ok := graph.Nodes[root]; !ok {
+		return nil, fmt.Errorf("root node %d does not exist in the graph", root)
+	}
+
+	var n = len(graph.Nodes)
+	tree := &RootedTree{
+		Graph:        graph,
+		Nodes:        make([]int, 0, n),
+		Index:        make(map[int]int, n),
+		Parent:       make([]int, 0, n),
+		Children:     make([][]int, 0, n),
+		ParentWeight: make([]float64, 0, n),
+		Depth:        make([]int, 0, n),
+		Distance:     make([]float64, 0, n),
+		SubtreeSize:  make([]int, n),
+	}
+
+	// Depth-first traversal with an explicit stack, visiting children in edge order
+	type visit struct {
+		node, parent int
+		weight       float64
+	}
+	var stack = []visit{{node: root, parent: -1}}
+	for len(stack) > 0 {
+		var current = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, visited := tree.Index[current.node]; visited {
+			return nil, fmt.Errorf("graph has a cycle through node %d", current.node)
+		}
+
+		var index = len(tree.Nodes)
+		tree.Index[current.node] = index
+		tree.Nodes = append(tree.Nodes, current.node)
+		tree.Parent = append(tree.Parent, current.parent)
+		tree.Children = append(tree.Children, nil)
+		tree.ParentWeight = append(tree.ParentWeight, current.weight)
+		if current.parent == -1 {
+			tree.Depth = append(tree.Depth, 0)
+			tree.Distance = append(tree.Distance, 0)
+		} else {
+			tree.Depth = append(tree.Depth, tree.Depth[current.parent]+1)
+			tree.Distance = append(tree.Distance, tree.Distance[current.parent]+current.weight)
+			tree.Children[current.parent] = append(tree.Children[current.parent], index)
+		}
+
+		var parentNode = -1
+		if current.parent != -1 {
+			parentNode = tree.Nodes[current.parent]
+		}
+		var edges = graph.Edges[current.node]
+		for i := len(edges) - 1; i >= 0; i-- {
+			var child = edges[i].Node1
+			if child == current.node {
+				child = edges[i].Node2
+			}
+			if child != parentNode {
+				stack = append(stack, visit{node: child, parent: index, weight: edges[i].Weight})
+			}
+		}
+	}
+
+	if len(tree.Nodes) != n {
+		return nil, fmt.Errorf("graph is not connected: %d of %d nodes are reachable from node %d", len(tree.Nodes), n, root)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		tree.SubtreeSize[i]++
+		if tree.Parent[i] != -1 {
+			tree.SubtreeSize[tree.Parent[i]] += tree.SubtreeSize[i]
+		}
+	}
+
+	return tree, nil
+}
+
+// Returns the node ID of the root
+func (t *RootedTree) Root() int {
+	return t.Nodes[0]
+}
+
+// Returns the number of nodes
+func (t *RootedTree) Size() int {
+	return len(t.Nodes)
+}
+
+// Returns true if the node at the index is a taxon
+func (t *RootedTree) IsTaxon(index int) bool {
+	return t.Graph.IsTaxon(t.Nodes[index])
+}
+
+// Returns the node indices in pre-order (parents before children)
+func (t *RootedTree) PreOrder() []int {
+	var order = make([]int, len(t.Nodes))
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// Returns the node indices in post-order (children before parents)
+func (t *RootedTree) PostOrder() []int {
+	var order = make([]int, 0, len(t.Nodes))
+	var stack = []int{0}
+	var expanded = make([]bool, len(t.Nodes))
+	for len(stack) > 0 {
+		var index = stack[len(stack)-1]
+		if expanded[index] {
+			stack = stack[:len(stack)-1]
+			order = append(order, index)
+			continue
+		}
+
+		expanded[index] = true
+		for i := len(t.Children[index]) - 1; i >= 0; i-- {
+			stack = append(stack, t.Children[index][i])
+		}
+	}
+	return order
+}
+
+// Converts the tree back to an undirected graph with the same node IDs, taxa, labels and
+// duplicates
+func (t *RootedTree) ToGraph() *Graph {
+	var graph = &Graph{
+		Nodes: map[int]struct{}{},
+		Edges: map[int][]Edge{},
+	}
+	for index, node := range t.Nodes {
+		graph.AddNode(node)
+		if t.Parent[index] != -1 {
+			graph.AddEdge(t.Nodes[t.Parent[index]], node, t.ParentWeight[index])
+		}
+	}
+
+	if t.Graph.Labels != nil {
+		graph.Labels = make(map[int]string)
+		for node, label := range t.Graph.Labels {
+			graph.Labels[node] = label
+		}
+	}
+	if t.Graph.Taxa != nil {
+		graph.Taxa = make(map[int]struct{})
+		for node := range t.Graph.Taxa {
+			graph.Taxa[node] = struct{}{}
+		}
+	}
+	for representative, duplicates := range t.Graph.Duplicates {
+		for _, duplicate := range duplicates {
+			graph.AddDuplicate(representative, duplicate)
+		}
+	}
+
+	return graph
+}
@@ -0,0 +1,72 @@
+package algorithms
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRootedTree(t *testing.T) {
+	for _, input := range []string{"manual3-7", "generated-12"} {
+		matrix := readTestMatrix(t, "../test_inputs/"+input+".input.txt")
+		graph, err := ReconstructRealTree(matrix, 1e-10)
+		if err != nil {
+			t.Fatalf("%s: ReconstructRealTree returned error: %v", input, err)
+		}
+
+		tree, err := NewRootedTree(graph, 0)
+		if err != nil {
+			t.Fatalf("%s: NewRootedTree returned error: %v", input, err)
+		}
+		if tree.Size() != len(graph.Nodes) || tree.Root() != 0 || tree.SubtreeSize[0] != tree.Size() {
+			t.Fatalf("%s: rooted tree has %d nodes, root %d and root subtree size %d", input, tree.Size(), tree.Root(), tree.SubtreeSize[0])
+		}
+
+		// Distances from the root match the graph, and parents come first in pre-order
+		distances, _ := weightedDistances(graph, 0)
+		for index, node := range tree.Nodes {
+			if tree.Index[node] != index {
+				t.Errorf("%s: index of node %d is %d, expected %d", input, node, tree.Index[node], index)
+			}
+			if math.Abs(tree.Distance[index]-distances[node]) > 1e-9 {
+				t.Errorf("%s: distance of node %d is %g, expected %g", input, node, tree.Distance[index], distances[node])
+			}
+			if index > 0 && tree.Parent[index] >= index {
+				t.Errorf("%s: parent of index %d comes after it", input, index)
+			}
+		}
+
+		// Children come before their parents in post-order
+		position := make([]int, tree.Size())
+		for i, index := range tree.PostOrder() {
+			position[index] = i
+		}
+		for index := 1; index < tree.Size(); index++ {
+			if position[index] > position[tree.Parent[index]] {
+				t.Errorf("%s: index %d comes after its parent in post-order", input, index)
+			}
+		}
+
+		if _, err := NewRootedTree(graph, graph.MaxNode+1); err == nil {
+			t.Errorf("%s: expected an error for a missing root", input)
+		}
+
+		// The graph converts back to the same edges
+		converted := tree.ToGraph()
+		if len(converted.AllEdges) != len(graph.AllEdges) {
+			t.Errorf("%s: converted graph has %d edges, expected %d", input, len(converted.AllEdges), len(graph.AllEdges))
+		}
+		for _, edge := range graph.AllEdges {
+			if IndexOfEdge(converted.Edges[edge.Node1], edge.Node1, edge.Node2) == -1 {
+				t.Errorf("%s: converted graph is missing edge %d-%d", input, edge.Node1, edge.Node2)
+			}
+		}
+	}
+
+	cycle := &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}}
+	cycle.AddEdge(0, 1, 1)
+	cycle.AddEdge(1, 2, 1)
+	cycle.AddEdge(2, 0, 1)
+	if _, err := NewRootedTree(cycle, 0); err == nil {
+		t.Errorf("expected an error for a graph with a cycle")
+	}
+}
@@ -0,0 +1,241 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+)
+
+// Describes where a tree was rooted
+type Rooting struct {
+	Root int
+	// Edge that was split by a new root node, nil if the root is an existing node
+	SplitEdge *Edge
+}
+
+// Places the root on the edge between the nodes at the given distance from node1. A new
+// root node is inserted unless the point is one of the nodes.
+func rootOnEdge(tree *Graph, node1 int, node2 int, distance float64, epsilon float64) (*Rooting, error) {
+	var index = IndexOfEdge(tree.Edges[node1], node1, node2)
+	if index == -1 {
+		return nil, fmt.Errorf("edge %d-%d not found in the tree", node1, node2)
+	}
+	var weight = tree.Edges[node1][index].Weight
+
+	if distance <= epsilon {
+		return &Rooting{Root: node1}, nil
+	}
+	if distance >= weight-epsilon {
+		return &Rooting{Root: node2}, nil
+	}
+
+	var support, hasSupport = tree.EdgeSupport(node1, node2)
+	if _, err := tree.RemoveEdge(node1, node2); err != nil {
+		return nil, err
+	}
+
+	var root = tree.AddNewNode()
+	if err := tree.AddEdge(node1, root, distance); err != nil {
+		return nil, err
+	}
+	if err := tree.AddEdge(root, node2, weight-distance); err != nil {
+		return nil, err
+	}
+	if hasSupport {
+		tree.SetEdgeSupport(node1, root, support)
+		tree.SetEdgeSupport(root, node2, support)
+	}
+
+	return &Rooting{Root: root, SplitEdge: &Edge{node1, node2, weight}}, nil
+}
+
+// Returns the taxon farthest from the start node and its distance
+func farthestTaxon(tree *Graph, start int) (int, float64) {
+	var distances, _ = weightedDistances(tree, start)
+	var farthest, maxDistance = start, math.Inf(-1)
+	for _, taxon := range tree.TaxonNodes() {
+		if distances[taxon] > maxDistance || (distances[taxon] == maxDistance && taxon < farthest) {
+			farthest, maxDistance = taxon, distances[taxon]
+		}
+	}
+	return farthest, maxDistance
+}
+
+// Roots the tree at the midpoint of its longest path between two taxa, inserting a new
+// root node if the midpoint lies inside an edge
+func MidpointRoot(tree *Graph, epsilon float64) (*Rooting, error) {
+	var taxa = tree.TaxonNodes()
+	if len(taxa) == 0 {
+		return nil, fmt.Errorf("tree has no taxa")
+	}
+
+	var a, _ = farthestTaxon(tree, taxa[0])
+	var b, diameter = farthestTaxon(tree, a)
+
+	// Walk from b towards a until the midpoint is passed
+	var fromA, parents = weightedDistances(tree, a)
+	var node = b
+	for node != a && fromA[parents[node]] > diameter/2 {
+		node = parents[node]
+	}
+	if node == a {
+		return &Rooting{Root: a}, nil
+	}
+
+	var parent = parents[node]
+	return rootOnEdge(tree, parent, node, diameter/2-fromA[parent], epsilon)
+}
+
+// Roots the tree on the edge separating the outgroup taxa from the others, at its midpoint.
+// The outgroup must be a clade of the unrooted tree. If it contains all taxa but one, the
+// remaining taxon is the outgroup instead.
+func OutgroupRoot(tree *Graph, outgroup []int, epsilon float64) (*Rooting, error) {
+	var allTaxa = tree.AllTaxa()
+	if len(outgroup) == 0 || len(outgroup) >= len(allTaxa) {
+		return nil, fmt.Errorf("outgroup must contain at least one taxon and leave out at least one, got %d of %d taxa", len(outgroup), len(allTaxa))
+	}
+
+	var inOutgroup = make(map[int]bool)
+	for _, taxon := range outgroup {
+		var location = tree.TaxonLocation(taxon)
+		if !tree.IsTaxon(location) {
+			return nil, fmt.Errorf("outgroup member %d is not a taxon", taxon)
+		}
+		inOutgroup[taxon] = true
+	}
+
+	// A single taxon at an internal node is a root by itself
+	if len(outgroup) == 1 && len(tree.Edges[tree.TaxonLocation(outgroup[0])]) > 1 {
+		return &Rooting{Root: tree.TaxonLocation(outgroup[0])}, nil
+	}
+
+	// Hang the tree from a taxon outside the outgroup, and find the largest clade holding
+	// only outgroup taxa
+	var start = -1
+	for _, taxon := range allTaxa {
+		if !inOutgroup[taxon] {
+			start = tree.TaxonLocation(taxon)
+			break
+		}
+	}
+
+	rooted, err := NewRootedTree(tree, start)
+	if err != nil {
+		return nil, err
+	}
+
+	var inside, total = make([]int, rooted.Size()), make([]int, rooted.Size())
+	for _, index := range rooted.PostOrder() {
+		if rooted.IsTaxon(index) {
+			var node = rooted.Nodes[index]
+			for _, taxon := range append([]int{node}, tree.Duplicates[node]...) {
+				total[index]++
+				if inOutgroup[taxon] {
+					inside[index]++
+				}
+			}
+		}
+		if parent := rooted.Parent[index]; parent != -1 {
+			inside[parent] += inside[index]
+			total[parent] += total[index]
+		}
+	}
+
+	for _, index := range rooted.PreOrder() {
+		if index != 0 && inside[index] == len(outgroup) && total[index] == len(outgroup) {
+			var parent = rooted.Nodes[rooted.Parent[index]]
+			return rootOnEdge(tree, parent, rooted.Nodes[index], rooted.ParentWeight[index]/2, epsilon)
+		}
+	}
+
+	return nil, fmt.Errorf("outgroup %v is not a clade of the tree", outgroup)
+}
+
+// Count, sum and sum of squares of the distances from a node to a set of taxa
+type distanceMoments struct {
+	count, sum, squares float64
+}
+
+// Moves the reference point of the moments by the given distance away from the taxa
+func (m distanceMoments) shift(distance float64) distanceMoments {
+	return distanceMoments{
+		count:   m.count,
+		sum:     m.sum + m.count*distance,
+		squares: m.squares + 2*distance*m.sum + m.count*distance*distance,
+	}
+}
+
+func (m distanceMoments) add(other distanceMoments) distanceMoments {
+	return distanceMoments{m.count + other.count, m.sum + other.sum, m.squares + other.squares}
+}
+
+func (m distanceMoments) subtract(other distanceMoments) distanceMoments {
+	return distanceMoments{m.count - other.count, m.sum - other.sum, m.squares - other.squares}
+}
+
+// Roots the tree at the point minimizing the variance of the root-to-taxon distances
+// (Mai, Sayyari and Mirarab, 2017). Moments of the distances to the taxa on both sides of
+// every edge are computed with two traversals, and the variance along each edge is a
+// quadratic function of the position, so the whole search takes O(n) time.
+func MinVarianceRoot(tree *Graph, epsilon float64) (*Rooting, error) {
+	var taxa = tree.TaxonNodes()
+	if len(taxa) == 0 {
+		return nil, fmt.Errorf("tree has no taxa")
+	}
+	if len(tree.AllEdges) == 0 {
+		return &Rooting{Root: taxa[0]}, nil
+	}
+
+	rooted, err := NewRootedTree(tree, taxa[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// Moments of the taxa below each node, then of all taxa, measured from the node
+	var below = make([]distanceMoments, rooted.Size())
+	for _, index := range rooted.PostOrder() {
+		if rooted.IsTaxon(index) {
+			below[index] = below[index].add(distanceMoments{count: float64(1 + len(tree.Duplicates[rooted.Nodes[index]]))})
+		}
+		if parent := rooted.Parent[index]; parent != -1 {
+			below[parent] = below[parent].add(below[index].shift(rooted.ParentWeight[index]))
+		}
+	}
+
+	var all = make([]distanceMoments, rooted.Size())
+	all[0] = below[0]
+	for _, index := range rooted.PreOrder()[1:] {
+		var weight = rooted.ParentWeight[index]
+		var outside = all[rooted.Parent[index]].subtract(below[index].shift(weight))
+		all[index] = below[index].add(outside.shift(weight))
+	}
+
+	var bestParent, bestNode, bestPosition, bestVariance = -1, -1, 0.0, math.Inf(1)
+	for _, index := range rooted.PreOrder()[1:] {
+		var parent, weight = rooted.Parent[index], rooted.ParentWeight[index]
+		var near = all[parent].subtract(below[index].shift(weight))
+		var far = below[index]
+		var n = near.count + far.count
+
+		// At distance x from the parent: taxa above are at d + x, taxa below at d + weight - x
+		var variance = func(x float64) float64 {
+			var side1, side2 = near.shift(x), far.shift(weight - x)
+			var mean = (side1.sum + side2.sum) / n
+			return (side1.squares+side2.squares)/n - mean*mean
+		}
+
+		// The variance is quadratic in x with leading coefficient 4 * near * far / n^2, and
+		// its derivative at 0 gives the vertex
+		var position = 0.0
+		if near.count > 0 && far.count > 0 {
+			var a = 4 * near.count * far.count / (n * n)
+			var slope = 2*(near.sum-far.shift(weight).sum)/n - 2*(near.count-far.count)*(near.sum+far.shift(weight).sum)/(n*n)
+			position = math.Min(math.Max(-slope/(2*a), 0), weight)
+		}
+
+		if v := variance(position); v < bestVariance-epsilon {
+			bestParent, bestNode, bestPosition, bestVariance = rooted.Nodes[parent], rooted.Nodes[index], position, v
+		}
+	}
+
+	return rootOnEdge(tree, bestParent, bestNode, bestPosition, epsilon)
+}
@@ -0,0 +1,78 @@
+package algorithms
+
+import (
+	"math"
+	"testing"
+)
+
+// Variance of the distances from the node to all taxa
+func rootVariance(tree *Graph, root int) float64 {
+	distances, _ := weightedDistances(tree, root)
+	taxa := tree.TaxonNodes()
+	mean, squares := 0.0, 0.0
+	for _, taxon := range taxa {
+		mean += distances[taxon]
+		squares += distances[taxon] * distances[taxon]
+	}
+	mean /= float64(len(taxa))
+	return squares/float64(len(taxa)) - mean*mean
+}
+
+func TestRooting(t *testing.T) {
+	for _, input := range []string{"manual3-7", "generated-12", "generated-chains-20"} {
+		matrix := readTestMatrix(t, "../test_inputs/"+input+".input.txt")
+		reconstruct := func() *Graph {
+			tree, err := ReconstructRealTree(matrix, 1e-10)
+			if err != nil {
+				t.Fatalf("%s: ReconstructRealTree returned error: %v", input, err)
+			}
+			return tree
+		}
+
+		// The midpoint root is at half the diameter from the farthest taxa
+		tree := reconstruct()
+		_, diameter := farthestTaxon(tree, 0)
+		for _, taxon := range tree.TaxonNodes() {
+			if _, d := farthestTaxon(tree, taxon); d > diameter {
+				diameter = d
+			}
+		}
+		rooting, err := MidpointRoot(tree, 1e-10)
+		if err != nil {
+			t.Fatalf("%s: MidpointRoot returned error: %v", input, err)
+		}
+		if _, height := farthestTaxon(tree, rooting.Root); math.Abs(height-diameter/2) > 1e-9 {
+			t.Errorf("%s: midpoint root is %g from the farthest taxon, expected %g", input, height, diameter/2)
+		}
+
+		// No point sampled along the edges has a smaller root-to-taxon variance
+		tree = reconstruct()
+		rooting, err = MinVarianceRoot(tree, 1e-10)
+		if err != nil {
+			t.Fatalf("%s: MinVarianceRoot returned error: %v", input, err)
+		}
+		best := rootVariance(tree, rooting.Root)
+		for _, edge := range tree.AllEdges {
+			for _, fraction := range []float64{0, 0.1, 0.3, 0.5, 0.7, 0.9} {
+				sampled, _ := tree.RenumberNodes(nil)
+				point, err := rootOnEdge(sampled, edge.Node1, edge.Node2, edge.Weight*fraction, 1e-10)
+				if err != nil {
+					t.Fatalf("%s: rootOnEdge returned error: %v", input, err)
+				}
+				if variance := rootVariance(sampled, point.Root); variance < best-1e-9 {
+					t.Errorf("%s: root at %g along edge %d-%d has variance %g, less than the minimum %g", input, fraction, edge.Node1, edge.Node2, variance, best)
+				}
+			}
+		}
+
+		// The outgroup root splits the pendant edge of the outgroup taxon in half
+		tree = reconstruct()
+		rooting, err = OutgroupRoot(tree, []int{1}, 1e-10)
+		if err != nil {
+			t.Fatalf("%s: OutgroupRoot returned error: %v", input, err)
+		}
+		if neighbors := neighborsOf(tree, rooting.Root); len(neighbors) != 2 || (neighbors[0] != 1 && neighbors[1] != 1) {
+			t.Errorf("%s: outgroup root %d is not next to taxon 1 (neighbors %v)", input, rooting.Root, neighbors)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+)
+
+type DistanceModel int
+
+const (
+	DistanceModelHamming DistanceModel = iota
+	DistanceModelPDistance
+	DistanceModelJukesCantor
+	DistanceModelKimura2P
+)
+
+// Parses a distance model name as accepted by the --model flags
+func ParseDistanceModel(name string) (DistanceModel, error) {
+	switch name {
+	case "hamming":
+		return DistanceModelHamming, nil
+	case "p-distance":
+		return DistanceModelPDistance, nil
+	case "jukes-cantor", "jc69":
+		return DistanceModelJukesCantor, nil
+	case "kimura", "k2p":
+		return DistanceModelKimura2P, nil
+	default:
+		return 0, fmt.Errorf("invalid distance model: %s", name)
+	}
+}
+
+// Computes pairwise distances between aligned sequences using the given model.
+// Sites where either sequence has a gap or an ambiguous residue are skipped.
+func SequenceDistanceMatrix(sequences []string, model DistanceModel) ([][]float64, error) {
+	var n = len(sequences)
+	var matrix = make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			distance, err := sequenceDistance(sequences[i], sequences[j], model)
+			if err != nil {
+				return nil, fmt.Errorf("sequences %d and %d: %v", i, j, err)
+			}
+
+			matrix[i][j] = distance
+			matrix[j][i] = distance
+		}
+	}
+
+	return matrix, nil
+}
+
+func sequenceDistance(a string, b string, model DistanceModel) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("sequences have different lengths (%d and %d)", len(a), len(b))
+	}
+
+	var sites, transitions, transversions = 0, 0, 0
+	for k := 0; k < len(a); k++ {
+		if isAmbiguousResidue(a[k]) || isAmbiguousResidue(b[k]) {
+			continue
+		}
+
+		sites++
+		var baseA, baseB = normalizeBase(a[k]), normalizeBase(b[k])
+		if baseA == baseB {
+			continue
+		}
+
+		if isTransition(baseA, baseB) {
+			transitions++
+		} else {
+			transversions++
+		}
+	}
+
+	var mismatches = transitions + transversions
+	if model == DistanceModelHamming {
+		return float64(mismatches), nil
+	}
+
+	if sites == 0 {
+		return 0, fmt.Errorf("no comparable sites")
+	}
+
+	var p = float64(mismatches) / float64(sites)
+	switch model {
+	case DistanceModelPDistance:
+		return p, nil
+	case DistanceModelJukesCantor:
+		if p >= 0.75 {
+			return 0, fmt.Errorf("p-distance %f is saturated for the Jukes-Cantor correction", p)
+		}
+		return -0.75 * math.Log(1-4.0/3.0*p), nil
+	case DistanceModelKimura2P:
+		var P = float64(transitions) / float64(sites)
+		var Q = float64(transversions) / float64(sites)
+		if 1-2*P-Q <= 0 || 1-2*Q <= 0 {
+			return 0, fmt.Errorf("substitutions are saturated for the Kimura 2-parameter correction (P=%f, Q=%f)", P, Q)
+		}
+		return -0.5*math.Log(1-2*P-Q) - 0.25*math.Log(1-2*Q), nil
+	default:
+		return 0, fmt.Errorf("invalid distance model: %d", model)
+	}
+}
+
+// Gaps, unknown residues and IUPAC ambiguity codes, which could stand for several bases,
+// are treated as missing
+func isAmbiguousResidue(residue byte) bool {
+	switch residue {
+	case '-', '.', '?', 'N', 'X', 'R', 'Y', 'K', 'M', 'S', 'W', 'B', 'D', 'H', 'V':
+		return true
+	default:
+		return false
+	}
+}
+
+// Uracil in RNA takes the place of thymine, so both are compared as T
+func normalizeBase(residue byte) byte {
+	if residue == 'U' {
+		return 'T'
+	}
+	return residue
+}
+
+// Transitions are substitutions between two purines (A, G) or two pyrimidines (C, T), for
+// bases normalized by normalizeBase
+func isTransition(a byte, b byte) bool {
+	var isPurine = func(r byte) bool { return r == 'A' || r == 'G' }
+	var isPyrimidine = func(r byte) bool { return r == 'C' || r == 'T' }
+	return (isPurine(a) && isPurine(b)) || (isPyrimidine(a) && isPyrimidine(b))
+}
@@ -0,0 +1,71 @@
+package algorithms
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSequenceDistance(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		model   DistanceModel
+		want    float64
+		wantErr bool
+	}{
+		{name: "hamming", a: "ACGTACGTAC", b: "ACGTACGTAA", model: DistanceModelHamming, want: 1},
+		{name: "hamming skips gaps and ambiguous residues", a: "AC-TN", b: "ACGAC", model: DistanceModelHamming, want: 1},
+		{name: "p-distance", a: "ACGTACGTAC", b: "ACGTACGTAA", model: DistanceModelPDistance, want: 0.1},
+		{name: "p-distance over comparable sites", a: "ACGT--", b: "ACGAAC", model: DistanceModelPDistance, want: 0.25},
+		{name: "jukes-cantor", a: "ACGTACGTAC", b: "ACGTACGTAA", model: DistanceModelJukesCantor, want: -0.75 * math.Log(1-4.0/3.0*0.1)},
+		{name: "jukes-cantor of identical sequences", a: "ACGT", b: "ACGT", model: DistanceModelJukesCantor, want: 0},
+		// One transition (A-G) and one transversion (A-C) in 10 sites
+		{name: "kimura", a: "ACGTACGTAA", b: "ACGTACGTGC", model: DistanceModelKimura2P, want: -0.5*math.Log(1-0.2-0.1) - 0.25*math.Log(1-0.2)},
+		{name: "kimura with transitions only", a: "AAAAAAAAAA", b: "GAAAAAAAAA", model: DistanceModelKimura2P, want: -0.5 * math.Log(1-0.2)},
+		// R (A or G) and Y (C or T) are missing like N, leaving one transversion in 4 sites
+		{name: "ambiguity codes are skipped", a: "ACGTRA", b: "ACGAAY", model: DistanceModelPDistance, want: 0.25},
+		{name: "ambiguity codes are not transversions", a: "AAAAAAKMSWBDHV", b: "GAAAAAAAAAAAAA", model: DistanceModelKimura2P, want: -0.5 * math.Log(1-2.0/6)},
+		{name: "uracil matches thymine", a: "ACGU", b: "ACGT", model: DistanceModelHamming, want: 0},
+		// U-C is a transition, like T-C
+		{name: "uracil is a pyrimidine", a: "ACGU", b: "ACGC", model: DistanceModelKimura2P, want: -0.5 * math.Log(1-0.5)},
+		{name: "different lengths", a: "ACGT", b: "ACG", model: DistanceModelHamming, wantErr: true},
+		{name: "no comparable sites", a: "--NN", b: "ACGT", model: DistanceModelPDistance, wantErr: true},
+		{name: "saturated jukes-cantor", a: "ACGT", b: "CATG", model: DistanceModelJukesCantor, wantErr: true},
+		{name: "saturated kimura transversions", a: "AAAA", b: "CCCC", model: DistanceModelKimura2P, wantErr: true},
+		{name: "saturated kimura transitions", a: "AAAA", b: "GGGA", model: DistanceModelKimura2P, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sequenceDistance(tt.a, tt.b, tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sequenceDistance(%q, %q) error = %v, wantErr %v", tt.a, tt.b, err, tt.wantErr)
+			}
+			if !tt.wantErr && math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("sequenceDistance(%q, %q) = %g, want %g", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequenceDistanceMatrix(t *testing.T) {
+	matrix, err := SequenceDistanceMatrix([]string{"ACGT", "ACGA", "TCGA"}, DistanceModelHamming)
+	if err != nil {
+		t.Fatalf("SequenceDistanceMatrix returned error: %v", err)
+	}
+	expected := [][]float64{{0, 1, 2}, {1, 0, 1}, {2, 1, 0}}
+	for i := range expected {
+		for j := range expected {
+			if matrix[i][j] != expected[i][j] {
+				t.Errorf("d(%d,%d)=%g, expected %g", i, j, matrix[i][j], expected[i][j])
+			}
+		}
+	}
+
+	if _, err := SequenceDistanceMatrix([]string{"ACGT", "ACG"}, DistanceModelHamming); err == nil {
+		t.Errorf("expected an error for sequences of different lengths")
+	}
+	if _, err := ParseDistanceModel("logdet"); err == nil {
+		t.Errorf("expected an error for an unknown model")
+	}
+}
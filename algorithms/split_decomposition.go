@@ -0,0 +1,248 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A bipartition of the taxa with a weight. Side holds the sorted taxa of the part that
+// does not contain taxon 0 (for splits of a tree, the tree's smallest taxon); the other part
+// is implied.
+type Split struct {
+	Side   []int
+	Weight float64
+}
+
+// Returns a string that identifies the bipartition regardless of its weight
+func (s *Split) Key() string {
+	var taxa = make([]string, len(s.Side))
+	for i, taxon := range s.Side {
+		taxa[i] = strconv.Itoa(taxon)
+	}
+	return strings.Join(taxa, ",")
+}
+
+// Returns true if the taxon is in Side
+func (s *Split) Contains(taxon int) bool {
+	var i = sort.SearchInts(s.Side, taxon)
+	return i < len(s.Side) && s.Side[i] == taxon
+}
+
+// Returns true if the splits can be displayed by the same tree, i.e. if one of the four
+// intersections of their parts is empty. Both splits are over taxa 0..n-1.
+func (s *Split) CompatibleWith(other *Split, n int) bool {
+	var inBoth, onlyThis, onlyOther = false, false, false
+	for taxon := 1; taxon < n; taxon++ {
+		var inThis, inOther = s.Contains(taxon), other.Contains(taxon)
+		inBoth = inBoth || (inThis && inOther)
+		onlyThis = onlyThis || (inThis && !inOther)
+		onlyOther = onlyOther || (!inThis && inOther)
+	}
+
+	// Taxon 0 is outside both sides, so the intersection of the complements is never empty
+	return !inBoth || !onlyThis || !onlyOther
+}
+
+// Returns the splits of the edges of a tree, weighted by edge length, in pre-order of the
+// tree hung from its smallest taxon. Duplicate taxa are on the same side as their
+// representative. Edges that only lead to nodes without taxa are skipped, and each edge of
+// a chain of degree-2 nodes gives the same split.
+func TreeSplits(tree *Graph) ([]Split, error) {
+	rooted, splits, err := rootedSplits(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var result = make([]Split, 0, rooted.Size())
+	for _, split := range splits[1:] {
+		if len(split.Side) > 0 {
+			result = append(result, split)
+		}
+	}
+	return result, nil
+}
+
+// Hangs the tree from its smallest taxon and returns, for each node index, the split of the
+// edge to its parent: the taxa below the node. The root gets an empty split.
+func rootedSplits(tree *Graph) (*RootedTree, []Split, error) {
+	var taxa = tree.AllTaxa()
+	if len(taxa) == 0 {
+		return nil, nil, fmt.Errorf("tree has no taxa")
+	}
+
+	rooted, err := NewRootedTree(tree, tree.TaxonLocation(taxa[0]))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var splits = make([]Split, rooted.Size())
+	for _, index := range rooted.PostOrder() {
+		var side = make([]int, 0)
+		if rooted.IsTaxon(index) {
+			side = append(side, rooted.Nodes[index])
+			side = append(side, tree.Duplicates[rooted.Nodes[index]]...)
+		}
+		for _, child := range rooted.Children[index] {
+			side = append(side, splits[child].Side...)
+		}
+		sort.Ints(side)
+		splits[index] = Split{Side: side, Weight: rooted.ParentWeight[index]}
+	}
+	splits[0].Side = nil
+
+	return rooted, splits, nil
+}
+
+// Computes the d-splits of a distance matrix with their isolation indices (Bandelt and
+// Dress split decomposition). Taxa are added one at a time: every d-split of the first
+// k+1 taxa extends a d-split of the first k taxa, or separates taxon k from the rest.
+// For a tree metric the result is exactly the set of edges of the tree.
+func SplitDecomposition(matrix [][]float64, epsilon float64) ([]Split, error) {
+	var n = len(matrix)
+	if n < 2 {
+		return nil, fmt.Errorf("matrix must have at least 2 rows")
+	}
+
+	// Sides are kept as membership arrays during construction
+	type candidate struct {
+		inSide []bool
+		weight float64
+	}
+
+	var splits = []candidate{{inSide: []bool{false, true}, weight: matrix[0][1]}}
+	for k := 2; k < n; k++ {
+		// Quartets with the taxa closest to k are the most likely to show that a candidate
+		// split is not a d-split, so they are tried first
+		var byDistance = make([]int, k)
+		for i := range byDistance {
+			byDistance[i] = i
+		}
+		sort.Slice(byDistance, func(i, j int) bool {
+			return matrix[k][byDistance[i]] < matrix[k][byDistance[j]]
+		})
+		var extended = make([]candidate, 0, 2*len(splits)+1)
+		for _, split := range splits {
+			for _, kInSide := range []bool{false, true} {
+				var inSide = append(append([]bool{}, split.inSide...), kInSide)
+				var weight = math.Min(split.weight, isolationIndexWithTaxon(matrix, inSide, k, byDistance, epsilon))
+				if weight > epsilon {
+					extended = append(extended, candidate{inSide: inSide, weight: weight})
+				}
+			}
+		}
+
+		// The trivial split of the new taxon
+		var inSide = make([]bool, k+1)
+		inSide[k] = true
+		if weight := isolationIndex(matrix, inSide); weight > epsilon {
+			extended = append(extended, candidate{inSide: inSide, weight: weight})
+		}
+
+		splits = extended
+	}
+
+	var result = make([]Split, len(splits))
+	for i, split := range splits {
+		var side = make([]int, 0)
+		for taxon, in := range split.inSide {
+			if in {
+				side = append(side, taxon)
+			}
+		}
+		result[i] = Split{Side: side, Weight: split.weight}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if len(result[i].Side) != len(result[j].Side) {
+			return len(result[i].Side) < len(result[j].Side)
+		}
+		return result[i].Key() < result[j].Key()
+	})
+
+	return result, nil
+}
+
+// Isolation index contribution of the quartet x,y | u,v
+func quartetIndex(matrix [][]float64, x int, y int, u int, v int) float64 {
+	var sum = math.Max(matrix[x][u]+matrix[y][v], math.Max(matrix[x][v]+matrix[y][u], matrix[x][y]+matrix[u][v]))
+	return (sum - matrix[x][y] - matrix[u][v]) / 2
+}
+
+// Computes the isolation index of the split given by membership of taxa 0..len(inSide)-1
+func isolationIndex(matrix [][]float64, inSide []bool) float64 {
+	var side, rest = splitParts(inSide)
+	var index = math.Inf(1)
+	for _, x := range side {
+		for _, y := range side {
+			for _, u := range rest {
+				for _, v := range rest {
+					index = math.Min(index, quartetIndex(matrix, x, y, u, v))
+				}
+			}
+		}
+	}
+	return index
+}
+
+// Computes the minimum quartet index over the quartets of the split that involve taxon k,
+// which is the last taxon of the membership array. The other taxa are visited in the given
+// order, and the search stops once the index drops to epsilon.
+func isolationIndexWithTaxon(matrix [][]float64, inSide []bool, k int, order []int, epsilon float64) float64 {
+	var side, rest = []int{k}, make([]int, 0, len(order))
+	for _, taxon := range order {
+		if inSide[taxon] == inSide[k] {
+			side = append(side, taxon)
+		} else {
+			rest = append(rest, taxon)
+		}
+	}
+
+	var index = math.Inf(1)
+	for _, u := range rest {
+		for _, y := range side {
+			for _, v := range rest {
+				index = math.Min(index, quartetIndex(matrix, k, y, u, v))
+				if index <= epsilon {
+					return index
+				}
+			}
+		}
+	}
+	return index
+}
+
+func splitParts(inSide []bool) ([]int, []int) {
+	var side, rest = make([]int, 0), make([]int, 0)
+	for taxon, in := range inSide {
+		if in {
+			side = append(side, taxon)
+		} else {
+			rest = append(rest, taxon)
+		}
+	}
+	return side, rest
+}
+
+// Computes how much of the distances the splits explain: the sum of split distances
+// (the total weight of splits separating each pair) relative to the sum of distances
+func SplitFit(matrix [][]float64, splits []Split) float64 {
+	var total, explained = 0.0, 0.0
+	for i := range matrix {
+		for j := i + 1; j < len(matrix); j++ {
+			total += matrix[i][j]
+			for k := range splits {
+				if splits[k].Contains(i) != splits[k].Contains(j) {
+					explained += splits[k].Weight
+				}
+			}
+		}
+	}
+
+	if total == 0 {
+		return 1
+	}
+	return explained / total
+}
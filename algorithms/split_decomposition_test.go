@@ -0,0 +1,91 @@
+package algorithms
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitDecompositionOfTreeMetric(t *testing.T) {
+	for _, input := range []string{"manual3-7", "generated-20", "generated-chains-30"} {
+		matrix := readTestMatrix(t, "../test_inputs/"+input+".input.txt")
+		splits, err := SplitDecomposition(matrix, 1e-10)
+		if err != nil {
+			t.Fatalf("%s: SplitDecomposition returned error: %v", input, err)
+		}
+
+		for i := range splits {
+			for j := range splits {
+				if !splits[i].CompatibleWith(&splits[j], len(matrix)) {
+					t.Errorf("%s: splits %v and %v of a tree metric are incompatible", input, splits[i].Side, splits[j].Side)
+				}
+			}
+		}
+
+		if fit := SplitFit(matrix, splits); math.Abs(fit-1) > 1e-9 {
+			t.Errorf("%s: expected the splits to explain all distances, got fit %f", input, fit)
+		}
+
+		tree, err := ReconstructRealTree(matrix, 1e-10)
+		if err != nil {
+			t.Fatalf("%s: ReconstructRealTree returned error: %v", input, err)
+		}
+		network := BuildSplitNetwork(splits, len(matrix))
+		if !CompareTreeTopology(tree, network.Graph) {
+			t.Errorf("%s: split network of a tree metric does not match the reconstructed tree", input)
+		}
+	}
+}
+
+func TestSplitNetworkRealizesCircularMetric(t *testing.T) {
+	// A circular metric: taxa around a cycle, with conflicting splits
+	matrix := [][]float64{
+		{0, 3, 4, 5, 4},
+		{3, 0, 3, 4, 5},
+		{4, 3, 0, 3, 4},
+		{5, 4, 3, 0, 3},
+		{4, 5, 4, 3, 0},
+	}
+
+	splits, err := SplitDecomposition(matrix, 1e-10)
+	if err != nil {
+		t.Fatalf("SplitDecomposition returned error: %v", err)
+	}
+	if len(splits) != 8 {
+		t.Errorf("expected 8 splits, got %d: %v", len(splits), splits)
+	}
+
+	// Shortest paths in the network must equal the distances
+	network := BuildSplitNetwork(splits, len(matrix)).Graph
+	var nodes []int
+	for node := range network.Nodes {
+		nodes = append(nodes, node)
+	}
+	shortest := make(map[int]map[int]float64)
+	for _, a := range nodes {
+		shortest[a] = map[int]float64{a: 0}
+		for _, b := range nodes {
+			if a != b {
+				shortest[a][b] = math.Inf(1)
+			}
+		}
+	}
+	for _, edge := range network.AllEdges {
+		shortest[edge.Node1][edge.Node2] = edge.Weight
+		shortest[edge.Node2][edge.Node1] = edge.Weight
+	}
+	for _, via := range nodes {
+		for _, a := range nodes {
+			for _, b := range nodes {
+				shortest[a][b] = math.Min(shortest[a][b], shortest[a][via]+shortest[via][b])
+			}
+		}
+	}
+
+	for i := range matrix {
+		for j := range matrix {
+			if math.Abs(shortest[i][j]-matrix[i][j]) > 1e-9 {
+				t.Errorf("network distance between %d and %d is %g, expected %g", i, j, shortest[i][j], matrix[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package algorithms
+
+import (
+	"sort"
+)
+
+// A graph displaying a set of splits: removing all edges of a split disconnects the
+// taxa on its two sides. Compatible splits give a tree, conflicting splits give boxes.
+type SplitNetwork struct {
+	Graph *Graph
+	// Index of the split each edge belongs to, keyed by the edge's nodes in ascending order
+	EdgeSplits map[[2]int]int
+}
+
+// Builds the median network of the splits over taxa 0..n-1 by convex expansion: each
+// split duplicates the vertices in the intersection of the convex hulls of its two sides,
+// and connects the copies with edges of the split's weight. Vertices holding taxa get the
+// smallest taxon ID, taxa at the same vertex are recorded as duplicates, and all other
+// vertices get IDs from n.
+func BuildSplitNetwork(splits []Split, n int) *SplitNetwork {
+	// Each vertex is identified by the side of every processed split it lies on
+	type vertex struct {
+		sides []bool
+	}
+	type vertexEdge struct {
+		from, to, split int
+	}
+
+	var vertices = []vertex{{sides: []bool{}}}
+	var edges = make([]vertexEdge, 0)
+
+	// A vertex is in the convex hull of a set of taxa if each of its sides contains one of them
+	var taxonSides = func(taxon int, count int) []bool {
+		var sides = make([]bool, count)
+		for i := 0; i < count; i++ {
+			sides[i] = splits[i].Contains(taxon)
+		}
+		return sides
+	}
+	var inHull = func(v vertex, taxa []int) bool {
+		for i, side := range v.sides {
+			var found = false
+			for _, taxon := range taxa {
+				if splits[i].Contains(taxon) == side {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+
+	for s, split := range splits {
+		var inside, outside = make([]int, 0), make([]int, 0)
+		for taxon := 0; taxon < n; taxon++ {
+			if split.Contains(taxon) {
+				inside = append(inside, taxon)
+			} else {
+				outside = append(outside, taxon)
+			}
+		}
+
+		// Copies of each old vertex on the inside and outside of the split (-1 if none)
+		var insideCopy, outsideCopy = make([]int, len(vertices)), make([]int, len(vertices))
+		var expanded = make([]vertex, 0, len(vertices))
+		var newEdges = make([]vertexEdge, 0, len(edges))
+		for i, v := range vertices {
+			insideCopy[i], outsideCopy[i] = -1, -1
+			var isInside, isOutside = inHull(v, inside), inHull(v, outside)
+			if !isInside && !isOutside {
+				isOutside = true
+			}
+
+			if isOutside {
+				outsideCopy[i] = len(expanded)
+				expanded = append(expanded, vertex{sides: append(append([]bool{}, v.sides...), false)})
+			}
+			if isInside {
+				insideCopy[i] = len(expanded)
+				expanded = append(expanded, vertex{sides: append(append([]bool{}, v.sides...), true)})
+			}
+			if isInside && isOutside {
+				newEdges = append(newEdges, vertexEdge{outsideCopy[i], insideCopy[i], s})
+			}
+		}
+
+		for _, edge := range edges {
+			for _, copies := range [][]int{insideCopy, outsideCopy} {
+				if copies[edge.from] != -1 && copies[edge.to] != -1 {
+					newEdges = append(newEdges, vertexEdge{copies[edge.from], copies[edge.to], edge.split})
+				}
+			}
+		}
+
+		vertices, edges = expanded, newEdges
+	}
+
+	// Locate the taxa and assign node IDs
+	var ids = make([]int, len(vertices))
+	for i := range ids {
+		ids[i] = -1
+	}
+	var taxaAt = make(map[int][]int)
+	for taxon := 0; taxon < n; taxon++ {
+		var sides = taxonSides(taxon, len(splits))
+		for i, v := range vertices {
+			if equalSides(v.sides, sides) {
+				taxaAt[i] = append(taxaAt[i], taxon)
+				break
+			}
+		}
+	}
+
+	var network = &SplitNetwork{
+		Graph: &Graph{
+			Nodes: map[int]struct{}{},
+			Edges: map[int][]Edge{},
+			Taxa:  map[int]struct{}{},
+		},
+		EdgeSplits: make(map[[2]int]int),
+	}
+	var graph = network.Graph
+
+	var located = make([]int, 0, len(taxaAt))
+	for i := range taxaAt {
+		located = append(located, i)
+	}
+	sort.Ints(located)
+	for _, i := range located {
+		ids[i] = taxaAt[i][0]
+		graph.AddNode(ids[i])
+		graph.Taxa[ids[i]] = struct{}{}
+		for _, duplicate := range taxaAt[i][1:] {
+			graph.AddDuplicate(ids[i], duplicate)
+		}
+	}
+
+	var nextNode = n
+	for i := range vertices {
+		if ids[i] == -1 {
+			ids[i] = nextNode
+			graph.AddNode(nextNode)
+			nextNode++
+		}
+	}
+
+	for _, edge := range edges {
+		var node1, node2 = ids[edge.from], ids[edge.to]
+		graph.AddEdge(node1, node2, splits[edge.split].Weight)
+		if node1 > node2 {
+			node1, node2 = node2, node1
+		}
+		network.EdgeSplits[[2]int{node1, node2}] = edge.split
+	}
+
+	return network
+}
+
+func equalSides(a []bool, b []bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
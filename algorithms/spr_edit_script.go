@@ -0,0 +1,454 @@
+package algorithms
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// A subtree prune and regraft (SPR) move: a subtree is cut off the tree and attached
+// somewhere else
+type SPRMove struct {
+	// Taxa of the moved subtree
+	Subtree []int
+	// Where the subtree is attached, as the taxa of each part of the tree around the point
+	// it is attached to (once the subtree is cut off), sorted by smallest taxon: two parts
+	// for a point inside an edge, and three or more for a node
+	Target [][]int
+}
+
+// Computes a sequence of SPR moves that turns the first tree into the second, comparing
+// only their topologies over their taxa (edge lengths are ignored). Taxa are matched by
+// name as in AlignTaxaByName, and the moves refer to them by their IDs in the first tree.
+// Taxa at internal nodes and duplicate taxa are treated as leaves hanging from their node.
+//
+// This is a heuristic: the taxa are placed one at a time in pre-order of the second tree,
+// and whenever the first tree restricted to the placed taxa differs from the second one,
+// the largest subtree of the new taxon that is also a subtree of the second tree and has
+// no placed taxa is moved to where the taxon is in the second tree. The number of moves is
+// at most the number of taxa minus three, but not necessarily the smallest possible.
+func SPREditScript(tree1, tree2 *Graph) ([]SPRMove, error) {
+	aligned, err := AlignTaxaByName([]*Graph{tree1, tree2})
+	if err != nil {
+		return nil, err
+	}
+	moves, err := sprEditScript(aligned[0], aligned[1])
+	if err != nil {
+		return nil, err
+	}
+
+	// Aligned taxa have the names of the taxa of the first tree
+	var taxonByName = make(map[string]int)
+	for _, taxon := range tree1.AllTaxa() {
+		taxonByName[tree1.NodeName(taxon)] = taxon
+	}
+	var original = func(taxa []int) []int {
+		var result = make([]int, len(taxa))
+		for i, taxon := range taxa {
+			result[i] = taxonByName[aligned[0].NodeName(taxon)]
+		}
+		sort.Ints(result)
+		return result
+	}
+	for i := range moves {
+		moves[i].Subtree = original(moves[i].Subtree)
+		for j := range moves[i].Target {
+			moves[i].Target[j] = original(moves[i].Target[j])
+		}
+		sort.Slice(moves[i].Target, func(a, b int) bool {
+			return moves[i].Target[a][0] < moves[i].Target[b][0]
+		})
+	}
+	return moves, nil
+}
+
+// Computes the SPR moves between trees whose taxa have the same IDs
+func sprEditScript(tree1, tree2 *Graph) ([]SPRMove, error) {
+	var taxa = tree1.AllTaxa()
+	if !reflect.DeepEqual(taxa, tree2.AllTaxa()) {
+		return nil, fmt.Errorf("trees must have the same taxa")
+	}
+
+	current, err := leafTaxaTopology(tree1)
+	if err != nil {
+		return nil, err
+	}
+	target, err := leafTaxaTopology(tree2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Taxa are placed in pre-order of the second tree hung from the smallest taxon, so that
+	// taxa of a clade are placed together. Clades of the second tree are indexed by their
+	// taxa.
+	rootedTarget, err := NewRootedTree(target, taxa[0])
+	if err != nil {
+		return nil, err
+	}
+	var order = make([]int, 0, len(taxa))
+	var targetClades = make(map[string]int, rootedTarget.Size())
+	for _, index := range rootedTarget.PreOrder() {
+		if rootedTarget.IsTaxon(index) {
+			order = append(order, rootedTarget.Nodes[index])
+		}
+		if index != 0 {
+			targetClades[(&Split{Side: subtreeTaxa(rootedTarget, index, nil)}).Key()] = index
+		}
+	}
+
+	// Any three taxa form the same tree, so the first move can only be needed for the
+	// fourth one
+	var moves = make([]SPRMove, 0)
+	for k := 3; k < len(order); k++ {
+		var placed = order[:k+1]
+		same, err := sameTopology(current, target, placed)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			continue
+		}
+
+		parts, err := partsAroundAttachment(target, placed, order[k])
+		if err != nil {
+			return nil, err
+		}
+		move, err := moveNewTaxon(current, order[k], order[:k], parts, rootedTarget, targetClades)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, move)
+
+		if same, err := sameTopology(current, target, placed); err != nil {
+			return nil, err
+		} else if !same {
+			return nil, fmt.Errorf("moving taxon %d did not place it as in the second tree", order[k])
+		}
+	}
+
+	return moves, nil
+}
+
+// Returns a copy of the tree's topology in which every taxon is a leaf: taxon nodes that
+// are not leaves or that have duplicates become plain nodes with their taxa hanging from
+// them, and nodes with two neighbors that are not taxa are removed
+func leafTaxaTopology(tree *Graph) (*Graph, error) {
+	topology, err := tree.RenumberNodes(nil)
+	if err != nil {
+		return nil, err
+	}
+	topology.MakeTaxaExplicit()
+
+	for _, node := range topology.TaxonNodes() {
+		if len(topology.Edges[node]) <= 1 && len(topology.Duplicates[node]) == 0 {
+			continue
+		}
+
+		var taxa = append([]int{node}, topology.Duplicates[node]...)
+		var hub = topology.MaxNode + 1
+		if err := topology.RenameNode(node, hub); err != nil {
+			return nil, err
+		}
+		delete(topology.Taxa, hub)
+		delete(topology.Duplicates, hub)
+		if label, ok := topology.Labels[hub]; ok {
+			delete(topology.Labels, hub)
+			topology.Labels[node] = label
+		}
+
+		for _, taxon := range taxa {
+			topology.AddNode(taxon)
+			topology.SetTaxon(taxon)
+			if err := topology.AddEdge(taxon, hub, 1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := topology.ContractUnaryNodes(); err != nil {
+		return nil, err
+	}
+	return topology, nil
+}
+
+// Checks if two trees restricted to the given taxa have the same splits
+func sameTopology(tree1, tree2 *Graph, taxa []int) (bool, error) {
+	var keys [2]map[string]bool
+	for i, tree := range []*Graph{tree1, tree2} {
+		pruned, err := PruneToTaxa(tree, taxa, true)
+		if err != nil {
+			return false, err
+		}
+		splits, err := TreeSplits(pruned)
+		if err != nil {
+			return false, err
+		}
+		keys[i] = make(map[string]bool, len(splits))
+		for _, split := range splits {
+			keys[i][split.Key()] = true
+		}
+	}
+	return reflect.DeepEqual(keys[0], keys[1]), nil
+}
+
+// Returns the taxa of each part of the tree restricted to the given taxa around the point
+// the taxon is attached to, leaving out the taxon itself. The taxon must be a leaf.
+func partsAroundAttachment(tree *Graph, taxa []int, taxon int) ([][]int, error) {
+	pruned, err := PruneToTaxa(tree, taxa, true)
+	if err != nil {
+		return nil, err
+	}
+	rooted, err := NewRootedTree(pruned, taxon)
+	if err != nil {
+		return nil, err
+	}
+	if len(rooted.Children[0]) != 1 {
+		return nil, fmt.Errorf("taxon %d is not a leaf", taxon)
+	}
+
+	var parts = make([][]int, 0)
+	for _, child := range rooted.Children[rooted.Children[0][0]] {
+		parts = append(parts, subtreeTaxa(rooted, child, nil))
+	}
+	sortParts(parts)
+	return parts, nil
+}
+
+// Returns the sorted taxa in the subtree of the node at the index, keeping only the given
+// ones if filter is not nil
+func subtreeTaxa(tree *RootedTree, index int, filter map[int]bool) []int {
+	var taxa = make([]int, 0)
+	// The subtree is a contiguous range of the pre-order
+	for i := index; i < index+tree.SubtreeSize[index]; i++ {
+		if tree.IsTaxon(i) && (filter == nil || filter[tree.Nodes[i]]) {
+			taxa = append(taxa, tree.Nodes[i])
+		}
+	}
+	sort.Ints(taxa)
+	return taxa
+}
+
+// Orders parts of a tree by their smallest taxon
+func sortParts(parts [][]int) {
+	sort.Slice(parts, func(a, b int) bool {
+		return parts[a][0] < parts[b][0]
+	})
+}
+
+// Moves the largest subtree of the current tree that contains the taxon, has none of the
+// placed taxa and is a clade of the target tree to the point that splits the placed taxa
+// into the given parts. Both trees are hung from the first placed taxon, which is the
+// smallest taxon, and the clades of the target tree are indexed by their taxa.
+func moveNewTaxon(current *Graph, taxon int, placed []int, parts [][]int, target *RootedTree, targetClades map[string]int) (SPRMove, error) {
+	rooted, err := NewRootedTree(current, placed[0])
+	if err != nil {
+		return SPRMove{}, err
+	}
+	var isPlaced = make(map[int]bool, len(placed))
+	for _, p := range placed {
+		isPlaced[p] = true
+	}
+	var placedCount = make([]int, rooted.Size())
+	for _, index := range rooted.PostOrder() {
+		if isPlaced[rooted.Nodes[index]] {
+			placedCount[index]++
+		}
+		if parent := rooted.Parent[index]; parent != -1 {
+			placedCount[parent] += placedCount[index]
+		}
+	}
+
+	// The taxon alone is always a clade of the target tree
+	var top = rooted.Index[taxon]
+	for index := rooted.Parent[top]; index != 0 && placedCount[index] == 0; index = rooted.Parent[index] {
+		var side = Split{Side: subtreeTaxa(rooted, index, nil)}
+		if _, ok := targetClades[side.Key()]; ok {
+			top = index
+		}
+	}
+	var move = SPRMove{Subtree: subtreeTaxa(rooted, top, nil)}
+
+	// If the subtree hangs from an edge in the target tree, the taxa below that edge tell
+	// which edge to choose among those between the same placed taxa
+	var sibling []int
+	if parent := target.Parent[targetClades[(&Split{Side: move.Subtree}).Key()]]; len(target.Children[parent]) == 2 {
+		for _, child := range target.Children[parent] {
+			if taxa := subtreeTaxa(target, child, nil); !reflect.DeepEqual(taxa, move.Subtree) {
+				sibling = taxa
+			}
+		}
+	}
+
+	// Cut the subtree off, keeping its edges to put it back, and remove the node it was
+	// attached to if it is left with two neighbors
+	var subtreeEdges = make([]Edge, 0)
+	var subtreeNodes = make([]int, 0, rooted.SubtreeSize[top])
+	for i := top; i < top+rooted.SubtreeSize[top]; i++ {
+		subtreeNodes = append(subtreeNodes, rooted.Nodes[i])
+		if i != top {
+			subtreeEdges = append(subtreeEdges, Edge{rooted.Nodes[rooted.Parent[i]], rooted.Nodes[i], rooted.ParentWeight[i]})
+		}
+	}
+	var subtreeRoot, attachment = rooted.Nodes[top], rooted.Nodes[rooted.Parent[top]]
+	var subtreeWeight = rooted.ParentWeight[top]
+	for _, node := range subtreeNodes {
+		if err := current.RemoveNode(node); err != nil {
+			return SPRMove{}, err
+		}
+	}
+	if len(current.Edges[attachment]) == 2 && !current.IsTaxon(attachment) {
+		var neighbors = neighborsOf(current, attachment)
+		var weight = current.Edges[attachment][0].Weight + current.Edges[attachment][1].Weight
+		if err := current.RemoveNode(attachment); err != nil {
+			return SPRMove{}, err
+		}
+		if err := current.AddEdge(neighbors[0], neighbors[1], weight); err != nil {
+			return SPRMove{}, err
+		}
+	}
+
+	// Find the point splitting the placed taxa into the parts. For three or more parts, it is
+	// the node whose subtrees and the rest of the tree have the parts as their placed taxa.
+	// For two parts, it is any edge on the path between them, or in a subtree without placed
+	// taxa hanging from a node inside that path; the edge with the taxa below it closest to
+	// those next to the subtree in the target tree is chosen.
+	rooted, err = NewRootedTree(current, placed[0])
+	if err != nil {
+		return SPRMove{}, err
+	}
+	var partKeys = make(map[string]bool, len(parts))
+	for _, part := range parts {
+		partKeys[(&Split{Side: part}).Key()] = true
+	}
+	var below = make([]string, rooted.Size())
+	for index := range below {
+		below[index] = (&Split{Side: subtreeTaxa(rooted, index, isPlaced)}).Key()
+	}
+
+	var regraft, bestDifference = -1, 0
+	if len(parts) == 2 {
+		// Node from which the subtree without placed taxa containing each node hangs
+		var hangsFrom = make([]int, rooted.Size())
+		for index := 1; index < rooted.Size(); index++ {
+			var parent = rooted.Parent[index]
+			hangsFrom[index] = hangsFrom[parent]
+			if below[parent] != "" {
+				hangsFrom[index] = parent
+			}
+		}
+		var insidePath = func(index int) bool {
+			var placedChildren = 0
+			for _, child := range rooted.Children[index] {
+				if below[child] != "" {
+					placedChildren++
+				}
+			}
+			return partKeys[below[index]] && placedChildren == 1
+		}
+
+		for index := 1; index < rooted.Size(); index++ {
+			if !partKeys[below[index]] && (below[index] != "" || !insidePath(hangsFrom[index])) {
+				continue
+			}
+			var taxa = subtreeTaxa(rooted, index, nil)
+			var difference = len(taxa) + len(sibling) - 2*len(intersectSorted(taxa, sibling))
+			if regraft == -1 || difference < bestDifference {
+				regraft, bestDifference = index, difference
+			}
+		}
+	} else {
+		for index := 1; index < rooted.Size() && regraft == -1; index++ {
+			var matched = 0
+			for _, child := range rooted.Children[index] {
+				if key := below[child]; key != "" {
+					if !partKeys[key] {
+						matched = -1
+						break
+					}
+					matched++
+				}
+			}
+			if matched == len(parts)-1 {
+				regraft = index
+			}
+		}
+	}
+	if regraft == -1 {
+		return SPRMove{}, fmt.Errorf("no point of the tree separates the placed taxa as in the target tree")
+	}
+	var onEdge = len(parts) == 2
+
+	var rest = current.AllTaxa()
+	if onEdge {
+		var taxa = subtreeTaxa(rooted, regraft, nil)
+		move.Target = [][]int{taxa, complementOf(rest, taxa)}
+	} else {
+		for _, child := range rooted.Children[regraft] {
+			var taxa = subtreeTaxa(rooted, child, nil)
+			move.Target = append(move.Target, taxa)
+			rest = complementOf(rest, taxa)
+		}
+		move.Target = append(move.Target, rest)
+	}
+
+	// Put the subtree back and attach it, in the middle of the edge above the point found
+	// or to the node
+	for _, node := range subtreeNodes {
+		current.AddNode(node)
+		if containsTaxon(move.Subtree, node) {
+			current.SetTaxon(node)
+		}
+	}
+	for _, edge := range subtreeEdges {
+		if err := current.AddEdge(edge.Node1, edge.Node2, edge.Weight); err != nil {
+			return SPRMove{}, err
+		}
+	}
+
+	var node = rooted.Nodes[regraft]
+	if onEdge {
+		var parent = rooted.Nodes[rooted.Parent[regraft]]
+		var weight = rooted.ParentWeight[regraft]
+		if _, err := current.RemoveEdge(parent, node); err != nil {
+			return SPRMove{}, err
+		}
+		var middle = current.AddNewNode()
+		if err := current.AddEdge(parent, middle, weight/2); err != nil {
+			return SPRMove{}, err
+		}
+		if err := current.AddEdge(middle, node, weight/2); err != nil {
+			return SPRMove{}, err
+		}
+		node = middle
+	}
+	if err := current.AddEdge(node, subtreeRoot, subtreeWeight); err != nil {
+		return SPRMove{}, err
+	}
+
+	sortParts(move.Target)
+	return move, nil
+}
+
+// Returns true if the sorted taxa contain the node
+func containsTaxon(taxa []int, node int) bool {
+	var i = sort.SearchInts(taxa, node)
+	return i < len(taxa) && taxa[i] == node
+}
+
+// Returns the taxa in both sorted lists
+func intersectSorted(a, b []int) []int {
+	var result = make([]int, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	return result
+}
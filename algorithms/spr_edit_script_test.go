@@ -0,0 +1,96 @@
+package algorithms
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSPREditScript(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3, 4} {
+		tree1, err := GenerateRandomTree(25, seed, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+		other, err := GenerateRandomTree(25, seed+100, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+
+		// Give the leaves of the other tree the IDs of the leaves of the first one
+		leaves1, leaves2 := tree1.AllTaxa(), other.AllTaxa()
+		mapping := make(map[int]int)
+		for node := range other.Nodes {
+			mapping[node] = tree1.MaxNode + 1 + node
+		}
+		for i, leaf := range leaves2 {
+			mapping[leaf] = leaves1[i]
+		}
+		tree2, err := other.RenumberNodes(mapping)
+		if err != nil {
+			t.Fatalf("seed %d: RenumberNodes returned error: %v", seed, err)
+		}
+
+		// SPREditScript checks that each move places its taxon as in the second tree
+		moves, err := SPREditScript(tree1, tree2)
+		if err != nil {
+			t.Fatalf("seed %d: SPREditScript returned error: %v", seed, err)
+		}
+		if len(moves) == 0 || len(moves) > 25-3 {
+			t.Errorf("seed %d: expected between 1 and 22 moves between random trees, got %d", seed, len(moves))
+		}
+		for _, move := range moves {
+			if len(move.Target) < 2 || len(move.Subtree) == 0 {
+				t.Errorf("seed %d: invalid move %v", seed, move)
+			}
+		}
+
+		moves, err = SPREditScript(tree1, shuffledCopy(tree1, rand.New(rand.NewSource(seed)), false))
+		if err != nil {
+			t.Fatalf("seed %d: SPREditScript returned error: %v", seed, err)
+		}
+		if len(moves) != 0 {
+			t.Errorf("seed %d: expected no moves between copies, got %v", seed, moves)
+		}
+	}
+
+	// Taxa 0..5 along a path of internal nodes, and the same tree with leaf 2 moved next
+	// to 4, which takes a single move onto the edge to 4
+	tree1 := treeOfEdges([][3]int{{0, 6, 1}, {1, 6, 1}, {6, 7, 1}, {2, 7, 1}, {7, 8, 1}, {3, 8, 1}, {8, 9, 1}, {4, 9, 1}, {5, 9, 1}})
+	tree2 := treeOfEdges([][3]int{{0, 6, 1}, {1, 6, 1}, {6, 8, 1}, {3, 8, 1}, {8, 9, 1}, {5, 9, 1}, {9, 7, 1}, {2, 7, 1}, {4, 7, 1}})
+	moves, err := SPREditScript(tree1, tree2)
+	if err != nil {
+		t.Fatalf("SPREditScript returned error: %v", err)
+	}
+	expected := []SPRMove{{Subtree: []int{2}, Target: [][]int{{0, 1, 3, 5}, {4}}}}
+	if !reflect.DeepEqual(moves, expected) {
+		t.Errorf("expected moves %v, got %v", expected, moves)
+	}
+
+	if _, err := SPREditScript(tree1, treeOfEdges([][3]int{{0, 3, 1}, {1, 3, 1}, {2, 3, 1}})); err == nil {
+		t.Errorf("expected an error for trees with different taxa")
+	}
+
+	// Labelled taxa are matched by label: ((A,B),(C,D),E) against the same tree with its
+	// taxa listed in another order, and against (((A,B),C),D,E), whose extra internal node
+	// shifts the IDs, where E is moved onto the edge between {A,B,C} and {D}
+	labelled := labelledTreeOfEdges([][3]int{{0, 5, 1}, {1, 5, 1}, {2, 6, 1}, {3, 6, 1}, {5, 7, 1}, {6, 7, 1}, {4, 7, 2}}, "A", "B", "C", "D", "E")
+	reordered := labelledTreeOfEdges([][3]int{{1, 5, 1}, {2, 5, 1}, {3, 6, 1}, {4, 6, 1}, {5, 7, 1}, {6, 7, 1}, {0, 7, 2}}, "E", "D", "C", "B", "A")
+	caterpillar := labelledTreeOfEdges([][3]int{{3, 5, 1}, {4, 5, 1}, {5, 6, 1}, {2, 6, 1}, {6, 7, 1}, {0, 7, 1}, {1, 7, 2}}, "D", "E", "C", "A", "B")
+	for _, tt := range []struct {
+		name     string
+		tree     *Graph
+		expected []SPRMove
+	}{
+		{name: "reordered", tree: reordered, expected: []SPRMove{}},
+		{name: "caterpillar", tree: caterpillar, expected: []SPRMove{{Subtree: []int{4}, Target: [][]int{{0, 1, 2}, {3}}}}},
+	} {
+		moves, err := SPREditScript(labelled, tt.tree)
+		if err != nil {
+			t.Fatalf("%s: SPREditScript returned error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(moves, tt.expected) {
+			t.Errorf("%s: expected moves %v, got %v", tt.name, tt.expected, moves)
+		}
+	}
+}
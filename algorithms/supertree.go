@@ -0,0 +1,454 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// A supertree of trees on overlapping sets of taxa, with the splits of the input trees it
+// does not display
+type Supertree struct {
+	Tree *Graph
+	// Taxon all trees were rooted at
+	Outgroup int
+	// Whether BUILD needed min cuts, i.e. the input trees are not compatible
+	UsedMinCut bool
+	Conflicts  []SupertreeConflict
+}
+
+// A split of an input tree that the supertree does not display
+type SupertreeConflict struct {
+	// Index of the input tree
+	Tree int
+	// Taxa on the side of the split without the outgroup, as taxa of the supertree
+	Side []int
+}
+
+// An input tree of the supertree, rooted at the outgroup: its taxa other than the outgroup
+// and the clusters below its nodes with at least 2 of them, as taxa of the supertree
+type supertreeInput struct {
+	taxa     []int
+	clusters [][]int
+}
+
+// Builds a supertree of trees whose taxa overlap. Taxa are matched across the trees by name
+// (label, or ID for unlabelled taxa); if any taxon is labelled, the supertree numbers the
+// taxa 0..n-1 in order of name and labels them, otherwise taxa keep their IDs. All trees are
+// rooted at the outgroup, which must be in all of them ("" picks the first such taxon), and
+// combined with BUILD (Aho et al.): the taxa below a node are split into the groups that
+// no input tree puts into a common cluster. When the input trees are incompatible this leaves
+// a single group, which is split by a minimum cut of the graph joining two taxa as many times
+// as there are trees with a cluster containing both, after merging taxa that are together
+// in every tree that has them (Semple and Steel's MinCutSupertree). The supertree has only a
+// shape: all its edges have length 1.
+func BuildSupertree(trees []*Graph, outgroup string) (*Supertree, error) {
+	if len(trees) == 0 {
+		return nil, fmt.Errorf("at least one tree is required")
+	}
+
+	var names = make([]string, 0)
+	var seen = make(map[string]bool)
+	var labelled = false
+	for _, tree := range trees {
+		for _, taxon := range tree.AllTaxa() {
+			if _, ok := tree.Labels[taxon]; ok {
+				labelled = true
+			}
+			if name := tree.NodeName(taxon); !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var supertree = &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}}
+	var ids = make(map[string]int, len(names))
+	for i, name := range names {
+		if labelled {
+			if supertree.Labels == nil {
+				supertree.Labels = make(map[int]string)
+			}
+			ids[name] = i
+			supertree.Labels[i] = name
+		} else {
+			ids[name], _ = strconv.Atoi(name)
+		}
+		supertree.AddNode(ids[name])
+	}
+
+	var outgroupID = -1
+	if outgroup != "" {
+		id, ok := ids[outgroup]
+		if !ok {
+			return nil, fmt.Errorf("outgroup %s is not a taxon of any tree", outgroup)
+		}
+		outgroupID = id
+	}
+	var common = make(map[int]int)
+	for _, tree := range trees {
+		for _, taxon := range tree.AllTaxa() {
+			common[ids[tree.NodeName(taxon)]]++
+		}
+	}
+	if outgroupID == -1 {
+		for _, name := range names {
+			if common[ids[name]] == len(trees) && (outgroupID == -1 || ids[name] < outgroupID) {
+				outgroupID = ids[name]
+			}
+		}
+		if outgroupID == -1 {
+			return nil, fmt.Errorf("no taxon is in all trees, so the trees cannot be rooted at a common outgroup")
+		}
+	} else if common[outgroupID] != len(trees) {
+		return nil, fmt.Errorf("outgroup %s is not in all trees", outgroup)
+	}
+
+	var inputs = make([]supertreeInput, len(trees))
+	for i, tree := range trees {
+		input, err := newSupertreeInput(tree, func(node int) int { return ids[tree.NodeName(node)] }, outgroupID)
+		if err != nil {
+			return nil, fmt.Errorf("tree %d: %v", i+1, err)
+		}
+		inputs[i] = input
+	}
+
+	var taxa = make([]int, 0, len(names)-1)
+	for _, name := range names {
+		if ids[name] != outgroupID {
+			taxa = append(taxa, ids[name])
+		}
+	}
+	sort.Ints(taxa)
+
+	var result = &Supertree{Tree: supertree, Outgroup: outgroupID}
+	var hub = supertree.AddNewNode()
+	if err := supertree.AddEdge(hub, outgroupID, 1); err != nil {
+		return nil, err
+	}
+
+	// Groups of taxa waiting to be split, with the node they hang from
+	type group struct {
+		taxa   []int
+		parent int
+	}
+	var stack = []group{{taxa: taxa, parent: hub}}
+	for len(stack) > 0 {
+		var current = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		var parts = buildComponents(current.taxa, inputs)
+		if len(parts) == 1 && len(current.taxa) > 1 {
+			result.UsedMinCut = true
+			parts = minCutParts(current.taxa, inputs)
+		}
+
+		for _, part := range parts {
+			if len(part) == 1 {
+				if err := supertree.AddEdge(current.parent, part[0], 1); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			var node = supertree.AddNewNode()
+			if err := supertree.AddEdge(current.parent, node, 1); err != nil {
+				return nil, err
+			}
+			stack = append(stack, group{taxa: part, parent: node})
+		}
+	}
+
+	// With only two taxa the hub joins just them
+	if err := supertree.ContractUnaryNodes(); err != nil {
+		return nil, err
+	}
+
+	displayed, err := newSupertreeInput(supertree, func(node int) int { return node }, outgroupID)
+	if err != nil {
+		return nil, err
+	}
+	for i, input := range inputs {
+		result.Conflicts = append(result.Conflicts, undisplayedClusters(i, input, displayed)...)
+	}
+
+	return result, nil
+}
+
+// Roots the tree at the outgroup and collects its clusters, with taxa numbered by taxonID
+func newSupertreeInput(tree *Graph, taxonID func(int) int, outgroup int) (supertreeInput, error) {
+	var location = -1
+	for _, taxon := range tree.AllTaxa() {
+		if taxonID(taxon) == outgroup {
+			location = tree.TaxonLocation(taxon)
+		}
+	}
+	rooted, err := NewRootedTree(tree, location)
+	if err != nil {
+		return supertreeInput{}, err
+	}
+
+	var input = supertreeInput{taxa: make([]int, 0)}
+	var below = make([][]int, rooted.Size())
+	for _, index := range rooted.PostOrder() {
+		var taxa = make([]int, 0)
+		if rooted.IsTaxon(index) {
+			for _, taxon := range append([]int{rooted.Nodes[index]}, tree.Duplicates[rooted.Nodes[index]]...) {
+				if id := taxonID(taxon); id != outgroup {
+					taxa = append(taxa, id)
+				}
+			}
+		}
+		for _, child := range rooted.Children[index] {
+			taxa = append(taxa, below[child]...)
+			below[child] = nil
+		}
+		sort.Ints(taxa)
+		below[index] = taxa
+		if index > 0 && len(taxa) > 1 {
+			input.clusters = append(input.clusters, taxa)
+		}
+	}
+	input.taxa = below[0]
+
+	return input, nil
+}
+
+// Groups the taxa so that taxa in a common cluster of an input tree, restricted to the taxa,
+// are in the same group. Clusters with all the taxa of a tree present say nothing about how
+// to split them, so they are skipped.
+func buildComponents(taxa []int, inputs []supertreeInput) [][]int {
+	var position = make(map[int]int, len(taxa))
+	for i, taxon := range taxa {
+		position[taxon] = i
+	}
+	var parent = make([]int, len(taxa))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	for _, input := range inputs {
+		var present = len(intersectSorted(input.taxa, taxa))
+		if present < 3 {
+			continue
+		}
+		for _, cluster := range input.clusters {
+			var positions = make([]int, 0, len(cluster))
+			for _, taxon := range cluster {
+				if i, ok := position[taxon]; ok {
+					positions = append(positions, i)
+				}
+			}
+			if len(positions) < 2 || len(positions) == present {
+				continue
+			}
+			for _, i := range positions[1:] {
+				parent[find(i)] = find(positions[0])
+			}
+		}
+	}
+
+	var parts = make(map[int][]int)
+	for i, taxon := range taxa {
+		parts[find(i)] = append(parts[find(i)], taxon)
+	}
+	var result = make([][]int, 0, len(parts))
+	for _, part := range parts {
+		result = append(result, part)
+	}
+	sortParts(result)
+	return result
+}
+
+// Splits the taxa in two by a minimum cut of the graph whose edge between two taxa is
+// weighted by the number of input trees with a cluster containing both, restricted to the
+// taxa. Taxa joined by an edge of maximal weight (in every tree that has both) are merged
+// first, so that the cut keeps the groups all trees agree on.
+func minCutParts(taxa []int, inputs []supertreeInput) [][]int {
+	var n = len(taxa)
+	var weights = make([][]float64, n)
+	var together = make([][]int, n)
+	for i := range weights {
+		weights[i] = make([]float64, n)
+		together[i] = make([]int, n)
+	}
+
+	// The groups of each tree are its clusters joined by BUILD over that tree alone
+	var position = make(map[int]int, n)
+	for i, taxon := range taxa {
+		position[taxon] = i
+	}
+	for _, input := range inputs {
+		var present = intersectSorted(input.taxa, taxa)
+		for _, a := range present {
+			for _, b := range present {
+				together[position[a]][position[b]]++
+			}
+		}
+		for _, part := range buildComponents(present, []supertreeInput{input}) {
+			for _, a := range part {
+				for _, b := range part {
+					if a != b {
+						weights[position[a]][position[b]]++
+					}
+				}
+			}
+		}
+	}
+
+	// Merge taxa that every tree with both of them keeps together
+	var merged = make([]int, n)
+	for i := range merged {
+		merged[i] = i
+	}
+	var find = func(i int) int {
+		for merged[i] != i {
+			i = merged[i]
+		}
+		return i
+	}
+	var groups = n
+	for a := 0; a < n; a++ {
+		for b := a + 1; b < n; b++ {
+			if weights[a][b] > 0 && int(weights[a][b]) == together[a][b] && find(a) != find(b) {
+				merged[find(b)] = find(a)
+				groups--
+			}
+		}
+	}
+	if groups == 1 {
+		for i := range merged {
+			merged[i] = i
+		}
+	}
+
+	var vertices = make(map[int]int)
+	var members = make([][]int, 0)
+	for i, taxon := range taxa {
+		var root = find(i)
+		if _, ok := vertices[root]; !ok {
+			vertices[root] = len(members)
+			members = append(members, nil)
+		}
+		members[vertices[root]] = append(members[vertices[root]], taxon)
+	}
+	var contracted = make([][]float64, len(members))
+	for i := range contracted {
+		contracted[i] = make([]float64, len(members))
+	}
+	for a := 0; a < n; a++ {
+		for b := 0; b < n; b++ {
+			if va, vb := vertices[find(a)], vertices[find(b)]; va != vb {
+				contracted[va][vb] += weights[a][b]
+			}
+		}
+	}
+
+	var side = stoerWagner(contracted)
+	var parts = [][]int{{}, {}}
+	for vertex, group := range members {
+		var part = 1
+		if side[vertex] {
+			part = 0
+		}
+		parts[part] = append(parts[part], group...)
+	}
+	for _, part := range parts {
+		sort.Ints(part)
+	}
+	sortParts(parts)
+	return parts
+}
+
+// Finds a minimum cut of a weighted graph given by its symmetric adjacency matrix with the
+// Stoer-Wagner algorithm, returning which vertices are on one side of it
+func stoerWagner(weights [][]float64) []bool {
+	var n = len(weights)
+	var w = make([][]float64, n)
+	var members = make([][]int, n)
+	for i := range w {
+		w[i] = append([]float64{}, weights[i]...)
+		members[i] = []int{i}
+	}
+
+	var active = make([]int, n)
+	for i := range active {
+		active[i] = i
+	}
+	var best = math.Inf(1)
+	var bestSide []int
+	for len(active) > 1 {
+		// Add vertices in order of how strongly they are connected to those already added;
+		// the last one is cut from the rest by the cut of the phase
+		var connection = make(map[int]float64)
+		var added = make(map[int]bool)
+		var previous, last = -1, -1
+		for range active {
+			var next = -1
+			for _, vertex := range active {
+				if !added[vertex] && (next == -1 || connection[vertex] > connection[next]) {
+					next = vertex
+				}
+			}
+			added[next] = true
+			previous, last = last, next
+			for _, vertex := range active {
+				connection[vertex] += w[next][vertex]
+			}
+		}
+
+		if connection[last] < best {
+			best = connection[last]
+			bestSide = append([]int{}, members[last]...)
+		}
+
+		// Merge the last two vertices
+		members[previous] = append(members[previous], members[last]...)
+		for _, vertex := range active {
+			w[previous][vertex] += w[last][vertex]
+			w[vertex][previous] = w[previous][vertex]
+		}
+		w[previous][previous] = 0
+		var remaining = make([]int, 0, len(active)-1)
+		for _, vertex := range active {
+			if vertex != last {
+				remaining = append(remaining, vertex)
+			}
+		}
+		active = remaining
+	}
+
+	var side = make([]bool, n)
+	for _, vertex := range bestSide {
+		side[vertex] = true
+	}
+	return side
+}
+
+// Returns the clusters of an input tree that no cluster of the supertree restricted to the
+// tree's taxa matches
+func undisplayedClusters(tree int, input, supertree supertreeInput) []SupertreeConflict {
+	var restricted = make(map[string]bool)
+	for _, cluster := range supertree.clusters {
+		restricted[fmt.Sprint(intersectSorted(cluster, input.taxa))] = true
+	}
+
+	var conflicts = make([]SupertreeConflict, 0)
+	var reported = make(map[string]bool)
+	for _, cluster := range input.clusters {
+		var key = fmt.Sprint(cluster)
+		if len(cluster) < len(input.taxa) && !restricted[key] && !reported[key] {
+			reported[key] = true
+			conflicts = append(conflicts, SupertreeConflict{Tree: tree, Side: cluster})
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,91 @@
+package algorithms
+
+import (
+	"testing"
+)
+
+func TestBuildSupertree(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3} {
+		tree, err := GenerateRandomTree(30, seed, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+
+		// Two overlapping halves of the taxa, both with the smallest one
+		var taxa = tree.AllTaxa()
+		var first, second = []int{taxa[0]}, []int{taxa[0]}
+		for i, taxon := range taxa[1:] {
+			if i%3 != 0 {
+				first = append(first, taxon)
+			}
+			if i%3 != 1 {
+				second = append(second, taxon)
+			}
+		}
+		var inputs []*Graph
+		for _, keep := range [][]int{first, second} {
+			pruned, err := PruneToTaxa(tree, keep, true)
+			if err != nil {
+				t.Fatalf("seed %d: PruneToTaxa returned error: %v", seed, err)
+			}
+			inputs = append(inputs, pruned)
+		}
+
+		supertree, err := BuildSupertree(inputs, "")
+		if err != nil {
+			t.Fatalf("seed %d: BuildSupertree returned error: %v", seed, err)
+		}
+		if supertree.UsedMinCut || len(supertree.Conflicts) > 0 {
+			t.Errorf("seed %d: subtrees of one tree reported as incompatible: %v", seed, supertree.Conflicts)
+		}
+
+		// Every split of each input is a split of the supertree restricted to its taxa
+		for i, input := range inputs {
+			restricted, err := PruneToTaxa(supertree.Tree, input.AllTaxa(), true)
+			if err != nil {
+				t.Fatalf("seed %d: PruneToTaxa returned error: %v", seed, err)
+			}
+			displayed, err := TreeSplits(restricted)
+			if err != nil {
+				t.Fatalf("seed %d: TreeSplits returned error: %v", seed, err)
+			}
+			var keys = make(map[string]bool)
+			for _, split := range displayed {
+				keys[split.Key()] = true
+			}
+			splits, err := TreeSplits(input)
+			if err != nil {
+				t.Fatalf("seed %d: TreeSplits returned error: %v", seed, err)
+			}
+			for _, split := range splits {
+				if !keys[split.Key()] {
+					t.Errorf("seed %d: split %v of input %d is not displayed by the supertree", seed, split.Side, i+1)
+				}
+			}
+		}
+	}
+
+	// ((A,B),C,D) and ((A,C),B,D) disagree on where B and C go
+	labelled := func(edges [][3]int) *Graph {
+		graph := treeOfEdges(edges)
+		graph.Labels = map[int]string{0: "A", 1: "B", 2: "C", 3: "D"}
+		return graph
+	}
+	tree1 := labelled([][3]int{{0, 4, 1}, {1, 4, 1}, {4, 5, 1}, {2, 5, 1}, {3, 5, 1}})
+	tree2 := labelled([][3]int{{0, 4, 1}, {2, 4, 1}, {4, 5, 1}, {1, 5, 1}, {3, 5, 1}})
+	supertree, err := BuildSupertree([]*Graph{tree1, tree2}, "")
+	if err != nil {
+		t.Fatalf("BuildSupertree returned error: %v", err)
+	}
+	if !supertree.UsedMinCut || len(supertree.Conflicts) != 1 {
+		t.Errorf("expected one conflict resolved by a min cut, got %v", supertree.Conflicts)
+	}
+	if taxa := supertree.Tree.TaxonNodes(); len(taxa) != 4 || supertree.Tree.NodeName(supertree.Outgroup) != "A" {
+		t.Errorf("expected taxa A to D rooted at A, got %v rooted at %d", taxa, supertree.Outgroup)
+	}
+
+	tree3 := labelled([][3]int{{1, 4, 1}, {2, 4, 1}, {3, 4, 1}})
+	if _, err := BuildSupertree([]*Graph{tree1, tree3}, "A"); err == nil {
+		t.Errorf("expected an error for an outgroup missing from a tree")
+	}
+}
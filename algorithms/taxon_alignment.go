@@ -0,0 +1,70 @@
+package algorithms
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Renumbers the taxa of the trees so that taxa with the same name (label, or ID for
+// unlabelled taxa) have the same ID in all of them, since the IDs of labelled Newick taxa
+// follow the order of the input. If any taxon is labelled, the taxa are numbered 0..n-1 in
+// order of name, as in BuildSupertree, and the other nodes of each tree get IDs after them;
+// otherwise the trees are returned as they are.
+func AlignTaxaByName(trees []*Graph) ([]*Graph, error) {
+	var names = make([]string, 0)
+	var seen = make(map[string]bool)
+	var labelled = false
+	for i, tree := range trees {
+		var inTree = make(map[string]bool)
+		for _, taxon := range tree.AllTaxa() {
+			if _, ok := tree.Labels[taxon]; ok {
+				labelled = true
+			}
+			var name = tree.NodeName(taxon)
+			if inTree[name] {
+				return nil, fmt.Errorf("tree %d has several taxa named %s", i+1, name)
+			}
+			inTree[name] = true
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if !labelled {
+		return trees, nil
+	}
+	sort.Strings(names)
+
+	var ids = make(map[string]int, len(names))
+	for i, name := range names {
+		ids[name] = i
+	}
+
+	var aligned = make([]*Graph, len(trees))
+	for i, tree := range trees {
+		var mapping = make(map[int]int)
+		for _, taxon := range tree.AllTaxa() {
+			mapping[taxon] = ids[tree.NodeName(taxon)]
+		}
+
+		var nodes = make([]int, 0, len(tree.Nodes))
+		for node := range tree.Nodes {
+			if _, ok := mapping[node]; !ok {
+				nodes = append(nodes, node)
+			}
+		}
+		sort.Ints(nodes)
+		for j, node := range nodes {
+			mapping[node] = len(names) + j
+		}
+
+		renumbered, err := tree.RenumberNodes(mapping)
+		if err != nil {
+			return nil, fmt.Errorf("tree %d: %v", i+1, err)
+		}
+		aligned[i] = renumbered
+	}
+
+	return aligned, nil
+}
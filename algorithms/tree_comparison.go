@@ -5,7 +5,7 @@ import (
 )
 
 // Checks if two trees have the same topology (structure)
-// ignoring node numbering/names
+// ignoring node numbering/names. Internal taxa and duplicate taxa must be at matching positions.
 func CompareTreeTopology(tree1, tree2 *Graph) bool {
 	if len(tree1.Nodes) != len(tree2.Nodes) {
 		return false
@@ -19,10 +19,74 @@ func CompareTreeTopology(tree1, tree2 *Graph) bool {
 		return false
 	}
 
-	canonical1 := generateCanonicalRepresentation(tree1)
-	canonical2 := generateCanonicalRepresentation(tree2)
+	_, isomorphic := FindIsomorphism(tree1, tree2)
+	return isomorphic
+}
+
+// Finds an isomorphism between two trees: a mapping from the nodes of tree1 to the nodes of
+// tree2 that preserves edges, internal taxa and the numbers of duplicates of each taxon (node
+// IDs and edge weights are ignored). Both trees are hung from their centers and their subtrees
+// classified together with integer AHU classes, which takes O(n log n) time. Returns false if
+// the trees are not isomorphic.
+func FindIsomorphism(tree1, tree2 *Graph) (map[int]int, bool) {
+	if len(tree1.Nodes) != len(tree2.Nodes) {
+		return nil, false
+	}
+	if len(tree1.Nodes) == 0 {
+		return map[int]int{}, true
+	}
+
+	// An isomorphism maps centers to centers, so it is enough to try the centers of tree2
+	centers1, centers2 := findTreeCenters(tree1), findTreeCenters(tree2)
+	if len(centers1) != len(centers2) {
+		return nil, false
+	}
+	rooted1, err := NewRootedTree(tree1, centers1[0])
+	if err != nil {
+		return nil, false
+	}
+
+	for _, center := range centers2 {
+		rooted2, err := NewRootedTree(tree2, center)
+		if err != nil {
+			return nil, false
+		}
+
+		classes := jointSubtreeClasses([]*RootedTree{rooted1, rooted2}, false)
+		if classes[0][0] == classes[1][0] {
+			return matchSubtrees(rooted1, rooted2, classes[0], classes[1]), true
+		}
+	}
+
+	return nil, false
+}
+
+// Maps the nodes of two rooted trees whose roots have the same class onto each other, pairing
+// the children of matched nodes by class
+func matchSubtrees(tree1, tree2 *RootedTree, classes1, classes2 []int) map[int]int {
+	var mapping = make(map[int]int, tree1.Size())
+	var byClass = func(children []int, classes []int) []int {
+		var sorted = append([]int{}, children...)
+		sort.Slice(sorted, func(a, b int) bool {
+			return classes[sorted[a]] < classes[sorted[b]]
+		})
+		return sorted
+	}
+
+	var stack = [][2]int{{0, 0}}
+	for len(stack) > 0 {
+		pair := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		mapping[tree1.Nodes[pair[0]]] = tree2.Nodes[pair[1]]
 
-	return canonical1 == canonical2
+		children1 := byClass(tree1.Children[pair[0]], classes1)
+		children2 := byClass(tree2.Children[pair[1]], classes2)
+		for i := range children1 {
+			stack = append(stack, [2]int{children1[i], children2[i]})
+		}
+	}
+
+	return mapping
 }
 
 // Checks if two trees have the same degree sequence
@@ -54,120 +118,48 @@ func compareDegreeSequences(tree1, tree2 *Graph) bool {
 	return true
 }
 
-// Creates a canonical string representation of the tree
-func generateCanonicalRepresentation(tree *Graph) string {
-	if len(tree.Nodes) == 0 {
-		return ""
-	}
-
-	// Find tree centers (there can be 1 or 2 centers in a tree)
-	centers := findTreeCenters(tree)
-
-	// Generate canonical representation from each center and take the lexicographically smallest
-	var minRepresentation string
-
-	for _, center := range centers {
-		representation := generateRepresentationFromRoot(tree, center)
-		if minRepresentation == "" || representation < minRepresentation {
-			minRepresentation = representation
-		}
-	}
-
-	return minRepresentation
-}
-
-// Finds the center(s) of the tree using the standard algorithm
+// Finds the center(s) of the tree: the middle node(s) of a longest path, counted in edges.
+// A longest path starts at the deepest node of the tree hung from any node.
 func findTreeCenters(tree *Graph) []int {
-	if len(tree.Nodes) == 1 {
-		for node := range tree.Nodes {
-			return []int{node}
+	var first = -1
+	for node := range tree.Nodes {
+		if first == -1 || node < first {
+			first = node
 		}
 	}
 
-	// Start with all nodes
-	remaining := make(map[int]bool)
-	degrees := make(map[int]int)
-
-	for node := range tree.Nodes {
-		remaining[node] = true
-		degrees[node] = len(tree.Edges[node])
-	}
-
-	// Repeatedly remove leaves until we have 1 or 2 nodes left
-	for len(remaining) > 2 {
-		// Find all current leaves (degree 1)
-		leaves := make([]int, 0)
-		for node := range remaining {
-			if degrees[node] == 1 {
-				leaves = append(leaves, node)
-			}
-		}
+	rooted, err := NewRootedTree(tree, first)
+	if err != nil {
+		return []int{first}
+	}
+	rooted, err = NewRootedTree(tree, rooted.Nodes[deepestIndex(rooted)])
+	if err != nil {
+		return []int{first}
+	}
 
-		// Remove leaves and update degrees of their neighbors
-		for _, leaf := range leaves {
-			delete(remaining, leaf)
-
-			// Find the neighbor of this leaf and decrease its degree
-			for _, edge := range tree.Edges[leaf] {
-				var neighbor int
-				if edge.Node1 == leaf {
-					neighbor = edge.Node2
-				} else {
-					neighbor = edge.Node1
-				}
-
-				if remaining[neighbor] {
-					degrees[neighbor]--
-				}
-			}
-		}
+	// Walk up from the other end of the longest path to its middle
+	var end = deepestIndex(rooted)
+	var length = rooted.Depth[end]
+	var index = end
+	for rooted.Depth[index] > (length+1)/2 {
+		index = rooted.Parent[index]
 	}
 
-	// Return remaining nodes as centers
-	centers := make([]int, 0)
-	for node := range remaining {
-		centers = append(centers, node)
+	centers := []int{rooted.Nodes[index]}
+	if length%2 == 1 {
+		centers = append(centers, rooted.Nodes[rooted.Parent[index]])
 	}
 	sort.Ints(centers) // For deterministic order
 	return centers
 }
 
-// Creates a canonical string representation rooted at the given node
-func generateRepresentationFromRoot(tree *Graph, root int) string {
-	visited := make(map[int]bool)
-	return dfsCanonical(tree, root, visited)
-}
-
-// Performs DFS to generate canonical representation
-func dfsCanonical(tree *Graph, node int, visited map[int]bool) string {
-	visited[node] = true
-
-	// Get all unvisited neighbors
-	childRepresentations := make([]string, 0)
-
-	for _, edge := range tree.Edges[node] {
-		var neighbor int
-		if edge.Node1 == node {
-			neighbor = edge.Node2
-		} else {
-			neighbor = edge.Node1
-		}
-
-		if !visited[neighbor] {
-			childRepresentation := dfsCanonical(tree, neighbor, visited)
-			childRepresentations = append(childRepresentations, childRepresentation)
+// Returns the index of the first node with the largest depth
+func deepestIndex(tree *RootedTree) int {
+	var deepest = 0
+	for index, depth := range tree.Depth {
+		if depth > tree.Depth[deepest] {
+			deepest = index
 		}
 	}
-
-	// Sort child representations for canonical order
-	sort.Strings(childRepresentations)
-
-	// Create representation: (child1)(child2)...(childN)
-	result := "("
-	for _, childRepr := range childRepresentations {
-		result += childRepr
-	}
-	result += ")"
-
-	return result
+	return deepest
 }
@@ -0,0 +1,83 @@
+package algorithms
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Checks that the mapping is a bijection between the nodes of the trees that preserves edges
+func checkIsomorphism(t *testing.T, name string, tree1, tree2 *Graph, mapping map[int]int) {
+	t.Helper()
+	if len(mapping) != len(tree1.Nodes) {
+		t.Errorf("%s: mapping has %d nodes, expected %d", name, len(mapping), len(tree1.Nodes))
+	}
+	images := make(map[int]bool)
+	for node, image := range mapping {
+		if _, ok := tree2.Nodes[image]; !ok || images[image] {
+			t.Errorf("%s: node %d is mapped to %d, which is missing or already used", name, node, image)
+		}
+		images[image] = true
+	}
+	for _, edge := range tree1.AllEdges {
+		if IndexOfEdge(tree2.Edges[mapping[edge.Node1]], mapping[edge.Node1], mapping[edge.Node2]) == -1 {
+			t.Errorf("%s: edge %d-%d is not mapped to an edge", name, edge.Node1, edge.Node2)
+		}
+	}
+}
+
+func TestFindIsomorphism(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3, 4, 5} {
+		tree, err := GenerateRandomTree(40, seed, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+		shuffled := shuffledCopy(tree, rand.New(rand.NewSource(seed)), true)
+
+		mapping, ok := FindIsomorphism(tree, shuffled)
+		if !ok {
+			t.Fatalf("seed %d: shuffled copy is not isomorphic", seed)
+		}
+		checkIsomorphism(t, "shuffled copy", tree, shuffled, mapping)
+	}
+
+	// Paths 0-1-2-3-4 with leaves 5 and 6 attached at 1 and 2 or at 1 and 3 have the same
+	// degrees, but different shapes
+	caterpillar := func(attachments ...int) *Graph {
+		graph := &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}}
+		for node := 0; node <= 6; node++ {
+			graph.AddNode(node)
+		}
+		for node := 1; node <= 4; node++ {
+			graph.AddEdge(node-1, node, 1)
+		}
+		graph.AddEdge(attachments[0], 5, 1)
+		graph.AddEdge(attachments[1], 6, 1)
+		return graph
+	}
+	if _, ok := FindIsomorphism(caterpillar(1, 2), caterpillar(1, 3)); ok {
+		t.Errorf("caterpillars with leaves at different positions are reported as isomorphic")
+	}
+	mapping, ok := FindIsomorphism(caterpillar(1, 2), caterpillar(3, 2))
+	if !ok {
+		t.Fatalf("mirrored caterpillars are not isomorphic")
+	}
+	checkIsomorphism(t, "mirrored caterpillar", caterpillar(1, 2), caterpillar(3, 2), mapping)
+
+	// Deep chains are classified level by level, without quadratic work
+	chain1 := &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}}
+	chain2 := &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}}
+	const length = 20000
+	for node := 0; node < length; node++ {
+		chain1.AddNode(node)
+		chain2.AddNode(node)
+	}
+	for node := 1; node < length; node++ {
+		chain1.AddEdge(node-1, node, 1)
+		chain2.AddEdge(length-node, length-node-1, 1)
+	}
+	mapping, ok = FindIsomorphism(chain1, chain2)
+	if !ok {
+		t.Fatalf("chains of the same length are not isomorphic")
+	}
+	checkIsomorphism(t, "chain", chain1, chain2, mapping)
+}
@@ -0,0 +1,427 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Differences between two trees, in terms of the splits of their edges over the taxa they
+// have in common
+type TreeDiff struct {
+	// Taxa of both trees, over which the splits are compared
+	Taxa []int
+	// Taxa that are in only one of the trees
+	OnlyInFirst  []int
+	OnlyInSecond []int
+	// Largest clades (taxa on one side of an edge) whose subtrees are the same in both trees,
+	// including edge lengths, leaving out single taxa
+	CommonSubtrees [][]int
+	// Splits of one tree that the other tree does not have, weighted by edge length
+	SplitsOnlyInFirst  []Split
+	SplitsOnlyInSecond []Split
+	// Splits of both trees whose edges have different lengths
+	ChangedSplits []ChangedSplit
+	// Taxa placed differently in the two trees: removing them from both trees reconciles
+	// the other splits
+	MovedTaxa []MovedTaxon
+}
+
+// A split of both trees with the length of its edge in each of them
+type ChangedSplit struct {
+	Side         []int
+	FirstWeight  float64
+	SecondWeight float64
+}
+
+// A taxon placed differently in two trees, with where it is attached in each: the ends of
+// the edge it hangs from (the edge that would remain if it was removed), or the nodes it is
+// joined to if there is no such edge
+type MovedTaxon struct {
+	Taxon            int
+	FirstAttachment  []int
+	SecondAttachment []int
+}
+
+// Returns true if the trees have the same taxa and the same splits with the same weights
+func (d *TreeDiff) Identical() bool {
+	return len(d.OnlyInFirst) == 0 && len(d.OnlyInSecond) == 0 &&
+		len(d.SplitsOnlyInFirst) == 0 && len(d.SplitsOnlyInSecond) == 0 && len(d.ChangedSplits) == 0
+}
+
+// Returns the smaller part of a split over the taxa of both trees, given one of its parts
+func (d *TreeDiff) SmallerPart(side []int) []int {
+	if 2*len(side) > len(d.Taxa) {
+		return complementOf(d.Taxa, side)
+	}
+	return side
+}
+
+// Compares two trees by the splits of their edges over the taxa they have in common. Nodes
+// with two neighbors that are not taxa are suppressed first, so a chain of unit edges is the
+// same as a single edge of the same length. Edge lengths differing by at most epsilon are
+// considered equal.
+func DiffTrees(tree1, tree2 *Graph, epsilon float64) (*TreeDiff, error) {
+	var diff = &TreeDiff{}
+	var inFirst = make(map[int]bool)
+	for _, taxon := range tree1.AllTaxa() {
+		inFirst[taxon] = true
+	}
+	for _, taxon := range tree2.AllTaxa() {
+		if inFirst[taxon] {
+			diff.Taxa = append(diff.Taxa, taxon)
+			delete(inFirst, taxon)
+		} else {
+			diff.OnlyInSecond = append(diff.OnlyInSecond, taxon)
+		}
+	}
+	for taxon := range inFirst {
+		diff.OnlyInFirst = append(diff.OnlyInFirst, taxon)
+	}
+	sort.Ints(diff.OnlyInFirst)
+	if len(diff.Taxa) == 0 {
+		return nil, fmt.Errorf("trees have no taxa in common")
+	}
+
+	pruned1, err := PruneToTaxa(tree1, diff.Taxa, true)
+	if err != nil {
+		return nil, err
+	}
+	pruned2, err := PruneToTaxa(tree2, diff.Taxa, true)
+	if err != nil {
+		return nil, err
+	}
+	rooted1, splits1, err := rootedSplits(pruned1)
+	if err != nil {
+		return nil, err
+	}
+	rooted2, splits2, err := rootedSplits(pruned2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Splits are matched by their sides; both trees are hung from the same taxon, so the
+	// sides are comparable. Edges whose split is missing from the other tree or has another
+	// weight there are marked as different.
+	var match1, different1 = matchSplits(splits1, splits2)
+	var match2, different2 = matchSplits(splits2, splits1)
+	for index := range splits1 {
+		if other, ok := match1[index]; !ok {
+			continue
+		} else if other == -1 {
+			diff.SplitsOnlyInFirst = append(diff.SplitsOnlyInFirst, splits1[index])
+		} else if math.Abs(splits1[index].Weight-splits2[other].Weight) > epsilon {
+			diff.ChangedSplits = append(diff.ChangedSplits, ChangedSplit{splits1[index].Side, splits1[index].Weight, splits2[other].Weight})
+			different1[index], different2[other] = true, true
+		}
+	}
+	for index := range splits2 {
+		if match2[index] == -1 && len(splits2[index].Side) > 0 {
+			diff.SplitsOnlyInSecond = append(diff.SplitsOnlyInSecond, splits2[index])
+		}
+	}
+
+	// The trees are the same over the common taxa
+	if len(diff.SplitsOnlyInFirst) == 0 && len(diff.SplitsOnlyInSecond) == 0 && len(diff.ChangedSplits) == 0 {
+		return diff, nil
+	}
+
+	diff.CommonSubtrees = commonSubtrees(diff.Taxa, rooted1, rooted2, splits1, match1, different1, different2)
+
+	for _, taxon := range movedTaxa(diff.Taxa, splits1, splits2) {
+		diff.MovedTaxa = append(diff.MovedTaxa, MovedTaxon{
+			Taxon:            taxon,
+			FirstAttachment:  taxonAttachment(pruned1, taxon),
+			SecondAttachment: taxonAttachment(pruned2, taxon),
+		})
+	}
+
+	return diff, nil
+}
+
+// Finds the index of each split in the other splits (-1 if it is missing) and marks the
+// missing ones. Splits with empty sides (the root of rootedSplits) are not matched.
+func matchSplits(splits, other []Split) (map[int]int, []bool) {
+	var index = make(map[string]int, len(other))
+	for i := range other {
+		if len(other[i].Side) > 0 {
+			index[other[i].Key()] = i
+		}
+	}
+
+	var match = make(map[int]int, len(splits))
+	var different = make([]bool, len(splits))
+	for i := range splits {
+		if len(splits[i].Side) == 0 {
+			continue
+		}
+		if j, ok := index[splits[i].Key()]; ok {
+			match[i] = j
+		} else {
+			match[i] = -1
+			different[i] = true
+		}
+	}
+	return match, different
+}
+
+// Finds the largest clades that have the same subtree in both trees. A clade is the side of
+// an edge both trees have, and its subtree is the same if no edge within it is different.
+// Both sides of each edge are considered: the subtree below the edge in pre-order, and the
+// rest of the tree.
+func commonSubtrees(taxa []int, rooted1, rooted2 *RootedTree, splits1 []Split, match1 map[int]int, different1, different2 []bool) [][]int {
+	var prefix1, prefix2 = prefixCounts(different1), prefixCounts(different2)
+	var countIn = func(prefix []int, tree *RootedTree, index int) int {
+		return prefix[index+tree.SubtreeSize[index]] - prefix[index+1]
+	}
+
+	var clades = make([][]int, 0)
+	for index, other := range match1 {
+		if other == -1 {
+			continue
+		}
+
+		var side = splits1[index].Side
+		if len(side) >= 2 && countIn(prefix1, rooted1, index) == 0 && countIn(prefix2, rooted2, other) == 0 {
+			clades = append(clades, side)
+		}
+
+		// The rest of the tree is the same if the only different edges are below this one
+		var rest = len(taxa) - len(side)
+		var outside1 = prefix1[len(different1)] - countIn(prefix1, rooted1, index) - boolCount(different1[index])
+		var outside2 = prefix2[len(different2)] - countIn(prefix2, rooted2, other) - boolCount(different2[other])
+		if rest >= 2 && outside1 == 0 && outside2 == 0 {
+			clades = append(clades, complementOf(taxa, side))
+		}
+	}
+
+	// Two clades of a tree are nested, disjoint or together cover all taxa, and clades that
+	// cover all taxa cannot both be common if the trees differ anywhere, so a clade is within
+	// a larger common clade if its first taxon is
+	sort.Slice(clades, func(a, b int) bool {
+		if len(clades[a]) != len(clades[b]) {
+			return len(clades[a]) > len(clades[b])
+		}
+		return clades[a][0] < clades[b][0]
+	})
+	var covered = make(map[int]bool)
+	var largest = make([][]int, 0)
+	for _, clade := range clades {
+		if covered[clade[0]] {
+			continue
+		}
+		largest = append(largest, clade)
+		for _, taxon := range clade {
+			covered[taxon] = true
+		}
+	}
+
+	sort.Slice(largest, func(a, b int) bool {
+		return largest[a][0] < largest[b][0]
+	})
+	return largest
+}
+
+// Returns the number of marked entries before each position
+func prefixCounts(marked []bool) []int {
+	var prefix = make([]int, len(marked)+1)
+	for i, isMarked := range marked {
+		prefix[i+1] = prefix[i] + boolCount(isMarked)
+	}
+	return prefix
+}
+
+func boolCount(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// Returns the sorted taxa that are not in the sorted side
+func complementOf(taxa []int, side []int) []int {
+	var rest = make([]int, 0, len(taxa)-len(side))
+	var j = 0
+	for _, taxon := range taxa {
+		if j < len(side) && side[j] == taxon {
+			j++
+		} else {
+			rest = append(rest, taxon)
+		}
+	}
+	return rest
+}
+
+// Most taxa movedTaxa removes before giving up: a few misplaced taxa explain a difference
+// well, but trees that are further apart are better described by their splits
+const maxMovedTaxa = 10
+
+// Finds taxa whose removal from both trees makes their splits agree. Taxa are removed
+// greedily, each time the one that leaves the fewest splits in only one of the trees, as
+// long as that number decreases. Returns nil if the splits do not agree after removing
+// maxMovedTaxa taxa. Edge lengths are ignored.
+func movedTaxa(taxa []int, splits1, splits2 []Split) []int {
+	var position = make(map[int]int, len(taxa))
+	for i, taxon := range taxa {
+		position[taxon] = i
+	}
+	var words = (len(taxa) + 63) / 64
+	var toBits = func(splits []Split) [][]uint64 {
+		var result = make([][]uint64, 0, len(splits))
+		for _, split := range splits {
+			if len(split.Side) == 0 {
+				continue
+			}
+			var set = make([]uint64, words)
+			for _, taxon := range split.Side {
+				set[position[taxon]/64] |= 1 << (position[taxon] % 64)
+			}
+			result = append(result, set)
+		}
+		return result
+	}
+	var bits1, bits2 = toBits(splits1), toBits(splits2)
+
+	var removed = make([]uint64, words)
+	var moved = make([]int, 0)
+	var differing, candidates = projectedDifference(bits1, bits2, removed, len(taxa))
+	for differing > 0 {
+		var best, bestDiffering = -1, differing
+		for _, candidate := range candidates {
+			removed[candidate/64] |= 1 << (candidate % 64)
+			if count, _ := projectedDifference(bits1, bits2, removed, len(taxa)); count < bestDiffering {
+				best, bestDiffering = candidate, count
+			}
+			removed[candidate/64] &^= 1 << (candidate % 64)
+		}
+		if best == -1 || len(moved) == maxMovedTaxa {
+			return nil
+		}
+
+		removed[best/64] |= 1 << (best % 64)
+		moved = append(moved, taxa[best])
+		differing, candidates = projectedDifference(bits1, bits2, removed, len(taxa))
+	}
+
+	sort.Ints(moved)
+	return moved
+}
+
+// Restricts the splits of two trees (as bit sets of taxon positions) to the taxa that are not
+// removed, and returns the number of non-trivial splits in only one of the trees, with the
+// sorted positions of the taxa on the smaller sides of those splits
+func projectedDifference(bits1, bits2 [][]uint64, removed []uint64, size int) (int, []int) {
+	var remaining = size
+	var reference = -1
+	for i := 0; i < size; i++ {
+		if removed[i/64]&(1<<(i%64)) != 0 {
+			remaining--
+		} else if reference == -1 {
+			reference = i
+		}
+	}
+	if remaining < 4 {
+		// Trees with fewer than four taxa have no non-trivial splits
+		return 0, nil
+	}
+
+	// Each split is normalized to the side without the first remaining taxon
+	var project = func(splits [][]uint64) map[string][]uint64 {
+		var projected = make(map[string][]uint64, len(splits))
+		for _, split := range splits {
+			var set = make([]uint64, len(split))
+			var flip = split[reference/64]&(1<<(reference%64)) != 0
+			var count = 0
+			for w := range split {
+				set[w] = split[w] &^ removed[w]
+				if flip {
+					set[w] = ^split[w] &^ removed[w]
+					if w == len(split)-1 && size%64 != 0 {
+						set[w] &= 1<<(size%64) - 1
+					}
+				}
+				count += bits.OnesCount64(set[w])
+			}
+			if count >= 2 && count <= remaining-2 {
+				projected[bitSetKey(set)] = set
+			}
+		}
+		return projected
+	}
+	var projected1, projected2 = project(bits1), project(bits2)
+
+	var count = 0
+	var candidates = make(map[int]bool)
+	for _, pair := range [][2]map[string][]uint64{{projected1, projected2}, {projected2, projected1}} {
+		for key, set := range pair[0] {
+			if _, ok := pair[1][key]; ok {
+				continue
+			}
+			count++
+
+			var members = make([]int, 0)
+			for i := 0; i < size; i++ {
+				if set[i/64]&(1<<(i%64)) != 0 {
+					members = append(members, i)
+				}
+			}
+			if 2*len(members) > remaining {
+				members = make([]int, 0)
+				for i := 0; i < size; i++ {
+					if (set[i/64]|removed[i/64])&(1<<(i%64)) == 0 {
+						members = append(members, i)
+					}
+				}
+			}
+			for _, member := range members {
+				candidates[member] = true
+			}
+		}
+	}
+
+	var sorted = make([]int, 0, len(candidates))
+	for candidate := range candidates {
+		sorted = append(sorted, candidate)
+	}
+	sort.Ints(sorted)
+	return count, sorted
+}
+
+func bitSetKey(set []uint64) string {
+	var key = make([]byte, 0, 8*len(set))
+	for _, word := range set {
+		for shift := 0; shift < 64; shift += 8 {
+			key = append(key, byte(word>>shift))
+		}
+	}
+	return string(key)
+}
+
+// Returns where a taxon is attached: for a leaf hanging from a node with two other neighbors
+// that is not a taxon, the ends of the edge it hangs from; otherwise the node(s) it is joined
+// to. A duplicate taxon is attached to its representative.
+func taxonAttachment(tree *Graph, taxon int) []int {
+	var location = tree.TaxonLocation(taxon)
+	if location != taxon {
+		return []int{location}
+	}
+
+	var neighbors = neighborsOf(tree, location)
+	if len(neighbors) == 1 {
+		var parent = neighbors[0]
+		if tree.IsTaxon(parent) || len(tree.Edges[parent]) != 3 {
+			return []int{parent}
+		}
+		var ends = make([]int, 0, 2)
+		for _, neighbor := range neighborsOf(tree, parent) {
+			if neighbor != location {
+				ends = append(ends, neighbor)
+			}
+		}
+		neighbors = ends
+	}
+
+	sort.Ints(neighbors)
+	return neighbors
+}
@@ -0,0 +1,79 @@
+package algorithms
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Builds a tree from its edges, with taxa as leaves
+func treeOfEdges(edges [][3]int) *Graph {
+	graph := &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}}
+	for _, edge := range edges {
+		graph.AddNode(edge[0])
+		graph.AddNode(edge[1])
+		graph.AddEdge(edge[0], edge[1], float64(edge[2]))
+	}
+	return graph
+}
+
+func TestDiffTrees(t *testing.T) {
+	// Taxa 0..5 along a path of internal nodes 6..9
+	caterpillar := [][3]int{{0, 6, 1}, {1, 6, 1}, {6, 7, 1}, {2, 7, 1}, {7, 8, 1}, {3, 8, 1}, {8, 9, 1}, {4, 9, 1}, {5, 9, 1}}
+	tree := treeOfEdges(caterpillar)
+
+	for _, seed := range []int64{1, 2, 3} {
+		generated, err := GenerateRandomTree(30, seed, 0.3, 0.25)
+		if err != nil {
+			t.Fatalf("seed %d: GenerateRandomTree returned error: %v", seed, err)
+		}
+		diff, err := DiffTrees(generated, shuffledCopy(generated, rand.New(rand.NewSource(seed)), false), 1e-6)
+		if err != nil {
+			t.Fatalf("seed %d: DiffTrees returned error: %v", seed, err)
+		}
+		if !diff.Identical() {
+			t.Errorf("seed %d: copy with renumbered internal nodes differs: %+v", seed, diff)
+		}
+	}
+
+	// Leaf 1 moved from next to 0 to next to 4 and 5
+	moved := treeOfEdges([][3]int{{0, 6, 1}, {6, 7, 1}, {2, 7, 1}, {7, 8, 1}, {3, 8, 1}, {8, 9, 1}, {4, 9, 1}, {5, 9, 1}, {1, 9, 1}})
+	diff, err := DiffTrees(tree, moved, 1e-6)
+	if err != nil {
+		t.Fatalf("DiffTrees returned error: %v", err)
+	}
+	expected := []MovedTaxon{{Taxon: 1, FirstAttachment: []int{0, 7}, SecondAttachment: []int{9}}}
+	if !reflect.DeepEqual(diff.MovedTaxa, expected) {
+		t.Errorf("expected moved taxa %v, got %v", expected, diff.MovedTaxa)
+	}
+	if len(diff.SplitsOnlyInFirst) != 3 || len(diff.SplitsOnlyInSecond) != 2 {
+		t.Errorf("expected 3 and 2 unmatched splits, got %v and %v", diff.SplitsOnlyInFirst, diff.SplitsOnlyInSecond)
+	}
+
+	// A longer edge to leaf 5 leaves the rest of the tree in common
+	longer := append([][3]int{}, caterpillar...)
+	longer[len(longer)-1][2] = 3
+	diff, err = DiffTrees(tree, treeOfEdges(longer), 1e-6)
+	if err != nil {
+		t.Fatalf("DiffTrees returned error: %v", err)
+	}
+	if len(diff.ChangedSplits) != 1 || diff.ChangedSplits[0].FirstWeight != 1 || diff.ChangedSplits[0].SecondWeight != 3 {
+		t.Errorf("expected the edge to 5 to change from 1 to 3, got %v", diff.ChangedSplits)
+	}
+	if !reflect.DeepEqual(diff.CommonSubtrees, [][]int{{0, 1, 2, 3, 4}}) {
+		t.Errorf("expected common subtree {0,1,2,3,4}, got %v", diff.CommonSubtrees)
+	}
+
+	// Taxa missing from one tree are reported, and the rest is compared without them
+	pruned, err := PruneToTaxa(tree, []int{0, 1, 2, 3, 4}, true)
+	if err != nil {
+		t.Fatalf("PruneToTaxa returned error: %v", err)
+	}
+	diff, err = DiffTrees(tree, pruned, 1e-6)
+	if err != nil {
+		t.Fatalf("DiffTrees returned error: %v", err)
+	}
+	if !reflect.DeepEqual(diff.OnlyInFirst, []int{5}) || len(diff.OnlyInSecond) != 0 || len(diff.SplitsOnlyInFirst)+len(diff.SplitsOnlyInSecond)+len(diff.ChangedSplits) != 0 {
+		t.Errorf("expected only taxon 5 to differ, got %+v", diff)
+	}
+}
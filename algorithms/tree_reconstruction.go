@@ -13,8 +13,32 @@ func CastMatrixToFloat(matrix [][]uint32) [][]float64 {
 }
 
 func ReconstructIntTree(matrix [][]uint32, epsilon float64) (*Graph, error) {
-	var floatMatrix = CastMatrixToFloat(matrix)
-	var tree, err = NeighborJoining(floatMatrix)
+	return reconstructFromDistances(CastMatrixToFloat(matrix), epsilon, false)
+}
+
+// Reconstructs a tree with real-valued branch lengths from a distance matrix.
+// Unlike the integer pipeline, edge weights are not required to be integral, and negative
+// branch lengths from non-additive (e.g. measured) distances are clamped to zero.
+// Identical taxa are collapsed before reconstruction and recorded as duplicates.
+func ReconstructRealTree(matrix [][]float64, epsilon float64) (*Graph, error) {
+	return reconstructFromDistances(matrix, epsilon, true)
+}
+
+// Collapses identical taxa, joins the rest and restores the duplicates
+func reconstructFromDistances(matrix [][]float64, epsilon float64, clampNegative bool) (*Graph, error) {
+	duplicates, err := FindDuplicateTaxa(matrix, epsilon)
+	if err != nil {
+		return nil, err
+	}
+
+	reduced, kept := CollapseDuplicateTaxa(matrix, duplicates)
+	if len(reduced) == 1 && len(matrix) > 1 {
+		tree := &Graph{Nodes: map[int]struct{}{}, Edges: map[int][]Edge{}, Taxa: map[int]struct{}{0: {}}}
+		tree.AddNode(0)
+		return restoreDuplicateTaxa(tree, kept, duplicates, len(matrix))
+	}
+
+	tree, err := neighborJoining(reduced, clampNegative)
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +48,30 @@ func ReconstructIntTree(matrix [][]uint32, epsilon float64) (*Graph, error) {
 		return nil, err
 	}
 
-	return tree, nil
+	if len(duplicates) == 0 {
+		return tree, nil
+	}
+
+	return restoreDuplicateTaxa(tree, kept, duplicates, len(matrix))
 }
 
+// Reconstructs a tree from an integer distance matrix, allowing edge weights that are
+// multiples of 1/k (e.g. half-integers when path parities are odd). The returned tree
+// has all weights multiplied by the smallest such k, which is returned alongside it.
+func ReconstructScaledIntTree(matrix [][]uint32, epsilon float64, maxScale int) (*Graph, int, error) {
+	tree, err := ReconstructIntTree(matrix, epsilon)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	scale, err := tree.IntegerScaleFactor(epsilon, maxScale)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if scale != 1 {
+		tree.ScaleWeights(float64(scale))
+	}
+
+	return tree, scale, nil
+}
@@ -0,0 +1,99 @@
+package algorithms
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReconstructScaledIntTree(t *testing.T) {
+	// The scale comes from the parities of the path lengths: every edge weight is half an
+	// integer combination of distances, such as (d(i,j) + d(i,k) - d(j,k)) / 2 for the edge of
+	// leaf i, so an integer matrix needs a factor of 2 when such a sum is odd, and never more
+	// (finer steps such as thirds are covered by TestIntegerScaleFactor)
+	tests := []struct {
+		name      string
+		matrix    [][]uint32
+		maxScale  int
+		wantScale int
+		wantErr   bool
+	}{
+		{
+			name:      "integer weights",
+			matrix:    [][]uint32{{0, 2, 3, 3}, {2, 0, 3, 3}, {3, 3, 0, 2}, {3, 3, 2, 0}},
+			maxScale:  1000,
+			wantScale: 1,
+		},
+		{
+			// A star with edges 0.5, 1.5 and 1.5
+			name:      "half-integer star",
+			matrix:    [][]uint32{{0, 2, 2}, {2, 0, 3}, {2, 3, 0}},
+			maxScale:  1000,
+			wantScale: 2,
+		},
+		{
+			// ((0,1),(2,3)) with leaf edges 0.5 and an internal edge of 1.5
+			name:      "half-integer internal edge",
+			matrix:    [][]uint32{{0, 1, 3, 3}, {1, 0, 3, 3}, {3, 3, 0, 1}, {3, 3, 1, 0}},
+			maxScale:  1000,
+			wantScale: 2,
+		},
+		{
+			name:     "half-integers with scaling disabled",
+			matrix:   [][]uint32{{0, 2, 2}, {2, 0, 3}, {2, 3, 0}},
+			maxScale: 1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, scale, err := ReconstructScaledIntTree(tt.matrix, 1e-10, tt.maxScale)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconstructScaledIntTree() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if scale != tt.wantScale {
+				t.Errorf("ReconstructScaledIntTree() scale = %d, want %d", scale, tt.wantScale)
+			}
+			if !tree.IsIntegerWeighted(1e-9) {
+				t.Errorf("expected integer weights after scaling, got %v", tree.AllEdges)
+			}
+
+			distances, err := CalculateDistanceMatrix(tree)
+			if err != nil {
+				t.Fatalf("CalculateDistanceMatrix returned error: %v", err)
+			}
+			expected := make([][]int, len(tt.matrix))
+			for i, row := range tt.matrix {
+				expected[i] = make([]int, len(row))
+				for j, distance := range row {
+					expected[i][j] = int(distance) * scale
+				}
+			}
+			if !reflect.DeepEqual(distances, expected) {
+				t.Errorf("expected the scaled tree to realize %v, got %v", expected, distances)
+			}
+		})
+	}
+}
+
+func TestNegativeBranchLengths(t *testing.T) {
+	// Not a tree metric: the edge of taxon 0 would be (1 + 1 - 5) / 2 < 0
+	matrix := [][]uint32{{0, 1, 1}, {1, 0, 5}, {1, 5, 0}}
+
+	if _, err := ReconstructIntTree(matrix, 1e-10); err == nil {
+		t.Errorf("expected an error for a negative branch length in the integer pipeline")
+	}
+
+	tree, err := ReconstructRealTree(CastMatrixToFloat(matrix), 1e-10)
+	if err != nil {
+		t.Fatalf("ReconstructRealTree returned error: %v", err)
+	}
+	for _, edge := range tree.AllEdges {
+		if edge.Weight < 0 {
+			t.Errorf("edge %d-%d has negative weight %g after clamping", edge.Node1, edge.Node2, edge.Weight)
+		}
+	}
+}
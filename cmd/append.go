@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+)
+
+type AppendResult struct {
+	SerializedTree string
+	Scale          int
+	Insertion      *algorithms.LeafInsertion
+	Error          error
+}
+
+// Inserts a new taxon into the tree stored in treeFilePath. The row file holds the distances
+// from the new taxon to the current taxa, in ascending order of their IDs. For integer
+// serializations, edge weights are scaled further if the new taxon needs it, up to a total
+// scale of maxScale.
+func runAppendCommand(treeFilePath, rowFilePath, outputFilePath string, serializationType io.SerializationType, maxScale int) AppendResult {
+	treeContent, err := os.ReadFile(treeFilePath)
+	if err != nil {
+		return AppendResult{Error: fmt.Errorf("error reading file %s: %v", treeFilePath, err)}
+	}
+
+	rowContent, err := os.ReadFile(rowFilePath)
+	if err != nil {
+		return AppendResult{Error: fmt.Errorf("error reading file %s: %v", rowFilePath, err)}
+	}
+
+	tree, err := io.ParseTree(string(treeContent))
+	if err != nil {
+		return AppendResult{Error: fmt.Errorf("error parsing tree from %s: %v", treeFilePath, err)}
+	}
+
+	scale, err := io.ParseScaleHeader(string(treeContent))
+	if err != nil {
+		return AppendResult{Error: err}
+	}
+
+	row, err := io.ParseDistanceRow(string(rowContent))
+	if err != nil {
+		return AppendResult{Error: fmt.Errorf("error parsing distances: %v", err)}
+	}
+
+	taxa := tree.AllTaxa()
+	if len(row) != len(taxa) {
+		return AppendResult{Error: fmt.Errorf("tree has %d taxa, but %d distances were given", len(taxa), len(row))}
+	}
+
+	distances := make(map[int]float64)
+	for i, taxon := range taxa {
+		distances[taxon] = row[i] * float64(scale)
+	}
+
+	epsilon := 1e-10
+	insertion, err := algorithms.InsertLeaf(tree, distances, epsilon)
+	if err != nil {
+		return AppendResult{Error: fmt.Errorf("error inserting leaf: %v", err)}
+	}
+
+	// A leaf attached inside an edge can halve integer weights
+	if io.RequiresIntegerWeights(serializationType) {
+		factor, err := tree.IntegerScaleFactor(epsilon, maxScale/scale)
+		if err != nil {
+			return AppendResult{Error: fmt.Errorf("error inserting leaf: %v", err)}
+		}
+		if factor != 1 {
+			tree.ScaleWeights(float64(factor))
+			scale *= factor
+		}
+	}
+
+	serialized, err := io.SerializeGraph(tree, serializationType)
+	if err != nil {
+		return AppendResult{Error: fmt.Errorf("error serializing tree: %v", err)}
+	}
+
+	if outputFilePath != "" {
+		if err := writeOutputFile(outputFilePath, formatTreeFile(tree, scale, serialized, serializationType)); err != nil {
+			return AppendResult{Error: err}
+		}
+	}
+
+	return AppendResult{SerializedTree: serialized, Scale: scale, Insertion: insertion}
+}
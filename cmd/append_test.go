@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+)
+
+func TestAppendRescalesIntegerTree(t *testing.T) {
+	dir := t.TempDir()
+	matrixFile := filepath.Join(dir, "matrix.csv")
+	treeFile := filepath.Join(dir, "tree.txt")
+	rowFile := filepath.Join(dir, "row.csv")
+	outputFile := filepath.Join(dir, "appended.txt")
+
+	// ((0,1),(2,3)) with leaf edges of 1 and an internal edge of 2; the new taxon hangs by
+	// an edge of 0.5 from a point 0.5 along the internal edge, so weights become halves
+	if err := os.WriteFile(matrixFile, []byte("0,2,4,4\n2,0,4,4\n4,4,0,2\n4,4,2,0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rowFile, []byte("2,2,3,3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	options := ReconstructOptions{SerializationType: io.SerializationTypeNeighborLists, MaxScale: 1000}
+	if result := runReconstructCommand(matrixFile, treeFile, options); result.Error != nil {
+		t.Fatalf("runReconstructCommand returned error: %v", result.Error)
+	}
+
+	result := runAppendCommand(treeFile, rowFile, outputFile, io.SerializationTypeNeighborLists, 1000)
+	if result.Error != nil {
+		t.Fatalf("runAppendCommand returned error: %v", result.Error)
+	}
+	if result.Scale != 2 {
+		t.Errorf("expected the weights to be scaled by 2, got %d", result.Scale)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := io.ParseTree(string(content))
+	if err != nil {
+		t.Fatalf("ParseTree returned error: %v", err)
+	}
+	scale, err := io.ParseScaleHeader(string(content))
+	if err != nil || scale != 2 {
+		t.Errorf("expected a scale header of 2, got %d (%v)", scale, err)
+	}
+
+	distances, err := algorithms.CalculateDistanceMatrix(tree)
+	if err != nil {
+		t.Fatalf("CalculateDistanceMatrix returned error: %v", err)
+	}
+	expected := [][]int{{0, 4, 8, 8, 4}, {4, 0, 8, 8, 4}, {8, 8, 0, 4, 6}, {8, 8, 4, 0, 6}, {4, 4, 6, 6, 0}}
+	if !reflect.DeepEqual(distances, expected) {
+		t.Errorf("expected the appended tree to realize twice the distances %v, got %v", expected, distances)
+	}
+
+	// Without room to scale, the half-integer edge cannot be serialized
+	if result := runAppendCommand(treeFile, rowFile, "", io.SerializationTypeNeighborLists, 1); result.Error == nil {
+		t.Errorf("expected an error with a maximum scale of 1")
+	}
+}
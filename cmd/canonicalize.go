@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	canonicalizeTreeFile                string
+	canonicalizeOutputFile              string
+	canonicalizeAnonymous               bool
+	canonicalizeSerializationTypeString string
+)
+
+type CanonicalizeOptions struct {
+	Anonymous         bool
+	SerializationType io.SerializationType
+}
+
+type CanonicalizeResult struct {
+	// Serialized tree with its headers, as written to the output file
+	Content string
+	Error   error
+}
+
+func init() {
+	canonicalizeCmd.Flags().StringVarP(&canonicalizeTreeFile, "input", "i", "", "Input tree file path (required)")
+	canonicalizeCmd.Flags().StringVarP(&canonicalizeOutputFile, "output", "o", "", "Output file path (defaults to stdout)")
+	canonicalizeCmd.Flags().BoolVar(&canonicalizeAnonymous, "anonymous", false, "Renumber the taxa too and drop labels, keeping only the shape and edge weights")
+	canonicalizeCmd.Flags().StringVarP(&canonicalizeSerializationTypeString, "serialization", "s", "newick", "Serialization type (brackets, brackets-shortened, neighbor-lists, neighbor-lists-shortened, newick, dot)")
+	canonicalizeCmd.MarkFlagRequired("input")
+
+	rootCmd.AddCommand(canonicalizeCmd)
+}
+
+func runCanonicalizeCommand(treeFilePath string, options CanonicalizeOptions) CanonicalizeResult {
+	content, err := os.ReadFile(treeFilePath)
+	if err != nil {
+		return CanonicalizeResult{Error: fmt.Errorf("error reading file %s: %v", treeFilePath, err)}
+	}
+
+	tree, err := io.ParseTree(string(content))
+	if err != nil {
+		return CanonicalizeResult{Error: fmt.Errorf("error parsing tree from %s: %v", treeFilePath, err)}
+	}
+
+	scale, err := io.ParseScaleHeader(string(content))
+	if err != nil {
+		return CanonicalizeResult{Error: err}
+	}
+
+	if io.RequiresIntegerWeights(options.SerializationType) && !tree.IsIntegerWeighted(1e-6) {
+		return CanonicalizeResult{Error: fmt.Errorf("real-valued trees can only be serialized as newick or dot")}
+	}
+
+	// Neighbor lists spell out weighted edges as chains of new nodes, which must be numbered
+	// canonically too, and shortened neighbor lists are always written with the longest
+	// edges possible
+	switch options.SerializationType {
+	case io.SerializationTypeNeighborLists:
+		err = tree.SplitEdges(1e-6)
+	case io.SerializationTypeNeighborListsShortened:
+		err = tree.ContractUnaryNodes()
+	}
+	if err != nil {
+		return CanonicalizeResult{Error: fmt.Errorf("error preparing tree: %v", err)}
+	}
+
+	canonical, root, err := algorithms.CanonicalTree(tree, options.Anonymous)
+	if err != nil {
+		return CanonicalizeResult{Error: fmt.Errorf("error canonicalizing tree: %v", err)}
+	}
+
+	serialized, err := io.SerializeRootedGraph(canonical, options.SerializationType, root)
+	if err != nil {
+		return CanonicalizeResult{Error: fmt.Errorf("error serializing tree: %v", err)}
+	}
+
+	return CanonicalizeResult{Content: formatTreeFile(canonical, scale, serialized, options.SerializationType)}
+}
+
+var canonicalizeCmd = &cobra.Command{
+	Use:   "canonicalize",
+	Short: "Rewrite a tree file in canonical form",
+	Long: `Rewrite a tree file in a canonical form: the tree is hung from its center, children are ordered by the
+shape of their subtrees, and internal nodes are numbered in that order after the taxa, which are
+numbered in order of label if labelled. Trees that are isomorphic with the same taxa at the same
+places give identical output, whatever order their taxa were listed in, so tree files can be
+deduplicated with sort | uniq and compared with diff. With --anonymous, taxa are renumbered too and
+labels dropped, so that trees with the same shape and edge weights give identical output.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serializationType, err := io.ParseSerializationType(canonicalizeSerializationTypeString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		result := runCanonicalizeCommand(canonicalizeTreeFile, CanonicalizeOptions{
+			Anonymous:         canonicalizeAnonymous,
+			SerializationType: serializationType,
+		})
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		if canonicalizeOutputFile != "" {
+			if err := writeOutputFile(canonicalizeOutputFile, result.Content); err != nil {
+				fmt.Printf("%v\n", err)
+				return
+			}
+			fmt.Printf("Canonical tree written to %s\n", canonicalizeOutputFile)
+			return
+		}
+
+		fmt.Print(result.Content)
+		if serializationType != io.SerializationTypeNeighborLists && serializationType != io.SerializationTypeNeighborListsShortened {
+			fmt.Println()
+		}
+	},
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	clusterTreeFile   string
+	clusterMatrixFile string
+	clusterOutputFile string
+	clusterRoot       string
+	clusterCount      int
+	clusterThreshold  float64
+	clusterFormat     string
+)
+
+type ClusterOptions struct {
+	Root      string
+	Count     int
+	Threshold float64
+	Format    string
+}
+
+type ClusterResult struct {
+	Clusters  [][]int
+	Formatted string
+	Error     error
+}
+
+// Assignment of a taxon to a cluster in the JSON output
+type clusterAssignment struct {
+	Taxon   string `json:"taxon"`
+	Cluster int    `json:"cluster"`
+}
+
+func init() {
+	clusterCmd.Flags().StringVarP(&clusterTreeFile, "input", "i", "", "Input tree file path (neighbor lists or Newick)")
+	clusterCmd.Flags().StringVarP(&clusterMatrixFile, "matrix", "m", "", "Input distance matrix file path, reconstructed into a tree first")
+	clusterCmd.Flags().StringVarP(&clusterOutputFile, "output", "o", "", "Output file path for the cluster assignments")
+	clusterCmd.Flags().StringVar(&clusterRoot, "root", "", "Where to root the tree: "+rootingUsage+" (required)")
+	clusterCmd.Flags().IntVarP(&clusterCount, "clusters", "k", 0, "Number of clusters to cut the tree into")
+	clusterCmd.Flags().Float64VarP(&clusterThreshold, "threshold", "t", -1, "Largest distance between two taxa of the same cluster")
+	clusterCmd.Flags().StringVarP(&clusterFormat, "format", "f", "csv", "Output format (csv, json)")
+	clusterCmd.MarkFlagRequired("root")
+
+	rootCmd.AddCommand(clusterCmd)
+}
+
+// Reads a tree file, undoing the scaling of its edge weights so that distances are in
+// the units of the original matrix
+func readTreeFile(treeFilePath string) (*algorithms.Graph, error) {
+	content, err := os.ReadFile(treeFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %v", treeFilePath, err)
+	}
+
+	tree, err := io.ParseTree(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing tree from %s: %v", treeFilePath, err)
+	}
+
+	scale, err := io.ParseScaleHeader(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if scale != 1 {
+		tree.ScaleWeights(1 / float64(scale))
+	}
+
+	return tree, nil
+}
+
+// Reads a distance matrix and reconstructs the tree with real-valued branch lengths
+func readMatrixAsTree(matrixFilePath string) (*algorithms.Graph, error) {
+	content, err := os.ReadFile(matrixFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+
+	matrix, err := io.ParseFloatMatrix(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing matrix: %v", err)
+	}
+
+	tree, err := algorithms.ReconstructRealTree(matrix, 1e-10)
+	if err != nil {
+		return nil, fmt.Errorf("error reconstructing tree: %v", err)
+	}
+
+	return tree, nil
+}
+
+func runClusterCommand(tree *algorithms.Graph, options ClusterOptions) ClusterResult {
+	if (options.Count > 0) == (options.Threshold >= 0) {
+		return ClusterResult{Error: fmt.Errorf("exactly one of --clusters and --threshold must be given")}
+	}
+
+	rooting, err := applyRooting(tree, options.Root)
+	if err != nil {
+		return ClusterResult{Error: fmt.Errorf("error rooting tree: %v", err)}
+	}
+	root := rooting.Root
+
+	var clusters [][]int
+	if options.Count > 0 {
+		clusters, err = algorithms.CutTreeIntoClusters(tree, root, options.Count)
+	} else {
+		clusters, err = algorithms.CutTreeAtThreshold(tree, root, options.Threshold)
+	}
+	if err != nil {
+		return ClusterResult{Error: fmt.Errorf("error clustering tree: %v", err)}
+	}
+
+	formatted, err := formatClusters(tree, clusters, options.Format)
+	if err != nil {
+		return ClusterResult{Error: err}
+	}
+
+	return ClusterResult{Clusters: clusters, Formatted: formatted}
+}
+
+// Formats the taxon to cluster assignments, with clusters numbered from 1
+func formatClusters(tree *algorithms.Graph, clusters [][]int, format string) (string, error) {
+	var assignments = make([]clusterAssignment, 0)
+	for i, cluster := range clusters {
+		for _, taxon := range cluster {
+			assignments = append(assignments, clusterAssignment{Taxon: tree.NodeName(taxon), Cluster: i + 1})
+		}
+	}
+
+	switch format {
+	case "csv":
+		var lines = []string{"taxon,cluster"}
+		for _, assignment := range assignments {
+			lines = append(lines, fmt.Sprintf("%s,%d", assignment.Taxon, assignment.Cluster))
+		}
+		return strings.Join(lines, "\n") + "\n", nil
+	case "json":
+		formatted, err := json.MarshalIndent(assignments, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error formatting clusters: %v", err)
+		}
+		return string(formatted) + "\n", nil
+	default:
+		return "", fmt.Errorf("invalid output format: %s", format)
+	}
+}
+
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Cut a tree into clusters of taxa",
+	Long: `Root a tree (read from a file, or reconstructed from a distance matrix) and cut it into clusters of taxa:
+either into k clusters by repeatedly splitting the widest cluster, or into the largest clades whose
+taxa are all within a distance threshold of each other.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var tree *algorithms.Graph
+		var err error
+		switch {
+		case clusterTreeFile != "" && clusterMatrixFile == "":
+			tree, err = readTreeFile(clusterTreeFile)
+		case clusterMatrixFile != "" && clusterTreeFile == "":
+			tree, err = readMatrixAsTree(clusterMatrixFile)
+		default:
+			err = fmt.Errorf("exactly one of --input and --matrix must be given")
+		}
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		result := runClusterCommand(tree, ClusterOptions{
+			Root:      clusterRoot,
+			Count:     clusterCount,
+			Threshold: clusterThreshold,
+			Format:    clusterFormat,
+		})
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		if clusterOutputFile != "" {
+			if err := writeOutputFile(clusterOutputFile, result.Formatted); err != nil {
+				fmt.Printf("%v\n", err)
+				return
+			}
+			fmt.Printf("%d clusters written to %s\n", len(result.Clusters), clusterOutputFile)
+			return
+		}
+
+		fmt.Print(result.Formatted)
+	},
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 
 	"treereconstruction/algorithms"
 	"treereconstruction/io"
@@ -10,18 +11,38 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	compareShowMapping bool
+	compareEditScript  bool
+)
+
+type CompareOptions struct {
+	// Compute the SPR moves that turn the first tree into the second
+	EditScript bool
+}
+
 type CompareResult struct {
 	TopologiesMatch bool
 	Tree1Summary    []string
 	Tree2Summary    []string
-	Error           error
+	// Nodes of the first tree and the nodes of the second tree they correspond to, as
+	// "node -> node" lines in the order of the first tree's node IDs
+	Mapping []string
+	// Where the trees differ (see io.DescribeTreeDiff), if the topologies do not match
+	Differences []string
+	// SPR moves turning the first tree into the second, if requested
+	EditScript []string
+	Error      error
 }
 
 func init() {
+	compareCmd.Flags().BoolVar(&compareShowMapping, "show-mapping", false, "Print which node of the second tree each node of the first tree corresponds to")
+	compareCmd.Flags().BoolVar(&compareEditScript, "edit-script", false, "Print subtree prune and regraft moves that turn the first tree into the second")
+
 	rootCmd.AddCommand(compareCmd)
 }
 
-func runCompareCommand(file1, file2 string) CompareResult {
+func runCompareCommand(file1, file2 string, options CompareOptions) CompareResult {
 	content1, err := os.ReadFile(file1)
 	if err != nil {
 		return CompareResult{Error: fmt.Errorf("error reading file %s: %v", file1, err)}
@@ -32,12 +53,12 @@ func runCompareCommand(file1, file2 string) CompareResult {
 		return CompareResult{Error: fmt.Errorf("error reading file %s: %v", file2, err)}
 	}
 
-	tree1, err := io.ParseNeighborList(string(content1))
+	tree1, err := io.ParseTree(string(content1))
 	if err != nil {
 		return CompareResult{Error: fmt.Errorf("error parsing tree from %s: %v", file1, err)}
 	}
 
-	tree2, err := io.ParseNeighborList(string(content2))
+	tree2, err := io.ParseTree(string(content2))
 	if err != nil {
 		return CompareResult{Error: fmt.Errorf("error parsing tree from %s: %v", file2, err)}
 	}
@@ -52,14 +73,63 @@ func runCompareCommand(file1, file2 string) CompareResult {
 		return CompareResult{Error: fmt.Errorf("tree from %s is invalid: %v", file2, err)}
 	}
 
-	topologiesMatch := algorithms.CompareTreeTopology(tree1, tree2)
+	// Nodes created when splitting edges below are left out of the mapping
+	var nodes = make([]int, 0, len(tree1.Nodes))
+	for node := range tree1.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Ints(nodes)
+
+	// Integer-weighted trees (e.g. from Newick files) are compared in their unit-edge form
+	for _, tree := range []*algorithms.Graph{tree1, tree2} {
+		if tree.IsIntegerWeighted(1e-6) {
+			if err := tree.SplitEdges(1e-6); err != nil {
+				return CompareResult{Error: err}
+			}
+		}
+	}
+
+	isomorphism, topologiesMatch := algorithms.FindIsomorphism(tree1, tree2)
 	tree1Summary := io.GetTreeSummary(tree1)
 	tree2Summary := io.GetTreeSummary(tree2)
 
+	var mapping []string
+	if topologiesMatch {
+		mapping = make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			mapping = append(mapping, fmt.Sprintf("%s -> %s", tree1.NodeName(node), tree2.NodeName(isomorphism[node])))
+		}
+	}
+
+	var differences []string
+	if !topologiesMatch {
+		diff, err := algorithms.DiffTrees(tree1, tree2, 1e-6)
+		if err != nil {
+			differences = []string{err.Error()}
+		} else {
+			differences = io.DescribeTreeDiff(diff, tree1, tree2, "first", "second")
+		}
+	}
+
+	var editScript []string
+	if options.EditScript {
+		moves, err := algorithms.SPREditScript(tree1, tree2)
+		if err != nil {
+			return CompareResult{Error: fmt.Errorf("error computing edit script: %v", err)}
+		}
+		editScript = make([]string, len(moves))
+		for i, move := range moves {
+			editScript[i] = io.DescribeSPRMove(move, tree1)
+		}
+	}
+
 	return CompareResult{
 		TopologiesMatch: topologiesMatch,
 		Tree1Summary:    tree1Summary,
 		Tree2Summary:    tree2Summary,
+		Mapping:         mapping,
+		Differences:     differences,
+		EditScript:      editScript,
 		Error:           nil,
 	}
 }
@@ -67,13 +137,18 @@ func runCompareCommand(file1, file2 string) CompareResult {
 var compareCmd = &cobra.Command{
 	Use:   "compare <file1> <file2>",
 	Short: "Compare two tree output files",
-	Long:  `Compare two tree output files to check if they represent the same topology (structure), ignoring node names/indexes.`,
-	Args:  cobra.ExactArgs(2),
+	Long: `Compare two tree output files to check if they represent the same topology (structure), ignoring node names/indexes.
+With --show-mapping, matching trees are followed by the correspondence between their nodes. Trees that
+do not match are compared by their splits over their common taxa, listing moved taxa, edges found in
+only one of the trees or with different lengths, and the largest subtrees they have in common.
+With --edit-script, subtree prune and regraft moves that turn the first tree into the second are
+printed (taxa must be the same in both trees; the moves are not necessarily the fewest possible).`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		file1 := args[0]
 		file2 := args[1]
 
-		result := runCompareCommand(file1, file2)
+		result := runCompareCommand(file1, file2, CompareOptions{EditScript: compareEditScript})
 		if result.Error != nil {
 			fmt.Printf("%v\n", result.Error)
 			return
@@ -84,6 +159,13 @@ var compareCmd = &cobra.Command{
 			for _, line := range result.Tree1Summary {
 				fmt.Printf("  %s\n", line)
 			}
+
+			if compareShowMapping {
+				fmt.Printf("Node mapping:\n")
+				for _, line := range result.Mapping {
+					fmt.Printf("  %s\n", line)
+				}
+			}
 		} else {
 			fmt.Printf("✗ Trees have different topologies\n")
 
@@ -96,6 +178,22 @@ var compareCmd = &cobra.Command{
 			for _, line := range result.Tree2Summary {
 				fmt.Printf("    %s\n", line)
 			}
+
+			fmt.Printf("  Differences:\n")
+			for _, line := range result.Differences {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+
+		if compareEditScript {
+			var moves = "moves"
+			if len(result.EditScript) == 1 {
+				moves = "move"
+			}
+			fmt.Printf("Edit script (%d %s):\n", len(result.EditScript), moves)
+			for i, line := range result.EditScript {
+				fmt.Printf("  %d. %s\n", i+1, line)
+			}
 		}
 	},
 }
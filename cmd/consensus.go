@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	consensusOutputFile              string
+	consensusMethodString            string
+	consensusSerializationTypeString string
+)
+
+type ConsensusOptions struct {
+	Method            algorithms.ConsensusMethod
+	SerializationType io.SerializationType
+}
+
+type ConsensusResult struct {
+	Tree           *algorithms.Graph
+	SerializedTree string
+	Error          error
+}
+
+func init() {
+	consensusCmd.Flags().StringVarP(&consensusOutputFile, "output", "o", "", "Output file path for the consensus tree")
+	consensusCmd.Flags().StringVarP(&consensusMethodString, "method", "m", "majority", "Which splits to keep: strict (in all trees), majority (in more than half) or greedy (most frequent first, skipping conflicting ones)")
+	consensusCmd.Flags().StringVarP(&consensusSerializationTypeString, "serialization", "s", "newick", "Serialization type (brackets, brackets-shortened, neighbor-lists, neighbor-lists-shortened, newick, dot)")
+
+	rootCmd.AddCommand(consensusCmd)
+}
+
+func runConsensusCommand(treeFilePaths []string, outputFilePath string, options ConsensusOptions) ConsensusResult {
+	var trees = make([]*algorithms.Graph, 0, len(treeFilePaths))
+	for _, path := range treeFilePaths {
+		tree, err := readTreeFile(path)
+		if err != nil {
+			return ConsensusResult{Error: err}
+		}
+		trees = append(trees, tree)
+	}
+
+	consensus, err := algorithms.ConsensusTree(trees, options.Method)
+	if err != nil {
+		return ConsensusResult{Error: fmt.Errorf("error building consensus tree: %v", err)}
+	}
+
+	// Edge lengths are averaged over the trees, so they may not be integers anymore
+	if io.RequiresIntegerWeights(options.SerializationType) && !consensus.IsIntegerWeighted(1e-6) {
+		return ConsensusResult{Error: fmt.Errorf("real-valued trees can only be serialized as newick or dot")}
+	}
+
+	serialized, err := io.SerializeRootedGraph(consensus, options.SerializationType, consensus.TaxonNodes()[0])
+	if err != nil {
+		return ConsensusResult{Error: fmt.Errorf("error serializing tree: %v", err)}
+	}
+
+	if outputFilePath != "" {
+		if err := writeOutputFile(outputFilePath, formatTreeFile(consensus, 1, serialized, options.SerializationType)); err != nil {
+			return ConsensusResult{Error: err}
+		}
+	}
+
+	return ConsensusResult{Tree: consensus, SerializedTree: serialized}
+}
+
+var consensusCmd = &cobra.Command{
+	Use:   "consensus <tree-file>...",
+	Short: "Build the consensus tree of several trees",
+	Long: `Build a consensus tree of trees with the same taxa (matched by label, or by ID if unlabelled) from
+the splits (bipartitions of the taxa) of their edges. The strict consensus keeps the splits found in all trees, the majority-rule consensus those
+found in more than half of them, and the greedy consensus adds splits from the most frequent down,
+skipping those that conflict with the ones already added. Edges are as long as the mean length of
+their split in the trees that have it, and internal edges are annotated with the fraction of trees
+that have their split: in Newick as a [&support=...] comment, in DOT after the edge length.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		method, err := algorithms.ParseConsensusMethod(consensusMethodString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+		serializationType, err := io.ParseSerializationType(consensusSerializationTypeString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		result := runConsensusCommand(args, consensusOutputFile, ConsensusOptions{
+			Method:            method,
+			SerializationType: serializationType,
+		})
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		fmt.Printf("Consensus of %d trees (%s)\n", len(args), consensusMethodString)
+		for _, line := range io.GetTreeSummary(result.Tree) {
+			fmt.Printf("%s\n", line)
+		}
+		printSerializedTree(serializationType, result.SerializedTree)
+	},
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	distancesInputFile  string
+	distancesOutputFile string
+	distancesModel      string
+)
+
+func init() {
+	distancesCmd.Flags().StringVarP(&distancesInputFile, "input", "i", "", "Input alignment file path in FASTA or PHYLIP format (required)")
+	distancesCmd.Flags().StringVarP(&distancesOutputFile, "output", "o", "", "Output file path for the distance matrix")
+	distancesCmd.Flags().StringVarP(&distancesModel, "model", "m", "jukes-cantor", "Distance model (hamming, p-distance, jukes-cantor, kimura)")
+	distancesCmd.MarkFlagRequired("input")
+
+	reconstructCmd.AddCommand(distancesCmd)
+}
+
+// Parses an alignment and computes the distance matrix between its sequences.
+// Returns the sequence names in matrix row order.
+func computeAlignmentDistances(fileContent string, modelName string) ([]string, [][]float64, error) {
+	model, err := algorithms.ParseDistanceModel(modelName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sequences, err := io.ParseAlignment(fileContent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing alignment: %v", err)
+	}
+
+	names := make([]string, len(sequences))
+	residues := make([]string, len(sequences))
+	for i, sequence := range sequences {
+		names[i] = sequence.Name
+		residues[i] = sequence.Residues
+	}
+
+	matrix, err := algorithms.SequenceDistanceMatrix(residues, model)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error computing distances: %v", err)
+	}
+
+	return names, matrix, nil
+}
+
+var distancesCmd = &cobra.Command{
+	Use:   "distances",
+	Short: "Compute a distance matrix from an alignment",
+	Long:  `Compute pairwise distances between the sequences of a FASTA or PHYLIP alignment and write them in the distance matrix CSV format, without reconstructing a tree.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fileContent, err := os.ReadFile(distancesInputFile)
+		if err != nil {
+			fmt.Printf("error reading file: %v\n", err)
+			return
+		}
+
+		names, matrix, err := computeAlignmentDistances(string(fileContent), distancesModel)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		matrixCSV := algorithms.FormatFloatDistanceMatrix(matrix)
+		if distancesOutputFile != "" {
+			if err := writeOutputFile(distancesOutputFile, matrixCSV); err != nil {
+				fmt.Printf("%v\n", err)
+				return
+			}
+		}
+
+		fmt.Printf("Rows: %s\n", strings.Join(names, ", "))
+		fmt.Printf("Distances:\n%s\n", matrixCSV)
+	},
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	probeMatrixFile              string
+	probeCommand                 string
+	probeTaxa                    int
+	probeOutputFile              string
+	probeSerializationTypeString string
+	probeMaxScale                int
+)
+
+func init() {
+	probeCmd.Flags().StringVarP(&probeMatrixFile, "input", "i", "", "Distance matrix file to answer queries from")
+	probeCmd.Flags().StringVarP(&probeCommand, "command", "c", "", "Command to run for each query; it is called with two taxon indices and must print their distance")
+	probeCmd.Flags().IntVarP(&probeTaxa, "taxa", "n", 0, "Number of taxa (required with --command)")
+	probeCmd.Flags().StringVarP(&probeOutputFile, "output", "o", "", "Output file path")
+	probeCmd.Flags().StringVarP(&probeSerializationTypeString, "serialization", "s", "newick", "Serialization type (brackets, brackets-shortened, neighbor-lists, neighbor-lists-shortened, newick, dot)")
+	probeCmd.Flags().IntVar(&probeMaxScale, "max-scale", 1000, "Largest factor edge weights may be scaled by to make them integral for integer serializations")
+
+	rootCmd.AddCommand(probeCmd)
+}
+
+// Oracle that runs a local command for every query, e.g. to measure a network distance
+type commandOracle struct {
+	command []string
+	taxa    int
+}
+
+func (o *commandOracle) Size() int {
+	return o.taxa
+}
+
+func (o *commandOracle) Distance(i int, j int) (float64, error) {
+	args := append(append([]string{}, o.command[1:]...), strconv.Itoa(i), strconv.Itoa(j))
+	output, err := exec.Command(o.command[0], args...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("query command failed for taxa %d and %d: %v", i, j, err)
+	}
+
+	distance, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil || distance < 0 {
+		return 0, fmt.Errorf("query command returned invalid distance for taxa %d and %d: %q", i, j, strings.TrimSpace(string(output)))
+	}
+
+	return distance, nil
+}
+
+func makeOracle() (algorithms.DistanceOracle, error) {
+	if (probeMatrixFile == "") == (probeCommand == "") {
+		return nil, fmt.Errorf("exactly one of --input and --command must be given")
+	}
+
+	if probeMatrixFile != "" {
+		content, err := os.ReadFile(probeMatrixFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %v", err)
+		}
+
+		matrix, err := io.ParseFloatMatrix(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing matrix: %v", err)
+		}
+
+		return &algorithms.MatrixOracle{Matrix: matrix}, nil
+	}
+
+	command := strings.Fields(probeCommand)
+	if len(command) == 0 {
+		return nil, fmt.Errorf("empty query command")
+	}
+	if probeTaxa < 2 {
+		return nil, fmt.Errorf("--taxa must be at least 2, got %d", probeTaxa)
+	}
+
+	return &commandOracle{command: command, taxa: probeTaxa}, nil
+}
+
+var probeCmd = &cobra.Command{
+	Use:   "probe",
+	Short: "Reconstruct a tree by querying distances lazily",
+	Long:  `Reconstruct a tree by querying only the distances needed to place each taxon, instead of reading a full matrix. Distances come from a matrix file or from a command run once per query, and the number of queries is reported.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serializationType, err := io.ParseSerializationType(probeSerializationTypeString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		oracle, err := makeOracle()
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		counting := algorithms.NewCountingOracle(oracle)
+		epsilon := 1e-10
+		tree, err := algorithms.ReconstructFromOracle(counting, epsilon)
+		if err != nil {
+			fmt.Printf("error reconstructing tree: %v\n", err)
+			return
+		}
+
+		scale := 1
+		if io.RequiresIntegerWeights(serializationType) {
+			scale, err = tree.IntegerScaleFactor(epsilon, probeMaxScale)
+			if err != nil {
+				fmt.Printf("error serializing tree: %v (use newick serialization for real-valued trees)\n", err)
+				return
+			}
+			tree.ScaleWeights(float64(scale))
+		}
+
+		serialized, err := io.SerializeGraph(tree, serializationType)
+		if err != nil {
+			fmt.Printf("error serializing tree: %v\n", err)
+			return
+		}
+
+		if probeOutputFile != "" {
+			if err := writeOutputFile(probeOutputFile, formatTreeFile(tree, scale, serialized, serializationType)); err != nil {
+				fmt.Printf("%v\n", err)
+				return
+			}
+		}
+
+		n := counting.Size()
+		pairs := n * (n - 1) / 2
+		fmt.Printf("Queries: %d of %d pairs (%.1f%%)\n", counting.Queries, pairs, float64(counting.Queries)/float64(pairs)*100)
+		if scale != 1 {
+			fmt.Printf("Edge weights scaled by %d to make them integral\n", scale)
+		}
+		if duplicates := io.FormatDuplicateGroups(tree); duplicates != "" {
+			fmt.Printf("Identical taxa (collapsed to the same vertex): %s\n", duplicates)
+		}
+		printSerializedTree(serializationType, serialized)
+	},
+}
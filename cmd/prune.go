@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneTreeFile                string
+	pruneOutputFile              string
+	pruneTaxa                    string
+	pruneSuppressUnary           bool
+	pruneSerializationTypeString string
+)
+
+type PruneOptions struct {
+	// Comma-separated IDs or labels of the taxa to keep
+	Taxa              string
+	SuppressUnary     bool
+	SerializationType io.SerializationType
+}
+
+type PruneResult struct {
+	Tree           *algorithms.Graph
+	SerializedTree string
+	Error          error
+}
+
+func init() {
+	pruneCmd.Flags().StringVarP(&pruneTreeFile, "input", "i", "", "Input tree file path (required)")
+	pruneCmd.Flags().StringVarP(&pruneOutputFile, "output", "o", "", "Output file path for the pruned tree")
+	pruneCmd.Flags().StringVarP(&pruneTaxa, "taxa", "t", "", "Comma-separated IDs or labels of the taxa to keep (required)")
+	pruneCmd.Flags().BoolVar(&pruneSuppressUnary, "suppress-unary", false, "Remove internal nodes left with two neighbors, joining their edges")
+	pruneCmd.Flags().StringVarP(&pruneSerializationTypeString, "serialization", "s", "newick", "Serialization type (brackets, brackets-shortened, neighbor-lists, neighbor-lists-shortened, newick, dot)")
+	pruneCmd.MarkFlagRequired("input")
+	pruneCmd.MarkFlagRequired("taxa")
+
+	rootCmd.AddCommand(pruneCmd)
+}
+
+func runPruneCommand(treeFilePath, outputFilePath string, options PruneOptions) PruneResult {
+	content, err := os.ReadFile(treeFilePath)
+	if err != nil {
+		return PruneResult{Error: fmt.Errorf("error reading file %s: %v", treeFilePath, err)}
+	}
+
+	tree, err := io.ParseTree(string(content))
+	if err != nil {
+		return PruneResult{Error: fmt.Errorf("error parsing tree from %s: %v", treeFilePath, err)}
+	}
+
+	scale, err := io.ParseScaleHeader(string(content))
+	if err != nil {
+		return PruneResult{Error: err}
+	}
+
+	var keep []int
+	for _, name := range strings.Split(options.Taxa, ",") {
+		taxon, err := tree.FindNode(strings.TrimSpace(name))
+		if err != nil {
+			return PruneResult{Error: fmt.Errorf("invalid taxon: %v", err)}
+		}
+		keep = append(keep, taxon)
+	}
+
+	pruned, err := algorithms.PruneToTaxa(tree, keep, options.SuppressUnary)
+	if err != nil {
+		return PruneResult{Error: fmt.Errorf("error pruning tree: %v", err)}
+	}
+
+	if io.RequiresIntegerWeights(options.SerializationType) && !pruned.IsIntegerWeighted(1e-6) {
+		return PruneResult{Error: fmt.Errorf("real-valued trees can only be serialized as newick or dot")}
+	}
+
+	// Node 0 may have been pruned, so the tree hangs from the smallest kept taxon instead
+	serialized, err := io.SerializeRootedGraph(pruned, options.SerializationType, pruned.TaxonNodes()[0])
+	if err != nil {
+		return PruneResult{Error: fmt.Errorf("error serializing tree: %v", err)}
+	}
+
+	if outputFilePath != "" {
+		if err := writeOutputFile(outputFilePath, formatTreeFile(pruned, scale, serialized, options.SerializationType)); err != nil {
+			return PruneResult{Error: err}
+		}
+	}
+
+	return PruneResult{Tree: pruned, SerializedTree: serialized}
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Restrict a tree to a subset of its taxa",
+	Long: `Remove all taxa of a tree except the given ones, along with the branches that only led to them.
+Taxa at internal nodes that are not kept remain as plain internal nodes. With --suppress-unary,
+internal nodes left with two neighbors are removed and their edges joined.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serializationType, err := io.ParseSerializationType(pruneSerializationTypeString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		result := runPruneCommand(pruneTreeFile, pruneOutputFile, PruneOptions{
+			Taxa:              pruneTaxa,
+			SuppressUnary:     pruneSuppressUnary,
+			SerializationType: serializationType,
+		})
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		for _, line := range io.GetTreeSummary(result.Tree) {
+			fmt.Printf("%s\n", line)
+		}
+		printSerializedTree(serializationType, result.SerializedTree)
+	},
+}
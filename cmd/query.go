@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"treereconstruction/algorithms"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryTreeFile string
+	queryRoot     string
+)
+
+type QueryOptions struct {
+	Root string
+}
+
+type QueryResult struct {
+	// One answer per query, or an "error: ..." line for a query that failed
+	Answers []string
+	Error   error
+}
+
+func init() {
+	queryCmd.Flags().StringVarP(&queryTreeFile, "input", "i", "", "Input tree file path (required)")
+	queryCmd.Flags().StringVar(&queryRoot, "root", "", "Root for lca queries: "+rootingUsage)
+	queryCmd.MarkFlagRequired("input")
+
+	rootCmd.AddCommand(queryCmd)
+}
+
+// Indices answering queries about a tree. Distances, paths and spans do not depend on the
+// root; LCA queries use a rooted copy of the tree, since rooting may insert a new node.
+type treeQueries struct {
+	tree        *algorithms.Graph
+	index       *algorithms.LCAIndex
+	rootedTree  *algorithms.Graph
+	rootedIndex *algorithms.LCAIndex
+}
+
+func newTreeQueries(tree *algorithms.Graph, root string) (*treeQueries, error) {
+	taxa := tree.TaxonNodes()
+	if len(taxa) == 0 {
+		return nil, fmt.Errorf("tree has no taxa")
+	}
+
+	index, err := algorithms.NewLCAIndex(tree, taxa[0])
+	if err != nil {
+		return nil, fmt.Errorf("error indexing tree: %v", err)
+	}
+	queries := &treeQueries{tree: tree, index: index}
+
+	if root != "" {
+		rootedTree, err := tree.RenumberNodes(nil)
+		if err != nil {
+			return nil, err
+		}
+		rooting, err := applyRooting(rootedTree, root)
+		if err != nil {
+			return nil, fmt.Errorf("error rooting tree: %v", err)
+		}
+		rootedIndex, err := algorithms.NewLCAIndex(rootedTree, rooting.Root)
+		if err != nil {
+			return nil, fmt.Errorf("error indexing rooted tree: %v", err)
+		}
+		queries.rootedTree, queries.rootedIndex = rootedTree, rootedIndex
+	}
+
+	return queries, nil
+}
+
+// Finds the tree nodes of the named nodes (IDs or labels); duplicate taxa are located at
+// their representative
+func (q *treeQueries) findNodes(names []string) ([]int, error) {
+	nodes := make([]int, len(names))
+	for i, name := range names {
+		node, err := q.tree.FindNode(name)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = q.tree.TaxonLocation(node)
+	}
+	return nodes, nil
+}
+
+// Answers a single query given as words, e.g. ["distance", "3", "7"]
+func (q *treeQueries) answer(words []string) (string, error) {
+	expectNodes := func(count int) ([]int, error) {
+		if len(words)-1 != count {
+			return nil, fmt.Errorf("%s expects %d nodes, got %d", words[0], count, len(words)-1)
+		}
+		return q.findNodes(words[1:])
+	}
+
+	switch words[0] {
+	case "distance":
+		nodes, err := expectNodes(2)
+		if err != nil {
+			return "", err
+		}
+		distance, err := q.index.Distance(nodes[0], nodes[1])
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(distance, 'g', 10, 64), nil
+	case "path":
+		nodes, err := expectNodes(2)
+		if err != nil {
+			return "", err
+		}
+		path, err := q.index.Path(nodes[0], nodes[1])
+		if err != nil {
+			return "", err
+		}
+		names := make([]string, len(path))
+		for i, node := range path {
+			names[i] = q.tree.NodeName(node)
+		}
+		return strings.Join(names, " "), nil
+	case "lca":
+		if q.rootedIndex == nil {
+			return "", fmt.Errorf("lca queries need a root (--root)")
+		}
+		nodes, err := expectNodes(2)
+		if err != nil {
+			return "", err
+		}
+		lca, err := q.rootedIndex.LCA(nodes[0], nodes[1])
+		if err != nil {
+			return "", err
+		}
+		return q.rootedTree.NodeName(lca), nil
+	case "span":
+		// Nodes may be separated by commas, spaces or both
+		names := strings.FieldsFunc(strings.Join(words[1:], ","), func(r rune) bool { return r == ',' })
+		if len(names) == 0 {
+			return "", fmt.Errorf("span expects at least one node")
+		}
+		nodes, err := q.findNodes(names)
+		if err != nil {
+			return "", err
+		}
+		span, err := q.index.Span(nodes)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(span, 'g', 10, 64), nil
+	default:
+		return "", fmt.Errorf("unknown query %q (expected distance, path, lca or span)", words[0])
+	}
+}
+
+// Answers the queries, each given as words. Failing queries are reported in place of their
+// answer so that answers stay aligned with the queries.
+func runQueryCommand(tree *algorithms.Graph, queries [][]string, options QueryOptions) QueryResult {
+	treeQueries, err := newTreeQueries(tree, options.Root)
+	if err != nil {
+		return QueryResult{Error: err}
+	}
+
+	answers := make([]string, len(queries))
+	for i, words := range queries {
+		answer, err := treeQueries.answer(words)
+		if err != nil {
+			answer = fmt.Sprintf("error: %v", err)
+		}
+		answers[i] = answer
+	}
+
+	return QueryResult{Answers: answers}
+}
+
+// Reads one query per line, skipping blank lines and '#' comments
+func readQueries(file *os.File) ([][]string, error) {
+	queries := make([][]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, strings.Fields(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading queries: %v", err)
+	}
+	return queries, nil
+}
+
+var queryCmd = &cobra.Command{
+	Use:   "query [distance a b | path a b | lca a b | span a,b,c,...]",
+	Short: "Answer distance, path, LCA and span queries about a tree",
+	Long: `Answer queries about a tree file, with nodes given by ID or label:
+
+  distance a b     weighted distance between two nodes
+  path a b         nodes on the path between two nodes
+  lca a b          lowest common ancestor of two nodes under the --root
+  span a,b,c,...   total edge length of the smallest subtree connecting the nodes
+
+A single query can be given as arguments. Without arguments, queries are read from stdin,
+one per line, and answered in order.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		tree, err := readTreeFile(queryTreeFile)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		queries := [][]string{args}
+		if len(args) == 0 {
+			queries, err = readQueries(os.Stdin)
+			if err != nil {
+				fmt.Printf("%v\n", err)
+				return
+			}
+		}
+
+		result := runQueryCommand(tree, queries, QueryOptions{Root: queryRoot})
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		for _, answer := range result.Answers {
+			fmt.Printf("%s\n", answer)
+		}
+	},
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"treereconstruction/algorithms"
 	"treereconstruction/io"
 
@@ -14,94 +16,533 @@ var (
 	inputFile               string
 	outputFile              string
 	serializationTypeString string
+	maxScale                int
+	realValued              bool
+	alignmentInput          bool
+	distanceModel           string
+	appendTreeFile          string
+	allowMissing            bool
+	reconstructRoot         string
+	reconstructRenumber     string
+	reconstructBlocks       []string
+	reconstructReplicates   int
+	reconstructNoise        string
+	reconstructNoiseScale   float64
+	reconstructSeed         int64
 )
 
+type ReconstructOptions struct {
+	SerializationType io.SerializationType
+	MaxScale          int
+	RealValued        bool
+	Alignment         bool
+	DistanceModel     string
+	AllowMissing      bool
+	Root              string
+	// Numbering policy for internal nodes, empty to keep the IDs from the reconstruction
+	Renumber string
+	// Files with labelled matrices over overlapping sets of taxa, read instead of the input
+	Blocks []string
+	// Number of perturbed replicates the support of the edges is estimated from, 0 for none
+	Replicates int
+	Noise      string
+	NoiseScale float64
+	Seed       int64
+}
+
 type ReconstructResult struct {
 	SerializedTree string
+	Scale          int
+	Completion     *algorithms.MatrixCompletion
+	InternalTaxa   []int
+	Duplicates     string
+	Rooting        *algorithms.Rooting
+	Stitching      *algorithms.BlockStitching
+	NoiseScale     float64
 	Error          error
 }
 
 func init() {
 	reconstructCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input file path (required)")
 	reconstructCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file path")
-	reconstructCmd.Flags().StringVarP(&serializationTypeString, "serialization", "s", "neighbor-lists", "Serialization type (brackets, brackets-shortened, neighbor-lists)")
-	reconstructCmd.MarkFlagRequired("input")
+	reconstructCmd.Flags().StringVarP(&serializationTypeString, "serialization", "s", "neighbor-lists", "Serialization type (brackets, brackets-shortened, neighbor-lists, neighbor-lists-shortened, newick, dot)")
+	reconstructCmd.Flags().IntVar(&maxScale, "max-scale", 1000, "Largest factor edge weights may be scaled by to make them integral (1 requires integer weights)")
+	reconstructCmd.Flags().BoolVarP(&realValued, "real", "r", false, "Accept real-valued distances and output branch lengths as-is (defaults to newick serialization)")
+	reconstructCmd.Flags().BoolVarP(&alignmentInput, "alignment", "a", false, "Input file is a FASTA or PHYLIP alignment instead of a distance matrix (implies --real)")
+	reconstructCmd.Flags().StringVarP(&distanceModel, "model", "m", "jukes-cantor", "Distance model for alignments (hamming, p-distance, jukes-cantor, kimura)")
+	reconstructCmd.Flags().StringVar(&appendTreeFile, "append", "", "Insert a new leaf into this tree file instead; the input file is then a row of distances from the new leaf to the current leaves")
+	reconstructCmd.Flags().StringVar(&reconstructRoot, "root", "", "Root the brackets or newick output: "+rootingUsage)
+	reconstructCmd.Flags().StringVar(&reconstructRenumber, "renumber", "", "Renumber internal nodes before serializing, given as --renumber=<policy> (contiguous if no policy is given): "+renumberingUsage)
+	reconstructCmd.Flags().Lookup("renumber").NoOptDefVal = "contiguous"
+	reconstructCmd.Flags().BoolVar(&allowMissing, "allow-missing", false, "Accept matrices with unmeasured ('?' or empty) entries and infer them from the tree metric constraints")
+	reconstructCmd.Flags().IntVar(&reconstructReplicates, "replicates", 0, "Annotate internal edges (newick or dot) with the fraction of this many perturbed replicates of the matrix whose tree has them")
+	reconstructCmd.Flags().StringVar(&reconstructNoise, "noise", "gaussian", "Noise model for replicates (gaussian, poisson)")
+	reconstructCmd.Flags().Float64Var(&reconstructNoiseScale, "noise-scale", 0, "Standard deviation of gaussian noise, or unit of poisson counts (0 scales it to the matrix, perturbing distances by about a tenth of the mean distance)")
+	reconstructCmd.Flags().Int64Var(&reconstructSeed, "seed", 1, "Random seed for replicates")
+	reconstructCmd.Flags().StringSliceVar(&reconstructBlocks, "blocks", nil, "Reconstruct from these files (comma-separated or repeated) instead of the input: matrices with labelled rows over overlapping sets of taxa")
 
 	rootCmd.AddCommand(reconstructCmd)
 }
 
-func runReconstructCommand(inputFilePath, outputFilePath string, serializationType io.SerializationType) ReconstructResult {
-	fileContent, err := os.ReadFile(inputFilePath)
+func defaultReconstructOptions(serializationType io.SerializationType) ReconstructOptions {
+	return ReconstructOptions{
+		SerializationType: serializationType,
+		MaxScale:          maxScale,
+	}
+}
+
+// Parses a matrix with missing entries and infers them. Fails if some remain undetermined.
+func completePartialMatrix(fileContent string, epsilon float64) ([][]float64, *algorithms.MatrixCompletion, error) {
+	matrix, err := io.ParsePartialMatrix(fileContent)
 	if err != nil {
-		return ReconstructResult{Error: fmt.Errorf("error reading file: %v", err)}
+		return nil, nil, fmt.Errorf("error parsing matrix: %v", err)
 	}
 
-	matrix, err := io.ParseMatrix(string(fileContent))
+	completion, err := algorithms.CompleteDistanceMatrix(matrix, epsilon)
+	if completion != nil && len(completion.Undetermined) > 0 {
+		return nil, completion, fmt.Errorf("%d missing distances could not be inferred: %s",
+			len(completion.Undetermined), formatPairs(completion.Undetermined, nil))
+	}
 	if err != nil {
-		return ReconstructResult{Error: fmt.Errorf("error parsing matrix: %v", err)}
+		return nil, nil, fmt.Errorf("error inferring missing distances: %v", err)
 	}
 
-	epsilon := 1e-10
-	tree, err := algorithms.ReconstructIntTree(matrix, epsilon)
+	return completion.Matrix, completion, nil
+}
+
+func formatPairs(pairs [][2]int, matrix [][]float64) string {
+	var formatted = make([]string, len(pairs))
+	for i, pair := range pairs {
+		if matrix != nil {
+			formatted[i] = fmt.Sprintf("d(%d,%d)=%g", pair[0], pair[1], matrix[pair[0]][pair[1]])
+		} else {
+			formatted[i] = fmt.Sprintf("d(%d,%d)", pair[0], pair[1])
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func reconstructPartialTree(fileContent string, options ReconstructOptions, epsilon float64) (*algorithms.Graph, int, *algorithms.MatrixCompletion, error) {
+	matrix, completion, err := completePartialMatrix(fileContent, epsilon)
 	if err != nil {
-		return ReconstructResult{Error: fmt.Errorf("error reconstructing tree: %v", err)}
+		return nil, 0, completion, err
+	}
+
+	if options.RealValued {
+		tree, err := algorithms.ReconstructRealTree(matrix, epsilon)
+		if err != nil {
+			return nil, 0, completion, fmt.Errorf("error reconstructing tree: %v", err)
+		}
+		return tree, 1, completion, nil
 	}
 
-	if !tree.IsIntegerWeighted(epsilon) {
-		return ReconstructResult{Error: fmt.Errorf("tree is not integer weighted")}
+	intMatrix := make([][]uint32, len(matrix))
+	for i := range matrix {
+		intMatrix[i] = make([]uint32, len(matrix[i]))
+		for j := range matrix[i] {
+			rounded := math.Round(matrix[i][j])
+			if math.Abs(matrix[i][j]-rounded) > epsilon {
+				return nil, 0, completion, fmt.Errorf("distance d(%d,%d)=%g is not an integer (use --real for real-valued distances)", i, j, matrix[i][j])
+			}
+			intMatrix[i][j] = uint32(rounded)
+		}
 	}
 
-	serialized, err := io.SerializeGraph(tree, serializationType)
+	tree, scale, err := algorithms.ReconstructScaledIntTree(intMatrix, epsilon, options.MaxScale)
 	if err != nil {
-		return ReconstructResult{Error: fmt.Errorf("error serializing tree: %v", err)}
+		return nil, 0, completion, fmt.Errorf("error reconstructing tree: %v", err)
 	}
 
-	if outputFilePath != "" {
-		if _, err := os.Stat(outputFilePath); err == nil {
-			os.Remove(outputFilePath)
+	return tree, scale, completion, nil
+}
+
+// Reads the labelled block matrices and stitches their trees together. Unless the options ask
+// for real-valued output, weights are scaled to integers like for a single matrix.
+func reconstructBlockTree(options ReconstructOptions, epsilon float64) (*algorithms.Graph, int, *algorithms.BlockStitching, error) {
+	var blocks = make([]algorithms.MatrixBlock, len(options.Blocks))
+	for i, path := range options.Blocks {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("error reading file %s: %v", path, err)
+		}
+		labels, matrix, err := io.ParseLabelledMatrix(string(content))
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("error parsing matrix %s: %v", path, err)
+		}
+		blocks[i] = algorithms.MatrixBlock{Labels: labels, Matrix: matrix}
+	}
+
+	stitching, err := algorithms.StitchBlocks(blocks, epsilon)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("error stitching blocks: %v", err)
+	}
+
+	var tree, scale = stitching.Tree, 1
+	if !options.RealValued {
+		scale, err = tree.IntegerScaleFactor(1e-9, options.MaxScale)
+		if err != nil {
+			return nil, 0, stitching, fmt.Errorf("error scaling stitched tree (use --real for real-valued distances): %v", err)
+		}
+		tree.ScaleWeights(float64(scale))
+	}
+
+	return tree, scale, stitching, nil
+}
+
+// Lists at most this many inconsistencies between blocks
+const maxReportedInconsistencies = 10
+
+// Formats a distance that the stitched tree does not realize, naming the block it was
+// measured in by its file
+func formatBlockInconsistency(inconsistency algorithms.BlockInconsistency, tree *algorithms.Graph, blockFiles []string) string {
+	return fmt.Sprintf("d(%s,%s)=%g in %s, but %g in the stitched tree", tree.NodeName(inconsistency.Taxon1), tree.NodeName(inconsistency.Taxon2),
+		inconsistency.Measured, blockFiles[inconsistency.Block], inconsistency.InTree)
+}
+
+// Reconstructs the tree of a matrix or alignment, also returning the distances it was
+// reconstructed from
+func reconstructTree(fileContent string, options ReconstructOptions, epsilon float64) (*algorithms.Graph, int, [][]float64, error) {
+	if options.Alignment {
+		names, matrix, err := computeAlignmentDistances(fileContent, options.DistanceModel)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		tree, err := algorithms.ReconstructRealTree(matrix, epsilon)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("error reconstructing tree: %v", err)
+		}
+
+		tree.Labels = make(map[int]string)
+		for i, name := range names {
+			tree.Labels[i] = name
+		}
+
+		return tree, 1, matrix, nil
+	}
+
+	if options.RealValued {
+		matrix, err := io.ParseFloatMatrix(fileContent)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("error parsing matrix: %v", err)
+		}
+
+		tree, err := algorithms.ReconstructRealTree(matrix, epsilon)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("error reconstructing tree: %v", err)
+		}
+
+		return tree, 1, matrix, nil
+	}
+
+	matrix, err := io.ParseMatrix(fileContent)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("error parsing matrix: %v", err)
+	}
+
+	tree, scale, err := algorithms.ReconstructScaledIntTree(matrix, epsilon, options.MaxScale)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("error reconstructing tree: %v", err)
+	}
+
+	var floatMatrix = make([][]float64, len(matrix))
+	for i, row := range matrix {
+		floatMatrix[i] = make([]float64, len(row))
+		for j, distance := range row {
+			floatMatrix[i][j] = float64(distance)
+		}
+	}
+
+	return tree, scale, floatMatrix, nil
+}
+
+func writeOutputFile(outputFilePath string, content string) error {
+	if _, err := os.Stat(outputFilePath); err == nil {
+		os.Remove(outputFilePath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFilePath), 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	if err := os.WriteFile(outputFilePath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error writing output file: %v", err)
+	}
+
+	return nil
+}
+
+// Roots the tree as requested by the options. A root inserted inside an edge may split
+// its weight into fractions, in which case integer weights are scaled up further.
+func rootTree(tree *algorithms.Graph, scale int, options ReconstructOptions) (*algorithms.Rooting, int, error) {
+	if options.SerializationType == io.SerializationTypeNeighborLists || options.SerializationType == io.SerializationTypeNeighborListsShortened {
+		return nil, 0, fmt.Errorf("neighbor lists cannot represent a root, use brackets or newick serialization")
+	}
+
+	rooting, err := applyRooting(tree, options.Root)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error rooting tree: %v", err)
+	}
+
+	if io.RequiresIntegerWeights(options.SerializationType) {
+		factor, err := tree.IntegerScaleFactor(1e-10, options.MaxScale/scale)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error rooting tree: %v", err)
+		}
+		if factor != 1 {
+			tree.ScaleWeights(float64(factor))
+			scale *= factor
 		}
+	}
+
+	return rooting, scale, nil
+}
 
-		if err := os.MkdirAll(filepath.Dir(outputFilePath), 0755); err != nil {
-			return ReconstructResult{Error: fmt.Errorf("error creating output directory: %v", err)}
+// Prepends the scale and duplicates headers (if needed) to a serialized tree. Newick and
+// DOT trees already show their duplicates as zero-length leaves or in node labels.
+func formatTreeFile(tree *algorithms.Graph, scale int, serialized string, serializationType io.SerializationType) string {
+	var content = serialized
+	if io.RequiresIntegerWeights(serializationType) {
+		content = io.FormatDuplicatesHeader(tree) + content
+	}
+	if scale != 1 {
+		content = io.FormatScaleHeader(scale) + content
+	}
+	return content
+}
+
+func runReconstructCommand(inputFilePath, outputFilePath string, options ReconstructOptions) ReconstructResult {
+	if options.RealValued && io.RequiresIntegerWeights(options.SerializationType) {
+		return ReconstructResult{Error: fmt.Errorf("real-valued trees can only be serialized as newick or dot")}
+	}
+
+	epsilon := 1e-10
+	var tree *algorithms.Graph
+	var scale int
+	var completion *algorithms.MatrixCompletion
+	var stitching *algorithms.BlockStitching
+	var matrix [][]float64
+	var err error
+	if options.Replicates > 0 && io.RequiresIntegerWeights(options.SerializationType) {
+		return ReconstructResult{Error: fmt.Errorf("support values can only be serialized as newick or dot")}
+	}
+	if len(options.Blocks) > 0 {
+		if options.Replicates > 0 {
+			return ReconstructResult{Error: fmt.Errorf("replicates cannot be combined with --blocks")}
 		}
+		if options.Alignment || options.AllowMissing {
+			return ReconstructResult{Error: fmt.Errorf("blocks cannot be combined with --alignment or --allow-missing")}
+		}
+		tree, scale, stitching, err = reconstructBlockTree(options, epsilon)
+	} else {
+		var fileContent []byte
+		fileContent, err = os.ReadFile(inputFilePath)
+		if err != nil {
+			return ReconstructResult{Error: fmt.Errorf("error reading file: %v", err)}
+		}
+
+		if options.AllowMissing && !options.Alignment {
+			tree, scale, completion, err = reconstructPartialTree(string(fileContent), options, epsilon)
+			if completion != nil {
+				matrix = completion.Matrix
+			}
+		} else {
+			tree, scale, matrix, err = reconstructTree(string(fileContent), options, epsilon)
+		}
+	}
+	if err != nil {
+		return ReconstructResult{Completion: completion, Stitching: stitching, Error: err}
+	}
 
-		err = os.WriteFile(outputFilePath, []byte(serialized), 0644)
+	if options.Replicates > 0 {
+		noise, err := algorithms.ParseNoiseModel(options.Noise)
+		if err != nil {
+			return ReconstructResult{Completion: completion, Error: err}
+		}
+		if options.NoiseScale == 0 {
+			options.NoiseScale = algorithms.DefaultNoiseScale(matrix, noise)
+		}
+		err = algorithms.ReplicateSupport(tree, matrix, algorithms.ReplicateOptions{
+			Replicates: options.Replicates,
+			Noise:      noise,
+			Scale:      options.NoiseScale,
+			Seed:       options.Seed,
+			Integral:   !options.RealValued,
+			Epsilon:    epsilon,
+		})
 		if err != nil {
-			return ReconstructResult{Error: fmt.Errorf("error writing output file: %v", err)}
+			return ReconstructResult{Completion: completion, Error: fmt.Errorf("error computing replicate support: %v", err)}
 		}
 	}
 
-	return ReconstructResult{SerializedTree: serialized, Error: nil}
+	var rooting = &algorithms.Rooting{Root: 0}
+	if options.Root != "" {
+		rooting, scale, err = rootTree(tree, scale, options)
+		if err != nil {
+			return ReconstructResult{Completion: completion, Stitching: stitching, Error: err}
+		}
+	}
+
+	if options.Renumber != "" {
+		var mapping map[int]int
+		tree, mapping, err = applyRenumbering(tree, options.Renumber, rooting.Root, options.SerializationType)
+		if err != nil {
+			return ReconstructResult{Completion: completion, Stitching: stitching, Error: err}
+		}
+
+		rooting.Root = renumberedNode(mapping, rooting.Root)
+		if edge := rooting.SplitEdge; edge != nil {
+			edge.Node1, edge.Node2 = renumberedNode(mapping, edge.Node1), renumberedNode(mapping, edge.Node2)
+		}
+	}
+
+	internalTaxa := tree.InternalTaxa()
+	duplicates := io.FormatDuplicateGroups(tree)
+	serialized, err := io.SerializeRootedGraph(tree, options.SerializationType, rooting.Root)
+	if err != nil {
+		return ReconstructResult{Completion: completion, Stitching: stitching, Error: fmt.Errorf("error serializing tree: %v", err)}
+	}
+
+	if outputFilePath != "" {
+		if err := writeOutputFile(outputFilePath, formatTreeFile(tree, scale, serialized, options.SerializationType)); err != nil {
+			return ReconstructResult{Completion: completion, Stitching: stitching, Error: err}
+		}
+	}
+
+	return ReconstructResult{SerializedTree: serialized, Scale: scale, Completion: completion, InternalTaxa: internalTaxa, Duplicates: duplicates, Rooting: rooting, Stitching: stitching, NoiseScale: options.NoiseScale, Error: nil}
 }
 
 var reconstructCmd = &cobra.Command{
 	Use:   "reconstruct",
 	Short: "Reconstruct a tree",
-	Long:  `Reconstruct a tree from distance matrix`,
+	// --renumber takes its policy after '=', so '--renumber bfs' would leave 'bfs' behind
+	Args: cobra.NoArgs,
+	Long: `Reconstruct a tree from distance matrix, or from a sequence alignment (see --alignment). With --blocks,
+the tree is stitched together from matrices over overlapping sets of taxa, whose rows start with
+the taxon labels: each block is reconstructed, the taxa of the other blocks are attached to the
+tree of the first one through the taxa they share, and distances measured in a block that the
+stitched tree does not realize are reported as inconsistencies between blocks. With --replicates,
+the matrix is perturbed that many times with seeded noise, each replicate is reconstructed in
+parallel, and internal edges are annotated with the fraction of replicates whose tree has them.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var serializationType io.SerializationType
-		switch serializationTypeString {
-		case "brackets":
-			serializationType = io.SerializationTypeBrackets
-		case "brackets-shortened":
-			serializationType = io.SerializationTypeBracketsShortened
-		case "neighbor-lists":
-			serializationType = io.SerializationTypeNeighborLists
-		default:
-			fmt.Printf("Invalid serialization type: %s\n", serializationTypeString)
+		if inputFile == "" && len(reconstructBlocks) == 0 {
+			fmt.Printf("either --input or --blocks is required\n")
+			return
+		}
+
+		if alignmentInput {
+			realValued = true
+		}
+
+		if realValued && !cmd.Flags().Changed("serialization") {
+			serializationTypeString = "newick"
+		}
+
+		serializationType, err := io.ParseSerializationType(serializationTypeString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		if appendTreeFile != "" {
+			if reconstructReplicates > 0 {
+				fmt.Printf("replicates cannot be combined with --append\n")
+				return
+			}
+			result := runAppendCommand(appendTreeFile, inputFile, outputFile, serializationType, maxScale)
+			if result.Error != nil {
+				fmt.Printf("%v\n", result.Error)
+				return
+			}
+
+			insertion := result.Insertion
+			if insertion.Duplicate {
+				fmt.Printf("New taxon %d is identical to taxon %d\n", insertion.Leaf, insertion.AttachedTo)
+			} else if insertion.PendantLength == 0 {
+				fmt.Printf("New taxon lies on the tree: it is internal node %d\n", insertion.Leaf)
+			} else if insertion.SplitEdge != nil {
+				fmt.Printf("Inserted leaf %d at new node %d on edge %d-%d (pendant length %g)\n",
+					insertion.Leaf, insertion.AttachedTo, insertion.SplitEdge.Node1, insertion.SplitEdge.Node2, insertion.PendantLength)
+			} else {
+				fmt.Printf("Inserted leaf %d at node %d (pendant length %g)\n", insertion.Leaf, insertion.AttachedTo, insertion.PendantLength)
+			}
+			if result.Scale != 1 {
+				fmt.Printf("Edge weights scaled by %d to make them integral\n", result.Scale)
+			}
+			printSerializedTree(serializationType, result.SerializedTree)
 			return
 		}
 
-		result := runReconstructCommand(inputFile, outputFile, serializationType)
+		options := defaultReconstructOptions(serializationType)
+		options.RealValued = realValued
+		options.Alignment = alignmentInput
+		options.DistanceModel = distanceModel
+		options.AllowMissing = allowMissing
+		options.Root = reconstructRoot
+		options.Renumber = reconstructRenumber
+		options.Blocks = reconstructBlocks
+		options.Replicates = reconstructReplicates
+		options.Noise = reconstructNoise
+		options.NoiseScale = reconstructNoiseScale
+		options.Seed = reconstructSeed
+
+		result := runReconstructCommand(inputFile, outputFile, options)
+		if result.Completion != nil && len(result.Completion.Inferred) > 0 {
+			fmt.Printf("Inferred %d missing distances: %s\n", len(result.Completion.Inferred), formatPairs(result.Completion.Inferred, result.Completion.Matrix))
+		}
+
 		if result.Error != nil {
 			fmt.Printf("%v\n", result.Error)
 			return
 		}
 
-		if serializationType == io.SerializationTypeNeighborLists {
-			fmt.Printf("Tree:\n%v\n", result.SerializedTree)
-		} else {
-			fmt.Printf("Tree: %v\n", result.SerializedTree)
+		if stitching := result.Stitching; stitching != nil {
+			var order = make([]string, len(stitching.Order))
+			for i, block := range stitching.Order {
+				order[i] = reconstructBlocks[block]
+			}
+			fmt.Printf("Stitched %d blocks (in order %s) into a tree with %d taxa\n", len(order), strings.Join(order, ", "), len(stitching.Tree.AllTaxa()))
+			if len(stitching.Inconsistencies) > 0 {
+				fmt.Printf("✗ %d measured distances are not realized by the stitched tree:\n", len(stitching.Inconsistencies))
+				for i, inconsistency := range stitching.Inconsistencies {
+					if i == maxReportedInconsistencies {
+						fmt.Printf("  ...\n")
+						break
+					}
+					fmt.Printf("  %s\n", formatBlockInconsistency(inconsistency, stitching.Tree, reconstructBlocks))
+				}
+			}
 		}
+
+		if reconstructReplicates > 0 {
+			fmt.Printf("Edge support from %d replicates with %s noise (scale %.4g, seed %d)\n", reconstructReplicates, reconstructNoise, result.NoiseScale, reconstructSeed)
+		}
+
+		if result.Scale != 1 {
+			fmt.Printf("Edge weights scaled by %d to make them integral\n", result.Scale)
+		}
+
+		if result.Duplicates != "" {
+			fmt.Printf("Identical taxa (collapsed to the same vertex): %s\n", result.Duplicates)
+		}
+
+		if result.Rooting != nil && reconstructRoot != "" {
+			fmt.Printf("%s\n", formatRooting(result.Rooting))
+		}
+
+		if len(result.InternalTaxa) > 0 {
+			fmt.Printf("Internal taxa (lying on paths between other taxa): %v\n", result.InternalTaxa)
+		}
+
+		printSerializedTree(serializationType, result.SerializedTree)
 	},
 }
+
+func printSerializedTree(serializationType io.SerializationType, serializedTree string) {
+	switch serializationType {
+	case io.SerializationTypeNeighborLists, io.SerializationTypeNeighborListsShortened, io.SerializationTypeDot:
+		fmt.Printf("Tree:\n%v\n", serializedTree)
+	default:
+		fmt.Printf("Tree: %v\n", serializedTree)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+)
+
+const renumberingUsage = "contiguous (internal nodes after the taxa, in their current order), bfs (breadth-first from the root) or canonical (shape-based, stable across runs)"
+
+// Renumbers the internal nodes of the tree as requested by a --renumber value, and returns
+// the renumbered tree with the mapping from old to new IDs. Neighbor lists spell out weighted edges
+// as chains of new nodes, so for them the edges are split first and the chains numbered too.
+func applyRenumbering(tree *algorithms.Graph, policyName string, root int, serializationType io.SerializationType) (*algorithms.Graph, map[int]int, error) {
+	policy, err := algorithms.ParseNumberingPolicy(policyName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if serializationType == io.SerializationTypeNeighborLists {
+		if err := tree.SplitEdges(1e-6); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	mapping, err := algorithms.InternalNodeNumbering(tree, policy, root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error renumbering tree: %v", err)
+	}
+	renumbered, err := tree.RenumberNodes(mapping)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error renumbering tree: %v", err)
+	}
+
+	return renumbered, mapping, nil
+}
+
+// Returns the new ID of a node, which is unchanged if the node is not in the mapping
+func renumberedNode(mapping map[int]int, node int) int {
+	if newNode, ok := mapping[node]; ok {
+		return newNode
+	}
+	return node
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"treereconstruction/algorithms"
+)
+
+const rootingUsage = "midpoint, min-variance, outgroup:<taxa> (comma-separated IDs or labels), or a node ID or label"
+
+// Roots the tree as requested by a --root value: midpoint, min-variance,
+// outgroup:<taxa> or a node (ID or label)
+func applyRooting(tree *algorithms.Graph, spec string) (*algorithms.Rooting, error) {
+	epsilon := 1e-10
+	switch {
+	case spec == "midpoint":
+		return algorithms.MidpointRoot(tree, epsilon)
+	case spec == "min-variance":
+		return algorithms.MinVarianceRoot(tree, epsilon)
+	case strings.HasPrefix(spec, "outgroup:"):
+		var outgroup []int
+		for _, name := range strings.Split(strings.TrimPrefix(spec, "outgroup:"), ",") {
+			taxon, err := tree.FindNode(strings.TrimSpace(name))
+			if err != nil {
+				return nil, fmt.Errorf("invalid outgroup: %v", err)
+			}
+			outgroup = append(outgroup, taxon)
+		}
+		return algorithms.OutgroupRoot(tree, outgroup, epsilon)
+	default:
+		node, err := tree.FindNode(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %q (expected %s): %v", spec, rootingUsage, err)
+		}
+		return &algorithms.Rooting{Root: tree.TaxonLocation(node)}, nil
+	}
+}
+
+func formatRooting(rooting *algorithms.Rooting) string {
+	if rooting.SplitEdge != nil {
+		return fmt.Sprintf("Rooted at new node %d on edge %d-%d", rooting.Root, rooting.SplitEdge.Node1, rooting.SplitEdge.Node2)
+	}
+	return fmt.Sprintf("Rooted at node %d", rooting.Root)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	simplifyTreeFile                string
+	simplifyOutputFile              string
+	simplifyRenumber                string
+	simplifySerializationTypeString string
+)
+
+type SimplifyOptions struct {
+	// Numbering policy for internal nodes, empty to keep their IDs
+	Renumber          string
+	SerializationType io.SerializationType
+}
+
+type SimplifyResult struct {
+	Tree           *algorithms.Graph
+	SerializedTree string
+	// Number of degree-2 nodes that were merged into weighted edges
+	Contracted int
+	Error      error
+}
+
+func init() {
+	simplifyCmd.Flags().StringVarP(&simplifyTreeFile, "input", "i", "", "Input tree file path (required)")
+	simplifyCmd.Flags().StringVarP(&simplifyOutputFile, "output", "o", "", "Output file path for the simplified tree")
+	simplifyCmd.Flags().StringVar(&simplifyRenumber, "renumber", "", "Renumber internal nodes, given as --renumber=<policy> (contiguous if no policy is given): "+renumberingUsage)
+	simplifyCmd.Flags().Lookup("renumber").NoOptDefVal = "contiguous"
+	simplifyCmd.Flags().StringVarP(&simplifySerializationTypeString, "serialization", "s", "neighbor-lists-shortened", "Serialization type (brackets, brackets-shortened, neighbor-lists, neighbor-lists-shortened, newick, dot)")
+	simplifyCmd.MarkFlagRequired("input")
+
+	rootCmd.AddCommand(simplifyCmd)
+}
+
+func runSimplifyCommand(treeFilePath, outputFilePath string, options SimplifyOptions) SimplifyResult {
+	content, err := os.ReadFile(treeFilePath)
+	if err != nil {
+		return SimplifyResult{Error: fmt.Errorf("error reading file %s: %v", treeFilePath, err)}
+	}
+
+	tree, err := io.ParseTree(string(content))
+	if err != nil {
+		return SimplifyResult{Error: fmt.Errorf("error parsing tree from %s: %v", treeFilePath, err)}
+	}
+
+	scale, err := io.ParseScaleHeader(string(content))
+	if err != nil {
+		return SimplifyResult{Error: err}
+	}
+
+	nodeCount := len(tree.Nodes)
+	if err := tree.ContractUnaryNodes(); err != nil {
+		return SimplifyResult{Error: fmt.Errorf("error contracting tree: %v", err)}
+	}
+	contracted := nodeCount - len(tree.Nodes)
+
+	root := tree.TaxonNodes()[0]
+	if options.Renumber != "" {
+		tree, _, err = applyRenumbering(tree, options.Renumber, root, options.SerializationType)
+		if err != nil {
+			return SimplifyResult{Error: err}
+		}
+	}
+
+	if io.RequiresIntegerWeights(options.SerializationType) && !tree.IsIntegerWeighted(1e-6) {
+		return SimplifyResult{Error: fmt.Errorf("real-valued trees can only be serialized as newick or dot")}
+	}
+
+	serialized, err := io.SerializeRootedGraph(tree, options.SerializationType, root)
+	if err != nil {
+		return SimplifyResult{Error: fmt.Errorf("error serializing tree: %v", err)}
+	}
+
+	if outputFilePath != "" {
+		if err := writeOutputFile(outputFilePath, formatTreeFile(tree, scale, serialized, options.SerializationType)); err != nil {
+			return SimplifyResult{Error: err}
+		}
+	}
+
+	return SimplifyResult{Tree: tree, SerializedTree: serialized, Contracted: contracted}
+}
+
+var simplifyCmd = &cobra.Command{
+	Use:   "simplify",
+	Short: "Merge chains of degree-2 nodes into weighted edges",
+	// --renumber takes its policy after '=', so '--renumber bfs' would leave 'bfs' behind
+	Args: cobra.NoArgs,
+	Long: `Merge every chain of degree-2 nodes of a tree into a single edge whose length is the length of the chain,
+the inverse of spelling out weighted edges as unit-length paths. By default the result is written as
+neighbor lists with edge lengths ('node:neighbor[length],...;').`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serializationType, err := io.ParseSerializationType(simplifySerializationTypeString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		result := runSimplifyCommand(simplifyTreeFile, simplifyOutputFile, SimplifyOptions{
+			Renumber:          simplifyRenumber,
+			SerializationType: serializationType,
+		})
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		fmt.Printf("Contracted %d degree-2 nodes\n", result.Contracted)
+		for _, line := range io.GetTreeSummary(result.Tree) {
+			fmt.Printf("%s\n", line)
+		}
+		printSerializedTree(serializationType, result.SerializedTree)
+	},
+}
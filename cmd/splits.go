@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	splitsInputFile  string
+	splitsOutputFile string
+	splitsDotFile    string
+)
+
+type SplitsResult struct {
+	Splits []algorithms.Split
+	// Indices of the splits each split is incompatible with
+	Conflicts [][]int
+	Fit       float64
+	TaxaCount int
+	Network   string
+	Error     error
+}
+
+func init() {
+	splitsCmd.Flags().StringVarP(&splitsInputFile, "input", "i", "", "Input distance matrix file path (required)")
+	splitsCmd.Flags().StringVarP(&splitsOutputFile, "output", "o", "", "Output file path for the list of splits")
+	splitsCmd.Flags().StringVarP(&splitsDotFile, "dot", "d", "", "Output file path for the split network in DOT format (printed if not given)")
+	splitsCmd.MarkFlagRequired("input")
+
+	rootCmd.AddCommand(splitsCmd)
+}
+
+func runSplitsCommand(inputFilePath string) SplitsResult {
+	fileContent, err := os.ReadFile(inputFilePath)
+	if err != nil {
+		return SplitsResult{Error: fmt.Errorf("error reading file: %v", err)}
+	}
+
+	matrix, err := io.ParseFloatMatrix(string(fileContent))
+	if err != nil {
+		return SplitsResult{Error: fmt.Errorf("error parsing matrix: %v", err)}
+	}
+
+	splits, err := algorithms.SplitDecomposition(matrix, 1e-10)
+	if err != nil {
+		return SplitsResult{Error: fmt.Errorf("error decomposing matrix: %v", err)}
+	}
+
+	n := len(matrix)
+	conflicts := make([][]int, len(splits))
+	for i := range splits {
+		conflicts[i] = make([]int, 0)
+		for j := range splits {
+			if i != j && !splits[i].CompatibleWith(&splits[j], n) {
+				conflicts[i] = append(conflicts[i], j)
+			}
+		}
+	}
+
+	network := algorithms.BuildSplitNetwork(splits, n)
+	edgeLabels := make(map[[2]int]string)
+	for edge, split := range network.EdgeSplits {
+		edgeLabels[edge] = fmt.Sprintf("S%d: %s", split+1, io.FormatBranchLength(splits[split].Weight))
+	}
+
+	return SplitsResult{
+		Splits:    splits,
+		Conflicts: conflicts,
+		Fit:       algorithms.SplitFit(matrix, splits),
+		TaxaCount: n,
+		Network:   io.SerializeAsDot(network.Graph, edgeLabels),
+	}
+}
+
+// Formats the splits one per line as 'S<number>  <weight>  <side> | <other side>',
+// followed by the splits each conflicts with
+func formatSplits(splits []algorithms.Split, conflicts [][]int, n int) string {
+	var lines = make([]string, len(splits))
+	for i, split := range splits {
+		var side, rest = make([]string, 0), make([]string, 0)
+		for taxon := 0; taxon < n; taxon++ {
+			if split.Contains(taxon) {
+				side = append(side, strconv.Itoa(taxon))
+			} else {
+				rest = append(rest, strconv.Itoa(taxon))
+			}
+		}
+
+		lines[i] = fmt.Sprintf("S%d  %s  %s | %s", i+1, io.FormatBranchLength(split.Weight), strings.Join(side, ","), strings.Join(rest, ","))
+		if len(conflicts[i]) > 0 {
+			var names = make([]string, len(conflicts[i]))
+			for j, other := range conflicts[i] {
+				names[j] = fmt.Sprintf("S%d", other+1)
+			}
+			lines[i] += "  (conflicts with " + strings.Join(names, ", ") + ")"
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+var splitsCmd = &cobra.Command{
+	Use:   "splits",
+	Short: "Compute the split decomposition of a distance matrix",
+	Long: `Compute the weighted splits (bipartitions of the taxa) of a distance matrix with split decomposition,
+and draw them as a split network in DOT format. Compatible splits form a tree; conflicting splits
+show up as boxes in the network, where the matrix is not tree-like.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		result := runSplitsCommand(splitsInputFile)
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		compatible := true
+		for _, conflicts := range result.Conflicts {
+			compatible = compatible && len(conflicts) == 0
+		}
+
+		splits := formatSplits(result.Splits, result.Conflicts, result.TaxaCount)
+		fmt.Printf("%d splits, explaining %.1f%% of the distances", len(result.Splits), result.Fit*100)
+		if compatible {
+			fmt.Printf(" (all compatible: the splits form a tree)")
+		}
+		fmt.Printf("\n%s\n", splits)
+
+		if splitsOutputFile != "" {
+			if err := writeOutputFile(splitsOutputFile, splits+"\n"); err != nil {
+				fmt.Printf("%v\n", err)
+				return
+			}
+		}
+
+		if splitsDotFile != "" {
+			if err := writeOutputFile(splitsDotFile, result.Network); err != nil {
+				fmt.Printf("%v\n", err)
+			}
+		} else {
+			fmt.Printf("Network:\n%s", result.Network)
+		}
+	},
+}
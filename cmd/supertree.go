@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	supertreeOutputFile              string
+	supertreeOutgroup                string
+	supertreeSerializationTypeString string
+)
+
+type SupertreeOptions struct {
+	// ID or label of the taxon to root all trees at, or empty for the first taxon in all trees
+	Outgroup          string
+	SerializationType io.SerializationType
+}
+
+type SupertreeResult struct {
+	Supertree      *algorithms.Supertree
+	SerializedTree string
+	// Descriptions of the splits of the input trees that the supertree does not display
+	Conflicts []string
+	Error     error
+}
+
+func init() {
+	supertreeCmd.Flags().StringVarP(&supertreeOutputFile, "output", "o", "", "Output file path for the supertree")
+	supertreeCmd.Flags().StringVar(&supertreeOutgroup, "outgroup", "", "ID or label of a taxon in all trees to root them at (defaults to the first such taxon)")
+	supertreeCmd.Flags().StringVarP(&supertreeSerializationTypeString, "serialization", "s", "newick", "Serialization type (brackets, brackets-shortened, neighbor-lists, neighbor-lists-shortened, newick, dot)")
+
+	rootCmd.AddCommand(supertreeCmd)
+}
+
+func runSupertreeCommand(treeFilePaths []string, outputFilePath string, options SupertreeOptions) SupertreeResult {
+	var trees = make([]*algorithms.Graph, 0, len(treeFilePaths))
+	for _, path := range treeFilePaths {
+		tree, err := readTreeFile(path)
+		if err != nil {
+			return SupertreeResult{Error: err}
+		}
+		trees = append(trees, tree)
+	}
+
+	supertree, err := algorithms.BuildSupertree(trees, options.Outgroup)
+	if err != nil {
+		return SupertreeResult{Error: fmt.Errorf("error building supertree: %v", err)}
+	}
+
+	var conflicts = make([]string, len(supertree.Conflicts))
+	for i, conflict := range supertree.Conflicts {
+		conflicts[i] = io.DescribeSupertreeConflict(conflict, supertree.Tree, treeFilePaths[conflict.Tree])
+	}
+
+	serialized, err := io.SerializeRootedGraph(supertree.Tree, options.SerializationType, supertree.Outgroup)
+	if err != nil {
+		return SupertreeResult{Error: fmt.Errorf("error serializing tree: %v", err)}
+	}
+
+	if outputFilePath != "" {
+		if err := writeOutputFile(outputFilePath, formatTreeFile(supertree.Tree, 1, serialized, options.SerializationType)); err != nil {
+			return SupertreeResult{Error: err}
+		}
+	}
+
+	return SupertreeResult{Supertree: supertree, SerializedTree: serialized, Conflicts: conflicts}
+}
+
+var supertreeCmd = &cobra.Command{
+	Use:   "supertree <tree-file>...",
+	Short: "Merge trees on overlapping sets of taxa into one tree",
+	Long: `Merge trees whose taxa overlap into a supertree with all their taxa. Taxa are matched by label (or ID
+for unlabelled taxa); if any taxon is labelled, the supertree numbers taxa in order of name. All
+trees are rooted at an outgroup taxon they share and combined with the BUILD algorithm, which
+finds a tree displaying all of them if there is one. Otherwise the trees are incompatible: BUILD
+falls back to minimum cuts (MinCutSupertree), and the splits of the input trees the supertree
+does not display are listed as conflicts. The supertree only has a shape: all edges have length 1.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		serializationType, err := io.ParseSerializationType(supertreeSerializationTypeString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		result := runSupertreeCommand(args, supertreeOutputFile, SupertreeOptions{
+			Outgroup:          supertreeOutgroup,
+			SerializationType: serializationType,
+		})
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		var supertree = result.Supertree
+		fmt.Printf("Supertree of %d trees, rooted at %s\n", len(args), supertree.Tree.NodeName(supertree.Outgroup))
+		if !supertree.UsedMinCut && len(result.Conflicts) == 0 {
+			fmt.Printf("✓ The trees are compatible: the supertree displays all of them\n")
+		} else {
+			var splits = "splits are"
+			if len(result.Conflicts) == 1 {
+				splits = "split is"
+			}
+			fmt.Printf("✗ The trees are incompatible: %d %s not in the supertree\n", len(result.Conflicts), splits)
+			for _, conflict := range result.Conflicts {
+				fmt.Printf("  %s\n", conflict)
+			}
+		}
+		for _, line := range io.GetTreeSummary(supertree.Tree) {
+			fmt.Printf("%s\n", line)
+		}
+		printSerializedTree(serializationType, result.SerializedTree)
+	},
+}
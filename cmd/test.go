@@ -125,7 +125,7 @@ func runSingleTest(inputFile string) TestResult {
 	outputFile := filepath.Join(tmpDir, fmt.Sprintf("test_output_%d.txt", time.Now().UnixNano()))
 	result.OutputFile = outputFile
 
-	reconstructResult := runReconstructCommand(inputFile, outputFile, io.SerializationTypeNeighborLists)
+	reconstructResult := runReconstructCommand(inputFile, outputFile, defaultReconstructOptions(io.SerializationTypeNeighborLists))
 
 	if reconstructResult.Error != nil {
 		result.Status = TestError
@@ -137,7 +137,7 @@ func runSingleTest(inputFile string) TestResult {
 	}
 
 	// Compare results using the extracted function
-	compareResult := runCompareCommand(outputFile, expectedFile)
+	compareResult := runCompareCommand(outputFile, expectedFile, CompareOptions{})
 	if compareResult.Error != nil {
 		result.Status = TestError
 		result.Error = fmt.Sprintf("Comparison failed: %v", compareResult.Error)
@@ -181,6 +181,10 @@ func printTestResult(result TestResult) {
 			for _, line := range result.ComparisonDetails.Tree2Summary {
 				fmt.Printf("    %s\n", line)
 			}
+			fmt.Printf("  Differences (first: generated, second: expected):\n")
+			for _, line := range result.ComparisonDetails.Differences {
+				fmt.Printf("    %s\n", line)
+			}
 		}
 	case TestSkipped:
 		fmt.Printf("- [%s] %s - %s\n", status, inputName, result.Error)
@@ -25,7 +25,7 @@ var (
 
 func init() {
 	timeCmd.Flags().StringVarP(&timeOutputFile, "output", "o", "", "Output file to save reconstruction times (required)")
-	timeCmd.Flags().StringVarP(&timeSerializationTypeString, "serialization", "s", "neighbor-lists", "Serialization type (brackets, brackets-shortened, neighbor-lists)")
+	timeCmd.Flags().StringVarP(&timeSerializationTypeString, "serialization", "s", "neighbor-lists", "Serialization type (brackets, brackets-shortened, neighbor-lists, neighbor-lists-shortened, newick, dot)")
 	timeCmd.MarkFlagRequired("output")
 
 	rootCmd.AddCommand(timeCmd)
@@ -44,16 +44,9 @@ var timeCmd = &cobra.Command{
 		}
 
 		// Parse serialization type
-		var serializationType io.SerializationType
-		switch timeSerializationTypeString {
-		case "brackets":
-			serializationType = io.SerializationTypeBrackets
-		case "brackets-shortened":
-			serializationType = io.SerializationTypeBracketsShortened
-		case "neighbor-lists":
-			serializationType = io.SerializationTypeNeighborLists
-		default:
-			fmt.Printf("Invalid serialization type: %s\n", timeSerializationTypeString)
+		serializationType, err := io.ParseSerializationType(timeSerializationTypeString)
+		if err != nil {
+			fmt.Printf("%v\n", err)
 			return
 		}
 
@@ -97,7 +90,7 @@ func runTimingTest(inputFile string, serializationType io.SerializationType) Tim
 	outputFile := filepath.Join(tmpDir, fmt.Sprintf("time_output_%d.txt", time.Now().UnixNano()))
 
 	start := time.Now()
-	reconstructResult := runReconstructCommand(inputFile, outputFile, serializationType)
+	reconstructResult := runReconstructCommand(inputFile, outputFile, defaultReconstructOptions(serializationType))
 	result.Duration = time.Since(start)
 
 	if reconstructResult.Error != nil {
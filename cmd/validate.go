@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"treereconstruction/algorithms"
+	"treereconstruction/io"
+
+	"github.com/spf13/cobra"
+)
+
+var validateInputFile string
+
+type ValidateResult struct {
+	Validation *algorithms.MatrixValidation
+	Error      error
+}
+
+func init() {
+	validateCmd.Flags().StringVarP(&validateInputFile, "input", "i", "", "Input distance matrix file path (required)")
+	validateCmd.MarkFlagRequired("input")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidateCommand(inputFilePath string) ValidateResult {
+	fileContent, err := os.ReadFile(inputFilePath)
+	if err != nil {
+		return ValidateResult{Error: fmt.Errorf("error reading file: %v", err)}
+	}
+
+	matrix, err := io.ParseUncheckedFloatMatrix(string(fileContent))
+	if err != nil {
+		return ValidateResult{Error: fmt.Errorf("error parsing matrix: %v", err)}
+	}
+
+	return ValidateResult{Validation: algorithms.ValidateDistanceMatrix(matrix, 1e-10)}
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a distance matrix",
+	Long:  `Check that a distance matrix is square, symmetric and non-negative with a zero diagonal, and that it can be realized by a tree. Identical taxa are reported as warnings.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		result := runValidateCommand(validateInputFile)
+		if result.Error != nil {
+			fmt.Printf("%v\n", result.Error)
+			return
+		}
+
+		validation := result.Validation
+		if len(validation.Errors) == 0 {
+			fmt.Printf("✓ Matrix can be realized by a tree\n")
+		} else {
+			fmt.Printf("✗ Matrix cannot be realized by a tree\n")
+			for _, message := range validation.Errors {
+				fmt.Printf("  %s\n", message)
+			}
+		}
+
+		for _, message := range validation.Warnings {
+			fmt.Printf("  Warning: %s\n", message)
+		}
+	},
+}
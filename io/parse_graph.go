@@ -7,7 +7,9 @@ import (
 	"treereconstruction/algorithms"
 )
 
-// Parses a neighbor list format string into a Graph structure
+// Parses a neighbor list format string into a Graph structure.
+// Lines starting with '#' are treated as comments (e.g. the scale header).
+// Node IDs followed by '*' (e.g. '4*:1,2,3;') mark internal nodes that are taxa.
 func ParseNeighborList(content string) (*algorithms.Graph, error) {
 	graph := &algorithms.Graph{
 		Nodes:    make(map[int]struct{}),
@@ -17,10 +19,11 @@ func ParseNeighborList(content string) (*algorithms.Graph, error) {
 	}
 
 	lines := strings.Split(strings.TrimSpace(content), "\n")
+	internalTaxa := make([]int, 0)
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if line == "" {
+		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
@@ -35,26 +38,31 @@ func ParseNeighborList(content string) (*algorithms.Graph, error) {
 		}
 
 		nodeStr := strings.TrimSpace(parts[0])
+		isInternalTaxon := strings.HasSuffix(nodeStr, "*")
+		nodeStr = strings.TrimSuffix(nodeStr, "*")
 		node, err := strconv.Atoi(nodeStr)
 		if err != nil {
 			return nil, fmt.Errorf("invalid node ID: %s", nodeStr)
 		}
 
 		graph.AddNode(node)
+		if isInternalTaxon {
+			internalTaxa = append(internalTaxa, node)
+		}
 	}
 
-	addedEdges := make(map[string]bool)
+	addedEdges := make(map[string]int)
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if line == "" {
+		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
 		line = strings.TrimSuffix(line, ";")
 		parts := strings.Split(line, ":")
 
-		nodeStr := strings.TrimSpace(parts[0])
+		nodeStr := strings.TrimSuffix(strings.TrimSpace(parts[0]), "*")
 		node, _ := strconv.Atoi(nodeStr)
 
 		neighborStr := strings.TrimSpace(parts[1])
@@ -65,6 +73,17 @@ func ParseNeighborList(content string) (*algorithms.Graph, error) {
 		neighbors := strings.Split(neighborStr, ",")
 		for _, neighborStr := range neighbors {
 			neighborStr = strings.TrimSpace(neighborStr)
+
+			// Edges longer than 1 are written as 'neighbor[length]'
+			length := 1
+			if open := strings.Index(neighborStr, "["); open != -1 && strings.HasSuffix(neighborStr, "]") {
+				parsed, err := strconv.Atoi(neighborStr[open+1 : len(neighborStr)-1])
+				if err != nil || parsed < 0 {
+					return nil, fmt.Errorf("invalid edge length: %s", neighborStr)
+				}
+				length, neighborStr = parsed, neighborStr[:open]
+			}
+
 			neighbor, err := strconv.Atoi(neighborStr)
 			if err != nil {
 				return nil, fmt.Errorf("invalid neighbor ID: %s", neighborStr)
@@ -77,15 +96,370 @@ func ParseNeighborList(content string) (*algorithms.Graph, error) {
 				edgeKey = fmt.Sprintf("%d-%d", neighbor, node)
 			}
 
-			if !addedEdges[edgeKey] {
-				err := graph.AddEdge(node, neighbor, 1.0)
+			if previous, added := addedEdges[edgeKey]; !added {
+				err := graph.AddEdge(node, neighbor, float64(length))
 				if err != nil {
 					return nil, fmt.Errorf("error adding edge %d-%d: %v", node, neighbor, err)
 				}
-				addedEdges[edgeKey] = true
+				addedEdges[edgeKey] = length
+			} else if previous != length {
+				return nil, fmt.Errorf("edge %s has length %d from one end and %d from the other", edgeKey, previous, length)
+			}
+		}
+	}
+
+	if len(internalTaxa) > 0 {
+		for _, node := range internalTaxa {
+			graph.SetTaxon(node)
+		}
+	}
+
+	return graph, nil
+}
+
+type newickNode struct {
+	label     string
+	hasLabel  bool
+	length    float64
+	hasLength bool
+	// Support of the branch, from a '[&support=...]' comment
+	support    float64
+	hasSupport bool
+	children   []*newickNode
+}
+
+type newickParser struct {
+	content  string
+	position int
+}
+
+func (p *newickParser) peek() byte {
+	for p.position < len(p.content) && strings.ContainsRune(" \t\r\n", rune(p.content[p.position])) {
+		p.position++
+	}
+	if p.position >= len(p.content) {
+		return 0
+	}
+	return p.content[p.position]
+}
+
+func (p *newickParser) parseLabel() (string, error) {
+	if p.peek() == '\'' {
+		p.position++
+		var label strings.Builder
+		for p.position < len(p.content) {
+			c := p.content[p.position]
+			p.position++
+			if c == '\'' {
+				if p.position < len(p.content) && p.content[p.position] == '\'' {
+					label.WriteByte('\'')
+					p.position++
+					continue
+				}
+				return label.String(), nil
+			}
+			label.WriteByte(c)
+		}
+		return "", fmt.Errorf("unterminated quoted label")
+	}
+
+	start := p.position
+	for p.position < len(p.content) && !strings.ContainsRune("()[]':;, \t\r\n", rune(p.content[p.position])) {
+		p.position++
+	}
+	return p.content[start:p.position], nil
+}
+
+// Skips a bracketed comment, returning its content
+func (p *newickParser) skipComment() (string, error) {
+	if p.peek() != '[' {
+		return "", nil
+	}
+	end := strings.IndexByte(p.content[p.position:], ']')
+	if end == -1 {
+		return "", fmt.Errorf("unterminated comment at position %d", p.position)
+	}
+	comment := p.content[p.position+1 : p.position+end]
+	p.position += end + 1
+	return comment, nil
+}
+
+func (p *newickParser) parseSubtree() (*newickNode, error) {
+	node := &newickNode{}
+
+	if p.peek() == '(' {
+		p.position++
+		for {
+			child, err := p.parseSubtree()
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+
+			c := p.peek()
+			p.position++
+			if c == ')' {
+				break
+			}
+			if c != ',' {
+				return nil, fmt.Errorf("invalid Newick: expected ',' or ')' at position %d", p.position-1)
+			}
+		}
+	}
+
+	if _, err := p.skipComment(); err != nil {
+		return nil, err
+	}
+
+	label, err := p.parseLabel()
+	if err != nil {
+		return nil, err
+	}
+	node.label, node.hasLabel = label, label != ""
+
+	if _, err := p.skipComment(); err != nil {
+		return nil, err
+	}
+
+	if p.peek() == ':' {
+		p.position++
+		p.peek()
+		start := p.position
+		for p.position < len(p.content) && strings.ContainsRune("0123456789.eE+-", rune(p.content[p.position])) {
+			p.position++
+		}
+		length, err := strconv.ParseFloat(p.content[start:p.position], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid branch length at position %d: %v", start, err)
+		}
+		node.length, node.hasLength = length, true
+	}
+
+	comment, err := p.skipComment()
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := strings.CutPrefix(comment, "&support="); ok {
+		node.support, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid support at position %d: %v", p.position, err)
+		}
+		node.hasSupport = true
+	}
+
+	if !node.hasLabel && len(node.children) == 0 {
+		return nil, fmt.Errorf("invalid Newick: unnamed leaf at position %d", p.position)
+	}
+
+	return node, nil
+}
+
+// Parses a tree in Newick format. Nodes named with non-negative integers keep them as
+// node IDs, other named nodes get fresh IDs and keep their names as labels.
+// Named internal nodes are taxa, and leaves on zero-length branches are merged into the
+// node they hang from (as duplicates if it is a taxon). Branches without a length get weight 1.
+// A '[&support=0.75]' comment after a branch length sets the support of the branch.
+func ParseNewick(content string) (*algorithms.Graph, error) {
+	parser := &newickParser{content: strings.TrimSpace(content)}
+	root, err := parser.parseSubtree()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.peek() != ';' {
+		return nil, fmt.Errorf("invalid Newick: expected ';' at position %d", parser.position)
+	}
+
+	graph := &algorithms.Graph{
+		Nodes:    make(map[int]struct{}),
+		Edges:    make(map[int][]algorithms.Edge),
+		AllEdges: make([]algorithms.Edge, 0),
+		MaxNode:  -1,
+		Labels:   make(map[int]string),
+	}
+
+	var nodes []*newickNode
+	var stack = []*newickNode{root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		nodes = append(nodes, node)
+		for i := len(node.children) - 1; i >= 0; i-- {
+			stack = append(stack, node.children[i])
+		}
+	}
+
+	ids := make(map[*newickNode]int)
+	internalTaxa := make([]*newickNode, 0)
+	for _, node := range nodes {
+		if id, err := strconv.Atoi(node.label); err == nil && id >= 0 {
+			if !graph.AddNode(id) {
+				return nil, fmt.Errorf("duplicate node ID in Newick: %d", id)
+			}
+			ids[node] = id
+		}
+	}
+
+	for _, node := range nodes {
+		if _, ok := ids[node]; ok {
+			continue
+		}
+
+		ids[node] = graph.AddNewNode()
+		if node.hasLabel {
+			graph.Labels[ids[node]] = node.label
+		}
+	}
+
+	for _, node := range nodes {
+		if node.hasLabel && len(node.children) > 0 {
+			internalTaxa = append(internalTaxa, node)
+		}
+
+		for _, child := range node.children {
+			weight := 1.0
+			if child.hasLength {
+				weight = child.length
+			}
+
+			if err := graph.AddEdge(ids[node], ids[child], weight); err != nil {
+				return nil, fmt.Errorf("error adding edge %d-%d: %v", ids[node], ids[child], err)
+			}
+			if child.hasSupport {
+				graph.SetEdgeSupport(ids[node], ids[child], child.support)
+			}
+		}
+	}
+
+	// Named internal nodes are taxa lying on the paths between other taxa
+	for _, node := range internalTaxa {
+		if len(graph.Edges[ids[node]]) > 1 {
+			graph.SetTaxon(ids[node])
+		}
+	}
+
+	// Zero-length branches place taxa on internal nodes, or make them duplicates of others
+	for _, edge := range graph.AllEdges {
+		if edge.Weight == 0 {
+			graph.MakeTaxaExplicit()
+			if err := graph.MergeZeroEdges(0); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return graph, nil
+}
+
+// Parses a tree in either neighbor list or Newick format, including the duplicate taxa
+// recorded in a '# duplicates:' header
+func ParseTree(content string) (*algorithms.Graph, error) {
+	var graph *algorithms.Graph
+	var err error
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "(") {
+			graph, err = ParseNewick(StripComments(content))
+		}
+		break
+	}
+
+	if graph == nil && err == nil {
+		graph, err = ParseNeighborList(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates, err := ParseDuplicatesHeader(content)
+	if err != nil {
+		return nil, err
+	}
+	for representative, group := range duplicates {
+		if !graph.IsTaxon(representative) {
+			return nil, fmt.Errorf("duplicates header refers to %d, which is not a taxon", representative)
+		}
+		for _, duplicate := range group {
+			if _, ok := graph.Nodes[duplicate]; ok {
+				return nil, fmt.Errorf("duplicate taxon %d is also a node of the tree", duplicate)
 			}
+			graph.AddDuplicate(representative, duplicate)
 		}
 	}
 
 	return graph, nil
 }
+
+// Removes '#' comment lines (such as the scale header) from a tree file
+func StripComments(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the duplicate taxa recorded in a '# duplicates: 0=3,5; 2=7' header, keyed by
+// the taxon node they are identical to, or nil if there is none
+func ParseDuplicatesHeader(content string) (map[int][]int, error) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		field := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if !strings.HasPrefix(line, "#") || !strings.HasPrefix(field, "duplicates:") {
+			continue
+		}
+
+		duplicates := make(map[int][]int)
+		for _, group := range strings.Split(strings.TrimPrefix(field, "duplicates:"), ";") {
+			parts := strings.Split(group, "=")
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid duplicates header: %s", line)
+			}
+
+			representative, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid duplicates header: %s", line)
+			}
+			for _, duplicateStr := range strings.Split(parts[1], ",") {
+				duplicate, err := strconv.Atoi(strings.TrimSpace(duplicateStr))
+				if err != nil {
+					return nil, fmt.Errorf("invalid duplicates header: %s", line)
+				}
+				duplicates[representative] = append(duplicates[representative], duplicate)
+			}
+		}
+		return duplicates, nil
+	}
+
+	return nil, nil
+}
+
+// Returns the scale factor recorded in a '# scale: <factor>' header, or 1 if there is none
+func ParseScaleHeader(content string) (int, error) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		field := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if !strings.HasPrefix(field, "scale:") {
+			continue
+		}
+
+		scale, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(field, "scale:")))
+		if err != nil || scale < 1 {
+			return 0, fmt.Errorf("invalid scale header: %s", line)
+		}
+		return scale, nil
+	}
+
+	return 1, nil
+}
@@ -0,0 +1,263 @@
+package io
+
+import (
+	"strings"
+	"testing"
+	"treereconstruction/algorithms"
+)
+
+func TestParseNewick(t *testing.T) {
+	tests := []struct {
+		name string
+		input string
+		wantNodes int
+		wantEdges int
+		wantLabels int
+		wantErr bool
+	}{
+		{
+			name: "numbered leaves with branch lengths",
+			input: "((1:0.5,2:1.5):2,3:1)0;",
+			wantNodes: 5,
+			wantEdges: 4,
+			wantLabels: 0,
+			wantErr: false,
+		},
+		{
+			name: "named leaves without branch lengths",
+			input: "(human,(chimp,'orang utan'),gorilla);",
+			wantNodes: 6,
+			wantEdges: 5,
+			wantLabels: 4,
+			wantErr: false,
+		},
+		{
+			name: "missing semicolon",
+			input: "(1:1,2:1)",
+			wantErr: true,
+		},
+		{
+			name: "duplicate node IDs",
+			input: "(1:1,1:1);",
+			wantErr: true,
+		},
+		{
+			name: "unnamed leaf",
+			input: "(1:1,:1);",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseNewick(test.input)
+			if err != nil && !test.wantErr {
+				t.Fatalf("ParseNewick(%q) returned error: %v", test.input, err)
+			} else if err == nil && test.wantErr {
+				t.Fatalf("ParseNewick(%q) was expected to return an error, but got nil", test.input)
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			if len(got.Nodes) != test.wantNodes || len(got.AllEdges) != test.wantEdges || len(got.Labels) != test.wantLabels {
+				t.Errorf("ParseNewick(%q) returned %d nodes, %d edges and %d labels, expected %d, %d and %d",
+					test.input, len(got.Nodes), len(got.AllEdges), len(got.Labels), test.wantNodes, test.wantEdges, test.wantLabels)
+			}
+		})
+	}
+}
+
+func TestNewickRoundTrip(t *testing.T) {
+	input := "(((1:0.5,2:1.5):2,3:1):1)0;"
+	graph, err := ParseNewick(input)
+	if err != nil {
+		t.Fatalf("ParseNewick(%q) returned error: %v", input, err)
+	}
+
+	serialized, err := SerializeAsNewick(graph, 0)
+	if err != nil {
+		t.Fatalf("SerializeAsNewick returned error: %v", err)
+	}
+
+	if serialized != input {
+		t.Errorf("round trip of %q produced %q", input, serialized)
+	}
+}
+
+func TestInternalTaxaRoundTrip(t *testing.T) {
+	input := "0:4;\n1*:2,3,4;\n2:1;\n3:1;\n4:0,1;\n"
+	graph, err := ParseNeighborList(input)
+	if err != nil {
+		t.Fatalf("ParseNeighborList(%q) returned error: %v", input, err)
+	}
+
+	if taxa := graph.InternalTaxa(); len(taxa) != 1 || taxa[0] != 1 {
+		t.Errorf("expected internal taxa [1], got %v", taxa)
+	}
+	if graph.IsTaxon(4) {
+		t.Errorf("expected node 4 not to be a taxon")
+	}
+
+	serialized, err := SerializeChildrenAsNeighborLists(graph)
+	if err != nil {
+		t.Fatalf("SerializeChildrenAsNeighborLists returned error: %v", err)
+	}
+	if serialized != input {
+		t.Errorf("round trip of %q produced %q", input, serialized)
+	}
+
+	newick, err := SerializeAsNewick(graph, 0)
+	if err != nil {
+		t.Fatalf("SerializeAsNewick returned error: %v", err)
+	}
+	parsed, err := ParseNewick(newick)
+	if err != nil {
+		t.Fatalf("ParseNewick(%q) returned error: %v", newick, err)
+	}
+	if taxa := parsed.InternalTaxa(); len(taxa) != 1 || taxa[0] != 1 {
+		t.Errorf("expected internal taxa [1] after Newick round trip of %q, got %v", newick, taxa)
+	}
+}
+
+func TestShortenedNeighborListsRoundTrip(t *testing.T) {
+	input := "0:3[2];\n1:3;\n2:3[4];\n3:0[2],1,2[4];\n"
+	graph, err := ParseNeighborList(input)
+	if err != nil {
+		t.Fatalf("ParseNeighborList(%q) returned error: %v", input, err)
+	}
+	if len(graph.AllEdges) != 3 || graph.AllEdges[0].Weight != 2 {
+		t.Errorf("expected 3 edges, the first of length 2, got %v", graph.AllEdges)
+	}
+
+	serialized, err := SerializeAsShortenedNeighborLists(graph)
+	if err != nil {
+		t.Fatalf("SerializeAsShortenedNeighborLists returned error: %v", err)
+	}
+	if serialized != input {
+		t.Errorf("round trip of %q produced %q", input, serialized)
+	}
+
+	// Splitting the edges spells the tree out with unit edges
+	if err := graph.SplitEdges(1e-6); err != nil {
+		t.Fatalf("SplitEdges returned error: %v", err)
+	}
+	if len(graph.Nodes) != 8 {
+		t.Errorf("expected 8 nodes after splitting edges, got %d", len(graph.Nodes))
+	}
+	if err := graph.ContractUnaryNodes(); err != nil {
+		t.Fatalf("ContractUnaryNodes returned error: %v", err)
+	}
+	if contracted, _ := SerializeAsShortenedNeighborLists(graph); contracted != input {
+		t.Errorf("contracting the split tree produced %q, expected %q", contracted, input)
+	}
+
+	for _, invalid := range []string{"0:1[x];\n1:0[x];\n", "0:1[2];\n1:0[3];\n", "0:1[-1];\n1:0[-1];\n"} {
+		if _, err := ParseNeighborList(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestDuplicateTaxaRoundTrip(t *testing.T) {
+	newick := "((2:1,(1:0,4:0):1)3:2)0;"
+	graph, err := ParseTree(newick)
+	if err != nil {
+		t.Fatalf("ParseTree(%q) returned error: %v", newick, err)
+	}
+
+	if header := FormatDuplicatesHeader(graph); header != "# duplicates: 1=4\n" {
+		t.Errorf("expected duplicates header for %q, got %q", newick, header)
+	}
+
+	serialized, err := SerializeAsNewick(graph, 0)
+	if err != nil {
+		t.Fatalf("SerializeAsNewick returned error: %v", err)
+	}
+	if serialized != newick {
+		t.Errorf("round trip of %q produced %q", newick, serialized)
+	}
+
+	neighborLists := "# duplicates: 0=4,6; 2=7\n0:5;\n1:2,5;\n2:1;\n5:0,1;\n"
+	graph, err = ParseTree(neighborLists)
+	if err != nil {
+		t.Fatalf("ParseTree(%q) returned error: %v", neighborLists, err)
+	}
+
+	if taxa := graph.AllTaxa(); len(taxa) != 5 {
+		t.Errorf("expected 5 taxa in %q, got %v", neighborLists, taxa)
+	}
+	if header := FormatDuplicatesHeader(graph); header != "# duplicates: 0=4,6; 2=7\n" {
+		t.Errorf("expected duplicates header of %q to round trip, got %q", neighborLists, header)
+	}
+}
+
+func TestSupportRoundTrip(t *testing.T) {
+	input := "((1:1,2:1):2[&support=0.75],3:1,4:1)0;"
+	graph, err := ParseNewick(input)
+	if err != nil {
+		t.Fatalf("ParseNewick(%q) returned error: %v", input, err)
+	}
+	if support, ok := graph.EdgeSupport(0, 5); !ok || support != 0.75 || graph.IsTaxon(5) {
+		t.Errorf("expected edge 0-5 with support 0.75 to an unnamed node, got %v", graph.Support)
+	}
+
+	serialized, err := SerializeAsNewick(graph, 0)
+	if err != nil {
+		t.Fatalf("SerializeAsNewick returned error: %v", err)
+	}
+	if serialized != input {
+		t.Errorf("round trip of %q produced %q", input, serialized)
+	}
+
+	if dot := SerializeAsDot(graph, nil); !strings.Contains(dot, "[label=\"2 (0.75)\"]") {
+		t.Errorf("expected the supported edge to be labelled with its support, got %q", dot)
+	}
+}
+
+func TestScaleHeaderRoundTrip(t *testing.T) {
+	// A star with edges 0.5, 1.5 and 1.5 is written with its weights doubled
+	matrix := [][]uint32{{0, 2, 2}, {2, 0, 3}, {2, 3, 0}}
+	tree, scale, err := algorithms.ReconstructScaledIntTree(matrix, 1e-10, 1000)
+	if err != nil {
+		t.Fatalf("ReconstructScaledIntTree returned error: %v", err)
+	}
+
+	serialized, err := SerializeGraph(tree, SerializationTypeNeighborLists)
+	if err != nil {
+		t.Fatalf("SerializeGraph returned error: %v", err)
+	}
+	content := FormatScaleHeader(scale) + serialized
+
+	parsedScale, err := ParseScaleHeader(content)
+	if err != nil {
+		t.Fatalf("ParseScaleHeader(%q) returned error: %v", content, err)
+	}
+	if parsedScale != 2 {
+		t.Errorf("expected scale 2 in %q, got %d", content, parsedScale)
+	}
+
+	parsed, err := ParseTree(content)
+	if err != nil {
+		t.Fatalf("ParseTree(%q) returned error: %v", content, err)
+	}
+	distances, err := algorithms.CalculateDistanceMatrix(parsed)
+	if err != nil {
+		t.Fatalf("CalculateDistanceMatrix returned error: %v", err)
+	}
+	for i := range matrix {
+		for j := range matrix {
+			if distances[i][j] != int(matrix[i][j])*parsedScale {
+				t.Errorf("d(%d,%d)=%d in the parsed tree, expected %d * %d", i, j, distances[i][j], matrix[i][j], parsedScale)
+			}
+		}
+	}
+
+	if _, err := ParseScaleHeader("# scale: 0\n0:1;\n1:0;\n"); err == nil {
+		t.Errorf("expected an error for a scale of 0")
+	}
+	if scale, err := ParseScaleHeader("0:1;\n1:0;\n"); err != nil || scale != 1 {
+		t.Errorf("expected scale 1 without a header, got %d (%v)", scale, err)
+	}
+}
@@ -0,0 +1,145 @@
+package io
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Sequence struct {
+	Name     string
+	Residues string
+}
+
+// Parses a multiple sequence alignment in FASTA or (sequential) PHYLIP format.
+// The format is detected from the first non-empty line.
+func ParseAlignment(fileContent string) ([]Sequence, error) {
+	content := strings.TrimSpace(fileContent)
+	if content == "" {
+		return nil, errors.New("empty alignment")
+	}
+
+	var sequences []Sequence
+	var err error
+	if strings.HasPrefix(content, ">") {
+		sequences, err = ParseFasta(content)
+	} else {
+		sequences, err = ParsePhylip(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sequences) < 2 {
+		return nil, fmt.Errorf("alignment must contain at least 2 sequences, got %d", len(sequences))
+	}
+
+	names := make(map[string]struct{})
+	for _, sequence := range sequences {
+		if _, ok := names[sequence.Name]; ok {
+			return nil, fmt.Errorf("duplicate sequence name: %s", sequence.Name)
+		}
+		names[sequence.Name] = struct{}{}
+
+		if len(sequence.Residues) != len(sequences[0].Residues) {
+			return nil, fmt.Errorf("sequence %s has length %d, but sequence %s has length %d",
+				sequence.Name, len(sequence.Residues), sequences[0].Name, len(sequences[0].Residues))
+		}
+	}
+
+	return sequences, nil
+}
+
+// Parses a FASTA alignment. Sequences may span multiple lines.
+func ParseFasta(fileContent string) ([]Sequence, error) {
+	var sequences []Sequence
+	var residues strings.Builder
+
+	for _, line := range strings.Split(fileContent, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, ">") {
+			if len(sequences) > 0 {
+				sequences[len(sequences)-1].Residues = residues.String()
+				residues.Reset()
+			}
+
+			name := strings.TrimSpace(strings.TrimPrefix(line, ">"))
+			if fields := strings.Fields(name); len(fields) > 0 {
+				name = fields[0]
+			}
+			if name == "" {
+				return nil, fmt.Errorf("sequence %d has no name", len(sequences))
+			}
+
+			sequences = append(sequences, Sequence{Name: name})
+			continue
+		}
+
+		if len(sequences) == 0 {
+			return nil, fmt.Errorf("invalid format: sequence data before the first '>' header: %s", line)
+		}
+
+		residues.WriteString(strings.ToUpper(strings.Join(strings.Fields(line), "")))
+	}
+
+	if len(sequences) > 0 {
+		sequences[len(sequences)-1].Residues = residues.String()
+	}
+
+	return sequences, nil
+}
+
+// Parses a sequential PHYLIP alignment: a header with the number of sequences and sites,
+// followed by one line per sequence starting with its name.
+func ParsePhylip(fileContent string) ([]Sequence, error) {
+	lines := strings.Split(fileContent, "\n")
+
+	header := strings.Fields(lines[0])
+	if len(header) < 2 {
+		return nil, fmt.Errorf("invalid PHYLIP header: expected sequence and site counts, got: %s", lines[0])
+	}
+
+	count, err := strconv.Atoi(header[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid sequence count in PHYLIP header: %s", header[0])
+	}
+
+	length, err := strconv.Atoi(header[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid site count in PHYLIP header: %s", header[1])
+	}
+
+	var sequences []Sequence
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid PHYLIP line: expected name and residues, got: %s", line)
+		}
+
+		sequences = append(sequences, Sequence{
+			Name:     fields[0],
+			Residues: strings.ToUpper(strings.Join(fields[1:], "")),
+		})
+	}
+
+	if len(sequences) != count {
+		return nil, fmt.Errorf("PHYLIP header declares %d sequences, but %d were found", count, len(sequences))
+	}
+
+	for _, sequence := range sequences {
+		if len(sequence.Residues) != length {
+			return nil, fmt.Errorf("PHYLIP header declares %d sites, but sequence %s has %d", length, sequence.Name, len(sequence.Residues))
+		}
+	}
+
+	return sequences, nil
+}
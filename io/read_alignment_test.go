@@ -0,0 +1,61 @@
+package io
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseAlignment(t *testing.T) {
+	tests := []struct {
+		name string
+		input string
+		want []Sequence
+		wantErr bool
+	}{
+		{
+			name: "fasta with multi-line sequences",
+			input: ">a first\nACGT\nacgt\n>b\nACGTACGA\n",
+			want: []Sequence{{"a", "ACGTACGT"}, {"b", "ACGTACGA"}},
+			wantErr: false,
+		},
+		{
+			name: "phylip",
+			input: "2 4\na ACGT\nb AC-T\n",
+			want: []Sequence{{"a", "ACGT"}, {"b", "AC-T"}},
+			wantErr: false,
+		},
+		{
+			name: "sequences of different lengths",
+			input: ">a\nACGT\n>b\nACG\n",
+			want: nil,
+			wantErr: true,
+		},
+		{
+			name: "phylip with wrong sequence count",
+			input: "3 4\na ACGT\nb ACGT\n",
+			want: nil,
+			wantErr: true,
+		},
+		{
+			name: "single sequence",
+			input: ">a\nACGT\n",
+			want: nil,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseAlignment(test.input)
+			if err != nil && !test.wantErr {
+				t.Errorf("ParseAlignment(%q) returned error: %v", test.input, err)
+			} else if err == nil && test.wantErr {
+				t.Errorf("ParseAlignment(%q) was expected to return an error, but got nil", test.input)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("ParseAlignment(%q) returned incorrect sequences: expected %v, got %v", test.input, test.want, got)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package io
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -46,3 +47,214 @@ func ParseMatrix(fileContent string) ([][]uint32, error) {
 
 	return matrix, nil
 }
+
+// Checks that a square matrix is symmetric with a zero diagonal
+func checkDistanceMatrix(matrix [][]float64) error {
+	for i := range matrix {
+		if matrix[i][i] != 0 {
+			return fmt.Errorf("distance %v on the diagonal at row %d is not 0", matrix[i][i], i)
+		}
+		for j := 0; j < i; j++ {
+			if matrix[i][j] != matrix[j][i] {
+				return fmt.Errorf("matrix is not symmetric at row %d, column %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// Parses a distance matrix with real-valued (e.g. decimal) entries, which must be symmetric
+// with a zero diagonal
+func ParseFloatMatrix(fileContent string) ([][]float64, error) {
+	matrix, err := ParseUncheckedFloatMatrix(fileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkDistanceMatrix(matrix); err != nil {
+		return nil, err
+	}
+
+	return matrix, nil
+}
+
+// Parses a square matrix of real-valued entries without checking that it is symmetric with
+// a zero diagonal, for reporting such problems along with others
+func ParseUncheckedFloatMatrix(fileContent string) ([][]float64, error) {
+	lines := strings.Split(strings.TrimSpace(fileContent), "\n")
+	matrix := [][]float64{}
+
+	for i, line := range lines {
+		fields := strings.Split(line, ",")
+		row := make([]float64, len(fields))
+		for j, field := range fields {
+			field = strings.TrimSpace(field)
+			num, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid distance %q at row %d, column %d: %v", field, i, j, err)
+			}
+
+			if num < 0 || math.IsNaN(num) || math.IsInf(num, 0) {
+				return nil, fmt.Errorf("invalid distance %q at row %d, column %d", field, i, j)
+			}
+
+			row[j] = num
+		}
+		matrix = append(matrix, row)
+	}
+
+	if len(matrix) == 0 || len(matrix[0]) == 0 {
+		return nil, errors.New("empty matrix")
+	}
+
+	if len(matrix) != len(matrix[0]) {
+		return nil, errors.New("matrix is not square")
+	}
+
+	for i, row := range matrix {
+		if len(row) != len(matrix) {
+			return nil, fmt.Errorf("row %d has %d elements, but row 0 has %d", i, len(row), len(matrix[0]))
+		}
+	}
+
+	return matrix, nil
+}
+
+// Parses a real-valued distance matrix whose rows start with the label of their taxon, as
+// in 'A,0,3,5'. An optional header row with an empty first field (',A,B,C') must list the
+// same labels in the same order.
+func ParseLabelledMatrix(fileContent string) ([]string, [][]float64, error) {
+	lines := strings.Split(strings.TrimSpace(fileContent), "\n")
+	var header []string
+	if fields := strings.Split(lines[0], ","); strings.TrimSpace(fields[0]) == "" {
+		for _, field := range fields[1:] {
+			header = append(header, strings.TrimSpace(field))
+		}
+		lines = lines[1:]
+	}
+
+	labels := make([]string, 0, len(lines))
+	rows := make([]string, 0, len(lines))
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		label, row, found := strings.Cut(line, ",")
+		label = strings.TrimSpace(label)
+		if !found || label == "" {
+			return nil, nil, fmt.Errorf("row %d does not start with a label", i)
+		}
+		if seen[label] {
+			return nil, nil, fmt.Errorf("label %s is used by several rows", label)
+		}
+		seen[label] = true
+		labels = append(labels, label)
+		rows = append(rows, row)
+	}
+
+	if header != nil && strings.Join(header, ",") != strings.Join(labels, ",") {
+		return nil, nil, errors.New("header does not list the row labels in order")
+	}
+
+	matrix, err := ParseFloatMatrix(strings.Join(rows, "\n"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return labels, matrix, nil
+}
+
+// Parses a single comma-separated row of real-valued distances
+func ParseDistanceRow(fileContent string) ([]float64, error) {
+	content := strings.TrimSpace(fileContent)
+	if content == "" {
+		return nil, errors.New("empty distance row")
+	}
+
+	if strings.Contains(content, "\n") {
+		return nil, errors.New("distance row must be a single line")
+	}
+
+	fields := strings.Split(content, ",")
+	row := make([]float64, len(fields))
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		num, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if num < 0 || math.IsNaN(num) || math.IsInf(num, 0) {
+			return nil, fmt.Errorf("invalid distance %q at column %d", field, i)
+		}
+
+		row[i] = num
+	}
+
+	return row, nil
+}
+
+// Returns true if the field denotes an unmeasured distance
+func IsMissingValue(field string) bool {
+	return field == "" || field == "?"
+}
+
+// Parses a distance matrix in which some entries may be missing ('?' or empty).
+// Missing entries are returned as NaN. An entry that is given only on one side of the
+// diagonal is copied to the other side, and missing diagonal entries are set to 0.
+func ParsePartialMatrix(fileContent string) ([][]float64, error) {
+	if strings.TrimSpace(fileContent) == "" {
+		return nil, errors.New("empty matrix")
+	}
+
+	lines := strings.Split(strings.TrimSpace(fileContent), "\n")
+	matrix := [][]float64{}
+
+	for i, line := range lines {
+		fields := strings.Split(line, ",")
+		row := make([]float64, len(fields))
+		for j, field := range fields {
+			field = strings.TrimSpace(field)
+			if IsMissingValue(field) {
+				row[j] = math.NaN()
+				continue
+			}
+
+			num, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, err
+			}
+
+			if num < 0 || math.IsNaN(num) || math.IsInf(num, 0) {
+				return nil, fmt.Errorf("invalid distance %q at row %d, column %d", field, i, j)
+			}
+
+			row[j] = num
+		}
+		matrix = append(matrix, row)
+	}
+
+	if len(matrix) != len(matrix[0]) {
+		return nil, errors.New("matrix is not square")
+	}
+
+	for i, row := range matrix {
+		if len(row) != len(matrix) {
+			return nil, fmt.Errorf("row %d has %d elements, but row 0 has %d", i, len(row), len(matrix[0]))
+		}
+	}
+
+	for i := range matrix {
+		if math.IsNaN(matrix[i][i]) {
+			matrix[i][i] = 0
+		}
+
+		for j := range matrix {
+			if math.IsNaN(matrix[i][j]) {
+				matrix[i][j] = matrix[j][i]
+			} else if !math.IsNaN(matrix[j][i]) && matrix[i][j] != matrix[j][i] {
+				return nil, fmt.Errorf("matrix is not symmetric at row %d, column %d", i, j)
+			}
+		}
+	}
+
+	return matrix, nil
+}
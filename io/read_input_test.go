@@ -1,6 +1,8 @@
 package io
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -90,3 +92,156 @@ func TestParseMatrix(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFloatMatrix(t *testing.T) {
+	tests := []struct {
+		name string
+		input string
+		want [][]float64
+		wantErr bool
+	}{
+		{
+			name: "decimal matrix",
+			input: "0,0.125,1\n0.125,0,1.5\n1,1.5,0\n",
+			want: [][]float64{{0, 0.125, 1}, {0.125, 0, 1.5}, {1, 1.5, 0}},
+			wantErr: false,
+		},
+		{
+			name: "empty matrix",
+			input: "",
+			want: nil,
+			wantErr: true,
+		},
+		{
+			name: "non-square matrix",
+			input: "0,0.5\n0.5,0\n1,1",
+			want: nil,
+			wantErr: true,
+		},
+		{
+			name: "matrix with negative values",
+			input: "0,-0.5\n-0.5,0",
+			want: nil,
+			wantErr: true,
+		},
+		{
+			name: "matrix with a non-numeric value",
+			input: "0,0.5\nx,0",
+			want: nil,
+			wantErr: true,
+		},
+		{
+			name: "asymmetric matrix",
+			input: "0,0.5,1\n0.5,0,1.5\n1,1.25,0",
+			want: nil,
+			wantErr: true,
+		},
+		{
+			name: "matrix with a non-zero diagonal",
+			input: "0,0.5\n0.5,0.1",
+			want: nil,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseFloatMatrix(test.input)
+			if err != nil && !test.wantErr {
+				t.Errorf("ParseFloatMatrix(%q) returned error: %v", test.input, err)
+			} else if err == nil && test.wantErr {
+				t.Errorf("ParseFloatMatrix(%q) was expected to return an error, but got nil", test.input)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("ParseFloatMatrix(%q) returned incorrect matrix: expected %v, got %v", test.input, test.want, got)
+			}
+		})
+	}
+}
+
+func TestParseFloatMatrixErrorPosition(t *testing.T) {
+	_, err := ParseFloatMatrix("0,0.5\nx,0")
+	if err == nil || !strings.Contains(err.Error(), "row 1, column 0") {
+		t.Errorf("expected an error naming row 1, column 0, got %v", err)
+	}
+}
+
+func TestParseUncheckedFloatMatrix(t *testing.T) {
+	got, err := ParseUncheckedFloatMatrix("0.5,1\n2,0")
+	if err != nil {
+		t.Fatalf("ParseUncheckedFloatMatrix returned error: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint([][]float64{{0.5, 1}, {2, 0}}) {
+		t.Errorf("ParseUncheckedFloatMatrix returned incorrect matrix: %v", got)
+	}
+}
+
+func TestParsePartialMatrix(t *testing.T) {
+	got, err := ParsePartialMatrix("0,3,?\n3,0,\n5,,?")
+	if err != nil {
+		t.Fatalf("ParsePartialMatrix returned error: %v", err)
+	}
+
+	want := "[[0 3 5] [3 0 NaN] [5 NaN 0]]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("ParsePartialMatrix returned incorrect matrix: expected %v, got %v", want, got)
+	}
+
+	if _, err := ParsePartialMatrix("0,3\n4,0"); err == nil {
+		t.Errorf("ParsePartialMatrix was expected to reject an asymmetric matrix, but got nil")
+	}
+}
+
+func TestParseLabelledMatrix(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantLabels []string
+		want       [][]float64
+		wantErr    bool
+	}{
+		{
+			name:       "labelled rows",
+			input:      "A,0,2,3\nB,2,0,1.5\nC,3,1.5,0\n",
+			wantLabels: []string{"A", "B", "C"},
+			want:       [][]float64{{0, 2, 3}, {2, 0, 1.5}, {3, 1.5, 0}},
+		},
+		{
+			name:       "header row",
+			input:      ",A,B\nA,0,2\nB,2,0",
+			wantLabels: []string{"A", "B"},
+			want:       [][]float64{{0, 2}, {2, 0}},
+		},
+		{
+			name:    "header in another order",
+			input:   ",B,A\nA,0,2\nB,2,0",
+			wantErr: true,
+		},
+		{
+			name:    "repeated label",
+			input:   "A,0,2\nA,2,0",
+			wantErr: true,
+		},
+		{
+			name:    "unlabelled rows",
+			input:   "0,2\n2,0",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			labels, got, err := ParseLabelledMatrix(test.input)
+			if err != nil && !test.wantErr {
+				t.Errorf("ParseLabelledMatrix(%q) returned error: %v", test.input, err)
+			} else if err == nil && test.wantErr {
+				t.Errorf("ParseLabelledMatrix(%q) was expected to return an error, but got nil", test.input)
+			}
+
+			if fmt.Sprint(labels) != fmt.Sprint(test.wantLabels) || fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("ParseLabelledMatrix(%q) returned %v and %v, expected %v and %v", test.input, labels, got, test.wantLabels, test.want)
+			}
+		})
+	}
+}
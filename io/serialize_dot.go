@@ -0,0 +1,68 @@
+package io
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"treereconstruction/algorithms"
+)
+
+// Serializes the graph in Graphviz DOT format as an undirected graph. Taxa are drawn as
+// boxes named by their labels or IDs (together with their duplicates), other nodes as
+// points. Edges are labelled with the given labels, keyed by their nodes in ascending
+// order, or with their weights followed by their support, if any.
+func SerializeAsDot(graph *algorithms.Graph, edgeLabels map[[2]int]string) string {
+	var nodes = make([]int, 0, len(graph.Nodes))
+	for node := range graph.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Ints(nodes)
+
+	var builder strings.Builder
+	builder.WriteString("graph {\n")
+	builder.WriteString("  node [shape=point];\n")
+	for _, node := range nodes {
+		if !graph.IsTaxon(node) {
+			fmt.Fprintf(&builder, "  %d;\n", node)
+			continue
+		}
+
+		var names = []string{graph.NodeName(node)}
+		for _, duplicate := range graph.Duplicates[node] {
+			names = append(names, graph.NodeName(duplicate))
+		}
+		fmt.Fprintf(&builder, "  %d [shape=box, label=%s];\n", node, formatDotString(strings.Join(names, ", ")))
+	}
+
+	var edges = append([]algorithms.Edge{}, graph.AllEdges...)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Node1 != edges[j].Node1 {
+			return edges[i].Node1 < edges[j].Node1
+		}
+		return edges[i].Node2 < edges[j].Node2
+	})
+	for _, edge := range edges {
+		var node1, node2 = edge.Node1, edge.Node2
+		if node1 > node2 {
+			node1, node2 = node2, node1
+		}
+
+		var label, ok = edgeLabels[[2]int{node1, node2}]
+		if !ok {
+			label = FormatBranchLength(edge.Weight)
+			if support, ok := graph.EdgeSupport(node1, node2); ok {
+				label += " (" + FormatBranchLength(support) + ")"
+			}
+		}
+		fmt.Fprintf(&builder, "  %d -- %d [label=%s];\n", node1, node2, formatDotString(label))
+	}
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// Quotes a string for use as a DOT attribute value
+func formatDotString(value string) string {
+	return "\"" + strings.ReplaceAll(strings.ReplaceAll(value, "\\", "\\\\"), "\"", "\\\"") + "\""
+}
@@ -15,8 +15,36 @@ const (
 	SerializationTypeBrackets SerializationType = iota
 	SerializationTypeBracketsShortened
 	SerializationTypeNeighborLists
+	SerializationTypeNewick
+	SerializationTypeDot
+	SerializationTypeNeighborListsShortened
 )
 
+// Parses a serialization type name as accepted by the --serialization flags
+func ParseSerializationType(name string) (SerializationType, error) {
+	switch name {
+	case "brackets":
+		return SerializationTypeBrackets, nil
+	case "brackets-shortened":
+		return SerializationTypeBracketsShortened, nil
+	case "neighbor-lists":
+		return SerializationTypeNeighborLists, nil
+	case "neighbor-lists-shortened":
+		return SerializationTypeNeighborListsShortened, nil
+	case "newick":
+		return SerializationTypeNewick, nil
+	case "dot":
+		return SerializationTypeDot, nil
+	default:
+		return 0, fmt.Errorf("invalid serialization type: %s", name)
+	}
+}
+
+// Returns true if the serialization type can only represent integer edge weights
+func RequiresIntegerWeights(serializationType SerializationType) bool {
+	return serializationType != SerializationTypeNewick && serializationType != SerializationTypeDot
+}
+
 func MakePrefixSuffix(incomingEdgeLength int, useShortenedSyntax bool) (string, string) {
 	if !useShortenedSyntax || incomingEdgeLength == 1 {
 		return strings.Repeat("(", incomingEdgeLength), strings.Repeat(")", incomingEdgeLength)
@@ -24,40 +52,50 @@ func MakePrefixSuffix(incomingEdgeLength int, useShortenedSyntax bool) (string,
 	return "[" + strconv.Itoa(incomingEdgeLength) + "](", ")"
 }
 
-func SerializeChildrenAsBrackets(
-	graph *algorithms.Graph,
-	node int,
-	alreadySerialized *map[int]struct{},
-	incomingEdgeLength int,
-	useShortenedSyntax bool,
-) (string, error) {
-	if _, ok := (*alreadySerialized)[node]; ok {
-		return "", nil
+// Serializes the tree as nested brackets hanging from the given root, one pair of brackets
+// per unit of edge length (or '[length](...)' with the shortened syntax)
+func SerializeAsBrackets(graph *algorithms.Graph, root int, useShortenedSyntax bool) (string, error) {
+	tree, err := algorithms.NewRootedTree(graph, root)
+	if err != nil {
+		return "", err
 	}
 
-	(*alreadySerialized)[node] = struct{}{}
-	var result, suffix = MakePrefixSuffix(incomingEdgeLength, useShortenedSyntax)
-	for _, edge := range graph.Edges[node] {
-		var otherNode = edge.Node1
-		if otherNode == node {
-			otherNode = edge.Node2
+	var serialized = make([]string, tree.Size())
+	for _, index := range tree.PostOrder() {
+		var incomingEdgeLength = 1
+		if tree.Parent[index] != -1 {
+			incomingEdgeLength = int(math.Round(tree.ParentWeight[index]))
 		}
+		var result, suffix = MakePrefixSuffix(incomingEdgeLength, useShortenedSyntax)
 
-		if _, ok := (*alreadySerialized)[otherNode]; ok {
-			continue
+		// Internal taxa are marked by using braces for their own pair of brackets
+		var node = tree.Nodes[index]
+		if graph.Taxa != nil && graph.IsTaxon(node) && len(graph.Edges[node]) > 1 {
+			result = strings.TrimSuffix(result, "(") + "{"
+			suffix = "}" + strings.TrimPrefix(suffix, ")")
 		}
 
-		var inside, err = SerializeChildrenAsBrackets(graph, otherNode, alreadySerialized, int(math.Round(edge.Weight)), useShortenedSyntax)
-		if err != nil {
-			return "", err
+		for _, child := range tree.Children[index] {
+			result += serialized[child]
+			serialized[child] = ""
 		}
-
-		result += inside
+		serialized[index] = result + suffix
 	}
-	return result + suffix, nil
+
+	return serialized[0], nil
 }
 
 func SerializeChildrenAsNeighborLists(graph *algorithms.Graph) (string, error) {
+	return serializeNeighborLists(graph, false)
+}
+
+// Serializes the graph as neighbor lists in which edges longer than 1 are written as
+// 'neighbor[length]', so that chains of degree-2 nodes need not be spelled out
+func SerializeAsShortenedNeighborLists(graph *algorithms.Graph) (string, error) {
+	return serializeNeighborLists(graph, true)
+}
+
+func serializeNeighborLists(graph *algorithms.Graph, useShortenedSyntax bool) (string, error) {
 	var allNodes = make([]int, 0)
 	for node := range graph.Nodes {
 		allNodes = append(allNodes, node)
@@ -66,17 +104,23 @@ func SerializeChildrenAsNeighborLists(graph *algorithms.Graph) (string, error) {
 
 	var result = ""
 	for _, node := range allNodes {
-		result += fmt.Sprintf("%d:", node)
+		if graph.Taxa != nil && graph.IsTaxon(node) && len(graph.Edges[node]) > 1 {
+			result += fmt.Sprintf("%d*:", node)
+		} else {
+			result += fmt.Sprintf("%d:", node)
+		}
 
 		var edges = graph.Edges[node]
 
 		var neighbors = make([]int, 0)
+		var lengths = make(map[int]int)
 		for _, edge := range edges {
 			var otherNode = edge.Node1
 			if otherNode == node {
 				otherNode = edge.Node2
 			}
 			neighbors = append(neighbors, otherNode)
+			lengths[otherNode] = int(math.Round(edge.Weight))
 		}
 		sort.Ints(neighbors)
 
@@ -84,32 +128,164 @@ func SerializeChildrenAsNeighborLists(graph *algorithms.Graph) (string, error) {
 			return "", fmt.Errorf("node %d has no neighbors", node)
 		}
 
-		for _, neighbor := range neighbors[:len(neighbors)-1] {
-			result += fmt.Sprintf("%d,", neighbor)
+		var formatted = make([]string, len(neighbors))
+		for i, neighbor := range neighbors {
+			formatted[i] = strconv.Itoa(neighbor)
+			if useShortenedSyntax && lengths[neighbor] != 1 {
+				formatted[i] += "[" + strconv.Itoa(lengths[neighbor]) + "]"
+			}
 		}
-		result += fmt.Sprintf("%d;\n", neighbors[len(neighbors)-1])
+		result += strings.Join(formatted, ",") + ";\n"
 	}
 
 	return result, nil
 }
 
+// Quotes a Newick node label if it contains characters with special meaning
+func FormatNewickLabel(label string) string {
+	if !strings.ContainsAny(label, " \t()[]':;,") {
+		return label
+	}
+	return "'" + strings.ReplaceAll(label, "'", "''") + "'"
+}
+
+// Formats an edge weight as a Newick branch length
+func FormatBranchLength(weight float64) string {
+	return strconv.FormatFloat(weight, 'g', 10, 64)
+}
+
+// Serializes a node of the tree in Newick format, given the serializations of its children
+func serializeNewickNode(tree *algorithms.RootedTree, index int, children []string) string {
+	var graph, node = tree.Graph, tree.Nodes[index]
+	var isRoot = tree.Parent[index] == -1
+
+	// Duplicates of the taxon are zero-length leaves next to it
+	var duplicates = make([]string, 0)
+	for _, duplicate := range graph.Duplicates[node] {
+		duplicates = append(duplicates, FormatNewickLabel(graph.NodeName(duplicate))+":0")
+	}
+
+	var result = ""
+	if len(children) == 0 && len(duplicates) > 0 && !isRoot {
+		// A leaf with duplicates becomes a polytomy of zero-length leaves
+		var leaf = FormatNewickLabel(graph.NodeName(node)) + ":0"
+		return "(" + strings.Join(append([]string{leaf}, duplicates...), ",") + "):" + FormatBranchLength(tree.ParentWeight[index])
+	}
+
+	children = append(children, duplicates...)
+	if len(children) > 0 {
+		result = "(" + strings.Join(children, ",") + ")"
+	}
+
+	if _, labelled := graph.Labels[node]; labelled || graph.IsTaxon(node) || len(graph.Edges[node]) <= 1 {
+		result += FormatNewickLabel(graph.NodeName(node))
+	}
+
+	if !isRoot {
+		result += ":" + FormatBranchLength(tree.ParentWeight[index])
+		// Support goes in a comment, since named internal nodes would be read back as taxa
+		if support, ok := graph.EdgeSupport(node, tree.Nodes[tree.Parent[index]]); ok {
+			result += "[&support=" + FormatBranchLength(support) + "]"
+		}
+	}
+
+	return result
+}
+
+// Serializes the tree in Newick format with branch lengths, starting from the given node.
+// Leaves and internal taxa are named by their labels or node IDs, other internal nodes
+// are named only if labelled. Duplicate taxa are written as zero-length leaves, and edge
+// support as a '[&support=0.75]' comment after the branch length.
+func SerializeAsNewick(graph *algorithms.Graph, root int) (string, error) {
+	tree, err := algorithms.NewRootedTree(graph, root)
+	if err != nil {
+		return "", err
+	}
+
+	var serialized = make([]string, tree.Size())
+	for _, index := range tree.PostOrder() {
+		var children = make([]string, len(tree.Children[index]))
+		for i, child := range tree.Children[index] {
+			children[i] = serialized[child]
+			serialized[child] = ""
+		}
+		serialized[index] = serializeNewickNode(tree, index, children)
+	}
+
+	return serialized[0] + ";", nil
+}
+
 func SerializeGraph(graph *algorithms.Graph, serializationType SerializationType) (string, error) {
+	return SerializeRootedGraph(graph, serializationType, 0)
+}
+
+// Serializes the graph starting from the given root. Neighbor lists and DOT do not depict
+// a root, so it is ignored for them.
+func SerializeRootedGraph(graph *algorithms.Graph, serializationType SerializationType, root int) (string, error) {
 	switch serializationType {
 	case SerializationTypeBrackets:
-		return SerializeChildrenAsBrackets(graph, 0, &map[int]struct{}{}, 1, false)
+		return SerializeAsBrackets(graph, root, false)
 	case SerializationTypeBracketsShortened:
-		return SerializeChildrenAsBrackets(graph, 0, &map[int]struct{}{}, 1, true)
+		return SerializeAsBrackets(graph, root, true)
 	case SerializationTypeNeighborLists:
 		err := graph.SplitEdges(1e-6)
 		if err != nil {
 			return "", err
 		}
 		return SerializeChildrenAsNeighborLists(graph)
+	case SerializationTypeNeighborListsShortened:
+		if !graph.IsIntegerWeighted(1e-6) {
+			return "", fmt.Errorf("graph has non-integer edge weights")
+		}
+		return SerializeAsShortenedNeighborLists(graph)
+	case SerializationTypeNewick:
+		return SerializeAsNewick(graph, root)
+	case SerializationTypeDot:
+		return SerializeAsDot(graph, nil), nil
 	default:
 		return "", fmt.Errorf("invalid serialization type: %d", serializationType)
 	}
 }
 
+// Returns a comment line recording the factor by which all edge weights were multiplied
+func FormatScaleHeader(scale int) string {
+	return fmt.Sprintf("# scale: %d\n", scale)
+}
+
+// Returns a comment line listing the duplicate taxa of each taxon node, e.g.
+// '# duplicates: 0=3,5; 2=7', or an empty string if there are none
+func FormatDuplicatesHeader(graph *algorithms.Graph) string {
+	var groups = graph.DuplicateGroups()
+	if len(groups) == 0 {
+		return ""
+	}
+
+	var formatted = make([]string, len(groups))
+	for i, group := range groups {
+		var duplicates = make([]string, len(group)-1)
+		for j, duplicate := range group[1:] {
+			duplicates[j] = strconv.Itoa(duplicate)
+		}
+		formatted[i] = fmt.Sprintf("%d=%s", group[0], strings.Join(duplicates, ","))
+	}
+
+	return fmt.Sprintf("# duplicates: %s\n", strings.Join(formatted, "; "))
+}
+
+// Formats duplicate groups as '{0,3,5}, {2,7}'
+func FormatDuplicateGroups(graph *algorithms.Graph) string {
+	var groups = graph.DuplicateGroups()
+	var formatted = make([]string, len(groups))
+	for i, group := range groups {
+		var names = make([]string, len(group))
+		for j, taxon := range group {
+			names[j] = graph.NodeName(taxon)
+		}
+		formatted[i] = "{" + strings.Join(names, ",") + "}"
+	}
+	return strings.Join(formatted, ", ")
+}
+
 // Returns a formatted summary of the tree structure
 func GetTreeSummary(graph *algorithms.Graph) []string {
 	totalNodes := len(graph.Nodes)
@@ -137,9 +313,113 @@ func GetTreeSummary(graph *algorithms.Graph) []string {
 	}
 	degreeDistribution := strings.Join(degreeStrings, ", ")
 
+	nodesSummary := fmt.Sprintf("Nodes: %d total, %d leaves", totalNodes, leafCount)
+	if internalTaxa := len(graph.InternalTaxa()); internalTaxa > 0 {
+		nodesSummary += fmt.Sprintf(", %d internal taxa", internalTaxa)
+	}
+	if duplicates := len(graph.AllTaxa()) - len(graph.TaxonNodes()); duplicates > 0 {
+		nodesSummary += fmt.Sprintf(", %d duplicate taxa", duplicates)
+	}
+
 	return []string{
-		fmt.Sprintf("Nodes: %d total, %d leaves", totalNodes, leafCount),
+		nodesSummary,
 		fmt.Sprintf("Edges: %d", len(graph.AllEdges)),
 		fmt.Sprintf("Degrees: %s", degreeDistribution),
 	}
 }
+
+// Describes the differences between two trees, one per line, naming the trees in the lines
+// that refer to one of them
+func DescribeTreeDiff(diff *algorithms.TreeDiff, tree1, tree2 *algorithms.Graph, name1, name2 string) []string {
+	var lines = make([]string, 0)
+	var taxa = func(graph *algorithms.Graph, taxa []int) string {
+		var names = make([]string, len(taxa))
+		for i, taxon := range taxa {
+			names[i] = graph.NodeName(taxon)
+		}
+		return strings.Join(names, ",")
+	}
+	var attachment = func(graph *algorithms.Graph, nodes []int) string {
+		switch len(nodes) {
+		case 1:
+			return "node " + graph.NodeName(nodes[0])
+		case 2:
+			return fmt.Sprintf("edge (%s)", taxa(graph, nodes))
+		default:
+			return fmt.Sprintf("nodes (%s)", taxa(graph, nodes))
+		}
+	}
+	var split = func(graph *algorithms.Graph, side []int) string {
+		return fmt.Sprintf("{%s} | rest", taxa(graph, diff.SmallerPart(side)))
+	}
+
+	if len(diff.OnlyInFirst) > 0 {
+		lines = append(lines, fmt.Sprintf("taxa only in the %s tree: %s", name1, taxa(tree1, diff.OnlyInFirst)))
+	}
+	if len(diff.OnlyInSecond) > 0 {
+		lines = append(lines, fmt.Sprintf("taxa only in the %s tree: %s", name2, taxa(tree2, diff.OnlyInSecond)))
+	}
+
+	for _, moved := range diff.MovedTaxa {
+		var kind = "taxon"
+		if location := tree1.TaxonLocation(moved.Taxon); len(tree1.Edges[location]) == 1 {
+			kind = "leaf"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s attached to %s instead of %s", kind, tree1.NodeName(moved.Taxon),
+			attachment(tree1, moved.FirstAttachment), attachment(tree2, moved.SecondAttachment)))
+	}
+
+	for _, only := range []struct {
+		splits []algorithms.Split
+		graph  *algorithms.Graph
+		name   string
+	}{{diff.SplitsOnlyInFirst, tree1, name1}, {diff.SplitsOnlyInSecond, tree2, name2}} {
+		for _, s := range only.splits {
+			lines = append(lines, fmt.Sprintf("split %s (length %s) only in the %s tree", split(only.graph, s.Side), FormatBranchLength(s.Weight), only.name))
+		}
+	}
+
+	for _, changed := range diff.ChangedSplits {
+		lines = append(lines, fmt.Sprintf("edge %s has length %s instead of %s", split(tree1, changed.Side),
+			FormatBranchLength(changed.FirstWeight), FormatBranchLength(changed.SecondWeight)))
+	}
+
+	if len(diff.CommonSubtrees) > 0 {
+		var subtrees = make([]string, len(diff.CommonSubtrees))
+		for i, subtree := range diff.CommonSubtrees {
+			subtrees[i] = "{" + taxa(tree1, subtree) + "}"
+		}
+		lines = append(lines, fmt.Sprintf("common subtrees: %s", strings.Join(subtrees, ", ")))
+	}
+
+	return lines
+}
+
+// Describes an SPR move in terms of taxa, as 'move {3,4} onto the edge between {7,8} and
+// {0,1,2,5,6}' or 'move {3,4} onto the node joining {0,1}, {2} and {5,6,7,8}'
+func DescribeSPRMove(move algorithms.SPRMove, graph *algorithms.Graph) string {
+	var parts = make([]string, 0, len(move.Target)+1)
+	for _, taxa := range append([][]int{move.Subtree}, move.Target...) {
+		var names = make([]string, len(taxa))
+		for i, taxon := range taxa {
+			names[i] = graph.NodeName(taxon)
+		}
+		parts = append(parts, "{"+strings.Join(names, ",")+"}")
+	}
+
+	var subtree, target = parts[0], parts[1:]
+	if len(target) == 2 {
+		return fmt.Sprintf("move %s onto the edge between %s and %s", subtree, target[0], target[1])
+	}
+	return fmt.Sprintf("move %s onto the node joining %s and %s", subtree, strings.Join(target[:len(target)-1], ", "), target[len(target)-1])
+}
+
+// Describes a split of an input tree that a supertree does not display, as 'split {3,4} |
+// rest of trees/a.txt is not in the supertree'
+func DescribeSupertreeConflict(conflict algorithms.SupertreeConflict, supertree *algorithms.Graph, treeName string) string {
+	var names = make([]string, len(conflict.Side))
+	for i, taxon := range conflict.Side {
+		names[i] = supertree.NodeName(taxon)
+	}
+	return fmt.Sprintf("split {%s} | rest of %s is not in the supertree", strings.Join(names, ","), treeName)
+}